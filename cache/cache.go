@@ -0,0 +1,158 @@
+// Package cache implements the read-through cache in front of the stores.
+// Values are stored JSON-encoded in a Backend: an in-process LRU, or a
+// server speaking the Redis protocol when instances should share entries.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend holds encoded values until they expire or are deleted
+type Backend interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Observer is told how each lookup ended: "hit", "miss" or "error"
+type Observer interface {
+	ObserveCache(name, result string)
+}
+
+// Cache loads values on a miss and stores them in its backend. Concurrent
+// misses of the same key share a single load, so an expired entry does not
+// send a stampede of queries to the database.
+//
+// A backend that fails is logged and bypassed: the cache only ever makes
+// reads faster, never makes them fail.
+type Cache struct {
+	Backend  Backend
+	Observer Observer // Told about every lookup, may be nil
+
+	mu    sync.Mutex
+	calls map[string]*call
+	// epoch advances with every invalidation, so that a load which started
+	// before it does not store what may be an outdated value. It only
+	// covers loads in this process: with a shared backend, another
+	// instance may still store a value it loaded before the invalidation.
+	epoch uint64
+}
+
+// call is a load in progress
+type call struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+// New creates a cache on backend
+func New(backend Backend) *Cache {
+	return &Cache{
+		Backend: backend,
+		calls:   make(map[string]*call),
+	}
+}
+
+// Fetch returns the value cached under key, or loads it, caches it for ttl
+// and returns it. Keys are written "name:id", e.g. "recipe:12"; the name
+// labels the lookup for the observer. Errors of load are returned and not
+// cached.
+func Fetch[T any](ctx context.Context, c *Cache, key string, ttl time.Duration, load func(context.Context) (T, error)) (T, error) {
+	var value T
+	data, err := c.fetch(ctx, key, ttl, func(ctx context.Context) ([]byte, error) {
+		loaded, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(loaded)
+	})
+	if err != nil {
+		return value, err
+	}
+	// Every caller decodes a copy of its own, so none can change another's
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
+
+func (c *Cache) fetch(ctx context.Context, key string, ttl time.Duration, load func(context.Context) ([]byte, error)) ([]byte, error) {
+	name, _, _ := strings.Cut(key, ":")
+	data, ok, err := c.Backend.Get(ctx, key)
+	switch {
+	case err != nil:
+		slog.WarnContext(ctx, "cache lookup failed", "key", key, "error", err)
+		c.observe(name, "error")
+	case ok:
+		c.observe(name, "hit")
+		return data, nil
+	default:
+		c.observe(name, "miss")
+	}
+
+	c.mu.Lock()
+	if cl, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-cl.done:
+			return cl.value, cl.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	cl := &call{done: make(chan struct{})}
+	c.calls[key] = cl
+	epoch := c.epoch
+	c.mu.Unlock()
+
+	// The load is shared, so one caller giving up must not fail the others
+	cl.value, cl.err = load(context.WithoutCancel(ctx))
+
+	c.mu.Lock()
+	if c.calls[key] == cl {
+		delete(c.calls, key)
+	}
+	current := c.epoch == epoch
+	c.mu.Unlock()
+
+	if cl.err == nil && current {
+		if err := c.Backend.Set(ctx, key, cl.value, ttl); err != nil {
+			slog.WarnContext(ctx, "cache store failed", "key", key, "error", err)
+		}
+	}
+	close(cl.done)
+	return cl.value, cl.err
+}
+
+// invalidateTimeout bounds the deletion of invalidated keys
+const invalidateTimeout = time.Second
+
+// Invalidate removes keys after the values they hold have changed. Loads of
+// the keys already in progress are not cached or shared with later callers.
+//
+// It usually runs once the change is committed, so the deletion outlives
+// ctx: a client that gives up at that point must not leave the old values
+// cached until they expire.
+func (c *Cache) Invalidate(ctx context.Context, keys ...string) {
+	c.mu.Lock()
+	c.epoch++
+	for _, key := range keys {
+		delete(c.calls, key)
+	}
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), invalidateTimeout)
+	defer cancel()
+	if err := c.Backend.Delete(ctx, keys...); err != nil {
+		slog.ErrorContext(ctx, "cache invalidation failed", "keys", keys, "error", err)
+	}
+}
+
+func (c *Cache) observe(name, result string) {
+	if c.Observer != nil {
+		c.Observer.ObserveCache(name, result)
+	}
+}
@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type item struct {
+	Name string
+	Tags []string
+}
+
+// brokenBackend fails every call
+type brokenBackend struct{}
+
+func (brokenBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return nil, false, errors.New("connection refused")
+}
+
+func (brokenBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return errors.New("connection refused")
+}
+
+func (brokenBackend) Delete(ctx context.Context, keys ...string) error {
+	return errors.New("connection refused")
+}
+
+// contextBackend fails calls whose context is done, like a network backend
+type contextBackend struct {
+	*LRU
+}
+
+func (b contextBackend) Delete(ctx context.Context, keys ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		return errors.New("no deadline")
+	}
+	return b.LRU.Delete(ctx, keys...)
+}
+
+type lookups map[string]int
+
+func (l lookups) ObserveCache(name, result string) {
+	l[name+" "+result]++
+}
+
+func TestFetch(t *testing.T) {
+	ctx := context.Background()
+	c := New(NewLRU(10))
+	observed := lookups{}
+	c.Observer = observed
+	loads := 0
+	load := func(ctx context.Context) (*item, error) {
+		loads++
+		return &item{Name: "shiro", Tags: []string{"vegan"}}, nil
+	}
+
+	first, err := Fetch(ctx, c, "item:1", time.Minute, load)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Tags[0] = "changed"
+	second, err := Fetch(ctx, c, "item:1", time.Minute, load)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loads != 1 {
+		t.Errorf("loaded %d times, want once", loads)
+	}
+	if second.Tags[0] != "vegan" {
+		t.Error("a caller's change to its value reached the cache")
+	}
+	if observed["item miss"] != 1 || observed["item hit"] != 1 {
+		t.Errorf("observed %v, want one miss and one hit", observed)
+	}
+
+	c.Invalidate(ctx, "item:1")
+	Fetch(ctx, c, "item:1", time.Minute, load)
+	if loads != 2 {
+		t.Errorf("loaded %d times, want a reload after invalidation", loads)
+	}
+
+	// Errors are returned and not cached
+	failing := func(ctx context.Context) (*item, error) { return nil, errors.New("not found") }
+	for i := 0; i < 2; i++ {
+		if _, err := Fetch(ctx, c, "item:2", time.Minute, failing); err == nil {
+			t.Fatal("load error was not returned")
+		}
+	}
+}
+
+func TestFetchCoalescesConcurrentMisses(t *testing.T) {
+	c := New(NewLRU(10))
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (string, error) {
+		loads.Add(1)
+		<-release
+		return "shiro", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := Fetch(context.Background(), c, "item:1", time.Minute, load); err != nil || got != "shiro" {
+				t.Errorf("Fetch = %q, %v", got, err)
+			}
+		}()
+	}
+	// Let the callers pile up behind the first load
+	for {
+		c.mu.Lock()
+		started := len(c.calls) == 1
+		c.mu.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := loads.Load(); n != 1 {
+		t.Errorf("loaded %d times, want once", n)
+	}
+}
+
+func TestFetchDoesNotCacheLoadsRacingInvalidation(t *testing.T) {
+	ctx := context.Background()
+	backend := NewLRU(10)
+	c := New(backend)
+
+	Fetch(ctx, c, "item:1", time.Minute, func(ctx context.Context) (string, error) {
+		// The value changes while the old one is being loaded
+		c.Invalidate(ctx, "item:1")
+		return "old", nil
+	})
+	if _, ok, _ := backend.Get(ctx, "item:1"); ok {
+		t.Error("value loaded before an invalidation was cached")
+	}
+}
+
+func TestFetchBypassesBrokenBackend(t *testing.T) {
+	c := New(brokenBackend{})
+	observed := lookups{}
+	c.Observer = observed
+
+	got, err := Fetch(context.Background(), c, "item:1", time.Minute, func(ctx context.Context) (string, error) {
+		return "shiro", nil
+	})
+	if err != nil || got != "shiro" {
+		t.Errorf("Fetch = %q, %v, want the loaded value", got, err)
+	}
+	if observed["item error"] != 1 {
+		t.Errorf("observed %v, want an error", observed)
+	}
+	c.Invalidate(context.Background(), "item:1")
+}
+
+func TestInvalidateOutlivesContext(t *testing.T) {
+	lru := NewLRU(10)
+	c := New(contextBackend{lru})
+	if _, err := Fetch(context.Background(), c, "item:1", time.Minute, func(ctx context.Context) (string, error) {
+		return "shiro", nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The client went away after the change was committed
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Invalidate(ctx, "item:1")
+	if _, ok, _ := lru.Get(context.Background(), "item:1"); ok {
+		t.Error("invalidated entry is still cached")
+	}
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU is an in-process Backend holding at most a fixed number of entries.
+// When it is full, the least recently used entry is evicted.
+type LRU struct {
+	// Now returns the current time; tests may replace it to expire entries
+	Now func() time.Time
+
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // Most recently used first
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRU creates an LRU holding up to capacity entries
+func NewLRU(capacity int) *LRU {
+	return &LRU{
+		Now:      time.Now,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get implements Backend
+func (l *LRU) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*lruEntry)
+	if !l.Now().Before(entry.expires) {
+		l.remove(elem)
+		return nil, false, nil
+	}
+	l.order.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+// Set implements Backend
+func (l *LRU) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	expires := l.Now().Add(ttl)
+	if elem, ok := l.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value, entry.expires = value, expires
+		l.order.MoveToFront(elem)
+		return nil
+	}
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	for l.order.Len() > l.capacity {
+		l.remove(l.order.Back())
+	}
+	return nil
+}
+
+// Delete implements Backend
+func (l *LRU) Delete(ctx context.Context, keys ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := l.entries[key]; ok {
+			l.remove(elem)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of entries held, including expired ones not yet
+// looked up
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+// remove drops an entry. The caller must hold l.mu.
+func (l *LRU) remove(elem *list.Element) {
+	l.order.Remove(elem)
+	delete(l.entries, elem.Value.(*lruEntry).key)
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	l := NewLRU(2)
+	l.Now = func() time.Time { return now }
+
+	l.Set(ctx, "a", []byte("1"), time.Minute)
+	l.Set(ctx, "b", []byte("2"), time.Minute)
+	l.Get(ctx, "a") // b is now the least recently used
+	l.Set(ctx, "c", []byte("3"), time.Minute)
+
+	if _, ok, _ := l.Get(ctx, "b"); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	if value, ok, _ := l.Get(ctx, "a"); !ok || string(value) != "1" {
+		t.Errorf("a = %q, %v, want 1", value, ok)
+	}
+
+	l.Delete(ctx, "a")
+	if _, ok, _ := l.Get(ctx, "a"); ok {
+		t.Error("deleted entry is still held")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok, _ := l.Get(ctx, "c"); ok {
+		t.Error("expired entry was returned")
+	}
+	if n := l.Len(); n != 0 {
+		t.Errorf("%d entries held, want none", n)
+	}
+}
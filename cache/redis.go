@@ -0,0 +1,262 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRedisTimeout bounds each command when the context has no earlier
+// deadline
+const DefaultRedisTimeout = time.Second
+
+// Redis is a Backend on a server speaking the Redis protocol (RESP), such
+// as Redis, Valkey or KeyDB. Entries expire on the server, so instances
+// sharing the server share their entries and see each other's
+// invalidations.
+type Redis struct {
+	Addr     string
+	Password string
+	DB       int
+	Timeout  time.Duration // Per-command timeout, DefaultRedisTimeout if zero
+
+	idle chan *redisConn
+}
+
+// errNil is a nil reply, e.g. to GET of a missing key
+var errNil = errors.New("nil reply")
+
+// redisError is an error reply from the server
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// NewRedis creates a Redis backend from a URL such as
+// redis://:password@localhost:6379/0. Connections are opened on demand and
+// up to maxIdle of them are kept open between commands.
+func NewRedis(rawURL string, maxIdle int) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" || u.Host == "" {
+		return nil, fmt.Errorf("%q is not a redis:// URL", rawURL)
+	}
+	r := &Redis{
+		Addr:    u.Host,
+		Timeout: DefaultRedisTimeout,
+		idle:    make(chan *redisConn, maxIdle),
+	}
+	if u.Port() == "" {
+		r.Addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if password, ok := u.User.Password(); ok {
+		r.Password = password
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if r.DB, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("database %q is not a number", db)
+		}
+	}
+	return r, nil
+}
+
+// Get implements Backend
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", key)
+	if err == errNil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected reply %T to GET", reply)
+	}
+	return value, true, nil
+}
+
+// Set implements Backend
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := r.do(ctx, "SET", key, string(value), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+// Delete implements Backend
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := r.do(ctx, "DEL", keys...)
+	return err
+}
+
+// Ping checks that the server is reachable, for health checks
+func (r *Redis) Ping(ctx context.Context) error {
+	_, err := r.do(ctx, "PING")
+	return err
+}
+
+// Close closes the idle connections
+func (r *Redis) Close() error {
+	for {
+		select {
+		case conn := <-r.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do sends a command and reads its reply. Error and nil replies are
+// returned as errors; the connection stays usable after them.
+func (r *Redis) do(ctx context.Context, command string, args ...string) (interface{}, error) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRedisTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	conn, err := r.conn(ctx, deadline)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.do(deadline, command, args...)
+	var replyErr redisError
+	if err != nil && err != errNil && !errors.As(err, &replyErr) {
+		// The connection is in an unknown state
+		conn.Close()
+		return nil, err
+	}
+	r.release(conn)
+	return reply, err
+}
+
+// conn returns an idle connection or dials a new one
+func (r *Redis) conn(ctx context.Context, deadline time.Time) (*redisConn, error) {
+	select {
+	case conn := <-r.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Deadline: deadline}
+	netConn, err := dialer.DialContext(ctx, "tcp", r.Addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: netConn, reader: bufio.NewReader(netConn)}
+	if r.Password != "" {
+		if _, err := conn.do(deadline, "AUTH", r.Password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if r.DB != 0 {
+		if _, err := conn.do(deadline, "SELECT", strconv.Itoa(r.DB)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// release keeps conn for the next command, or closes it if enough
+// connections are idle
+func (r *Redis) release(conn *redisConn) {
+	select {
+	case r.idle <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *redisConn) do(deadline time.Time, command string, args ...string) (interface{}, error) {
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if _, err := c.Write(encodeCommand(command, args...)); err != nil {
+		return nil, err
+	}
+	return readReply(c.reader)
+}
+
+// encodeCommand encodes a command as a RESP array of bulk strings
+func encodeCommand(command string, args ...string) []byte {
+	buf := []byte("*" + strconv.Itoa(len(args)+1) + "\r\n")
+	for _, arg := range append([]string{command}, args...) {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	return buf
+}
+
+// readReply reads one RESP reply: a string for simple strings, an int64
+// for integers, a []byte for bulk strings and an []interface{} for arrays.
+// Error replies are returned as redisError and nil replies as errNil.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, rest := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return rest, nil
+	case '-':
+		return nil, redisError(rest)
+	case ':':
+		return strconv.ParseInt(rest, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(rest)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", rest)
+		}
+		if n < 0 {
+			return nil, errNil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(rest)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed array length %q", rest)
+		}
+		if n < 0 {
+			return nil, errNil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil && err != errNil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
@@ -0,0 +1,217 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a local stand-in for a Redis server. It speaks enough of
+// the protocol for the Redis backend: AUTH, SELECT, PING, GET, SET with PX
+// and DEL.
+type fakeRedis struct {
+	password string
+
+	mu      sync.Mutex
+	dbs     map[int]map[string]fakeEntry
+	now     time.Time
+	conns   int
+	closers []net.Conn
+}
+
+type fakeEntry struct {
+	value   string
+	expires time.Time
+}
+
+func startFakeRedis(t *testing.T, password string) (*fakeRedis, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{password: password, dbs: make(map[int]map[string]fakeEntry), now: time.Unix(1700000000, 0)}
+	t.Cleanup(func() {
+		ln.Close()
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for _, conn := range f.closers {
+			conn.Close()
+		}
+	})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.conns++
+			f.closers = append(f.closers, conn)
+			f.mu.Unlock()
+			go f.serve(conn)
+		}
+	}()
+	return f, ln.Addr().String()
+}
+
+func (f *fakeRedis) advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	f.mu.Unlock()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := f.password == ""
+	db := 0
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+		items, _ := reply.([]interface{})
+		var args []string
+		for _, item := range items {
+			b, _ := item.([]byte)
+			args = append(args, string(b))
+		}
+		if len(args) == 0 {
+			fmt.Fprint(conn, "-ERR empty command\r\n")
+			continue
+		}
+
+		command := strings.ToUpper(args[0])
+		if !authed && command != "AUTH" {
+			fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		f.mu.Lock()
+		entries := f.dbs[db]
+		if entries == nil {
+			entries = make(map[string]fakeEntry)
+			f.dbs[db] = entries
+		}
+		switch command {
+		case "AUTH":
+			if args[1] != f.password {
+				fmt.Fprint(conn, "-WRONGPASS invalid password\r\n")
+				break
+			}
+			authed = true
+			fmt.Fprint(conn, "+OK\r\n")
+		case "SELECT":
+			db, _ = strconv.Atoi(args[1])
+			fmt.Fprint(conn, "+OK\r\n")
+		case "PING":
+			fmt.Fprint(conn, "+PONG\r\n")
+		case "GET":
+			entry, ok := entries[args[1]]
+			if !ok || !f.now.Before(entry.expires) {
+				fmt.Fprint(conn, "$-1\r\n")
+				break
+			}
+			fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(entry.value), entry.value)
+		case "SET":
+			ms, _ := strconv.Atoi(args[4])
+			entries[args[1]] = fakeEntry{value: args[2], expires: f.now.Add(time.Duration(ms) * time.Millisecond)}
+			fmt.Fprint(conn, "+OK\r\n")
+		case "DEL":
+			deleted := 0
+			for _, key := range args[1:] {
+				if _, ok := entries[key]; ok {
+					delete(entries, key)
+					deleted++
+				}
+			}
+			fmt.Fprintf(conn, ":%d\r\n", deleted)
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+		}
+		f.mu.Unlock()
+	}
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	server, addr := startFakeRedis(t, "hunter2")
+	r, err := NewRedis("redis://:hunter2@"+addr+"/2", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if err := r.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if _, ok, err := r.Get(ctx, "recipe:1"); ok || err != nil {
+		t.Fatalf("Get of a missing key = %v, %v, want a miss", ok, err)
+	}
+	value := []byte("{\"title\":\"Shiro\"}\r\n")
+	if err := r.Set(ctx, "recipe:1", value, time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, ok, err := r.Get(ctx, "recipe:1"); !ok || err != nil || string(got) != string(value) {
+		t.Fatalf("Get = %q, %v, %v, want %q", got, ok, err, value)
+	}
+	server.mu.Lock()
+	_, selected := server.dbs[2]["recipe:1"]
+	server.mu.Unlock()
+	if !selected {
+		t.Error("entry was not stored in the database of the URL")
+	}
+
+	if err := r.Delete(ctx, "recipe:1", "recipe:2"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok, _ := r.Get(ctx, "recipe:1"); ok {
+		t.Error("deleted entry is still held")
+	}
+
+	r.Set(ctx, "categories", []byte("[]"), time.Minute)
+	server.advance(time.Minute)
+	if _, ok, _ := r.Get(ctx, "categories"); ok {
+		t.Error("expired entry was returned")
+	}
+
+	server.mu.Lock()
+	conns := server.conns
+	server.mu.Unlock()
+	if conns != 1 {
+		t.Errorf("opened %d connections, want the first one reused", conns)
+	}
+}
+
+func TestRedisErrors(t *testing.T) {
+	ctx := context.Background()
+	_, addr := startFakeRedis(t, "hunter2")
+
+	r, err := NewRedis("redis://:wrong@"+addr, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Ping(ctx); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("Ping with a wrong password = %v, want WRONGPASS", err)
+	}
+
+	for _, url := range []string{"http://cache:6379", "redis://", "redis://cache/one"} {
+		if _, err := NewRedis(url, 2); err == nil {
+			t.Errorf("NewRedis(%q) succeeded", url)
+		}
+	}
+
+	// A backend that cannot be reached makes Fetch fall back to loading
+	r, _ = NewRedis("redis://127.0.0.1:1", 2)
+	c := New(r)
+	got, err := Fetch(ctx, c, "recipe:1", time.Minute, func(ctx context.Context) (string, error) { return "shiro", nil })
+	if err != nil || got != "shiro" {
+		t.Errorf("Fetch = %q, %v, want the loaded value", got, err)
+	}
+}
@@ -0,0 +1,51 @@
+// Command mediagc deletes uploaded images that are no longer referenced by
+// any recipe.
+//
+// Usage:
+//
+//	DATABASE_URL=postgres://... mediagc [-dry-run] [-grace 24h] [-dir uploads]
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"backend-app/media"
+	"backend-app/store"
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "list orphaned media without deleting anything")
+	grace := flag.Duration("grace", 24*time.Hour, "how long media must be unreferenced before it is deleted")
+	dir := flag.String("dir", "uploads", "directory holding uploaded media")
+	flag.Parse()
+
+	db, err := sql.Open("postgres", os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	sweeper := media.NewSweeper(media.NewStore(*dir), store.NewPostgresMediaStore(db), *grace)
+	sweeper.DryRun = *dryRun
+
+	paths, err := sweeper.Sweep(context.Background())
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+	if err != nil {
+		log.Fatalf("Failed to sweep media: %v", err)
+	}
+
+	if *dryRun {
+		fmt.Printf("%d orphaned media files would be deleted\n", len(paths))
+	} else {
+		fmt.Printf("%d orphaned media files deleted\n", len(paths))
+	}
+}
@@ -1,28 +1,547 @@
+// Package config loads the application configuration from an optional YAML
+// or TOML file, applies environment variable overrides and validates the
+// result.
 package config
 
 import (
-    "os"
-    "fmt"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration structure
 type Config struct {
-    DatabaseURL string `json:"DatabaseURL"`
-    Port        string `json:"Port"`
+	Server     ServerConfig     `yaml:"server" toml:"server"`
+	Database   DatabaseConfig   `yaml:"database" toml:"database"`
+	Auth       AuthConfig       `yaml:"auth" toml:"auth"`
+	Uploads    UploadConfig     `yaml:"uploads" toml:"uploads"`
+	CORS       CORSConfig       `yaml:"cors" toml:"cors"`
+	Security   SecurityConfig   `yaml:"security" toml:"security"`
+	Media      MediaConfig      `yaml:"media" toml:"media"`
+	Log        LogConfig        `yaml:"log" toml:"log"`
+	Tracing    TracingConfig    `yaml:"tracing" toml:"tracing"`
+	RateLimit  RateLimitConfig  `yaml:"rate_limit" toml:"rate_limit"`
+	Cache      CacheConfig      `yaml:"cache" toml:"cache"`
+	ReadCache  ReadCacheConfig  `yaml:"read_cache" toml:"read_cache"`
+	Trash      TrashConfig      `yaml:"trash" toml:"trash"`
+	Publishing PublishingConfig `yaml:"publishing" toml:"publishing"`
+}
+
+// ServerConfig configures the HTTP listener
+type ServerConfig struct {
+	// Addr is the address the API listens on, e.g. ":8080"
+	Addr              string   `yaml:"addr" toml:"addr" env:"LISTEN_ADDR"`
+	ReadTimeout       Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	WriteTimeout      Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout       Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	MaxHeaderBytes    int      `yaml:"max_header_bytes" toml:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES"`
+	// ShutdownTimeout bounds how long in-flight requests may take to finish
+	// after SIGTERM
+	ShutdownTimeout Duration  `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+	TLS             TLSConfig `yaml:"tls" toml:"tls"`
+}
+
+// TLSConfig enables HTTPS when both files are set. The certificate is
+// reloaded on SIGHUP.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file" toml:"cert_file" env:"TLS_CERT_FILE"`
+	KeyFile  string `yaml:"key_file" toml:"key_file" env:"TLS_KEY_FILE"`
+}
+
+// Enabled reports whether the server should serve HTTPS
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+// DatabaseConfig configures the PostgreSQL connection pool
+type DatabaseConfig struct {
+	URL string `yaml:"url" toml:"url" env:"DATABASE_URL"`
+	// Password, if set, replaces the password in URL so the URL itself
+	// does not have to be kept secret
+	Password        Secret   `yaml:"password" toml:"password" env:"DB_PASSWORD"`
+	MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+	// QueryTimeout bounds every store call
+	QueryTimeout Duration `yaml:"query_timeout" toml:"query_timeout" env:"DB_QUERY_TIMEOUT"`
+	// ConnectTimeout bounds how long startup waits for the database to
+	// become reachable
+	ConnectTimeout Duration `yaml:"connect_timeout" toml:"connect_timeout" env:"DB_CONNECT_TIMEOUT"`
+	// ReplicaURLs are read replicas serving recipe and category browsing.
+	// Password applies to them as well.
+	ReplicaURLs []string `yaml:"replica_urls" toml:"replica_urls" env:"DATABASE_REPLICA_URLS"`
+	// ReadYourWritesWindow is how long a user's reads go to the primary
+	// after they write. Only the instance that served the write knows of
+	// it.
+	ReadYourWritesWindow Duration `yaml:"read_your_writes_window" toml:"read_your_writes_window" env:"DB_READ_YOUR_WRITES_WINDOW"`
+	// ReplicaCheckInterval is how often replica health is checked
+	ReplicaCheckInterval Duration `yaml:"replica_check_interval" toml:"replica_check_interval" env:"DB_REPLICA_CHECK_INTERVAL"`
+}
+
+// AuthConfig configures token signing
+type AuthConfig struct {
+	// JWTKeys are the HMAC keys accepted for tokens. The first key signs new
+	// tokens; the others are still accepted so keys can be rotated. Keys
+	// are reloaded on SIGHUP.
+	JWTKeys  []Secret      `yaml:"jwt_keys" toml:"jwt_keys" env:"JWT_KEYS"`
+	TokenTTL Duration      `yaml:"token_ttl" toml:"token_ttl" env:"JWT_TOKEN_TTL"`
+	Session  SessionConfig `yaml:"session" toml:"session"`
+}
+
+// SessionConfig configures cookie sessions for browser clients. When
+// enabled, POST /login?session=true sets an HttpOnly session cookie and
+// returns a CSRF token that must be sent in X-CSRF-Token with every
+// request that changes state.
+type SessionConfig struct {
+	Enabled    bool   `yaml:"enabled" toml:"enabled" env:"SESSION_ENABLED"`
+	CookieName string `yaml:"cookie_name" toml:"cookie_name" env:"SESSION_COOKIE_NAME"`
+	// CSRFCookieName is the cookie the frontend reads the CSRF token from
+	CSRFCookieName string `yaml:"csrf_cookie_name" toml:"csrf_cookie_name" env:"SESSION_CSRF_COOKIE_NAME"`
+	// Secure restricts the cookies to HTTPS
+	Secure bool `yaml:"secure" toml:"secure" env:"SESSION_COOKIE_SECURE"`
+	// SameSite is "lax", "strict" or "none"; "none" is needed when the
+	// frontend runs on another site
+	SameSite string `yaml:"same_site" toml:"same_site" env:"SESSION_COOKIE_SAME_SITE"`
+}
+
+// UploadConfig configures image uploads
+type UploadConfig struct {
+	Dir string `yaml:"dir" toml:"dir" env:"UPLOAD_DIR"`
+	// MaxRequestBytes bounds the size of a whole upload request
+	MaxRequestBytes int64 `yaml:"max_request_bytes" toml:"max_request_bytes" env:"UPLOAD_MAX_REQUEST_BYTES"`
+	// MaxMemoryBytes bounds the part of a multipart form held in memory;
+	// the rest is spooled to temporary files
+	MaxMemoryBytes int64 `yaml:"max_memory_bytes" toml:"max_memory_bytes" env:"UPLOAD_MAX_MEMORY_BYTES"`
+}
+
+// CORSConfig configures cross-origin requests. CORS is disabled when no
+// origins are allowed.
+type CORSConfig struct {
+	AllowedOrigins   []string `yaml:"allowed_origins" toml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string `yaml:"allowed_methods" toml:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string `yaml:"allowed_headers" toml:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders   []string `yaml:"exposed_headers" toml:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+	AllowCredentials bool     `yaml:"allow_credentials" toml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           Duration `yaml:"max_age" toml:"max_age" env:"CORS_MAX_AGE"`
+}
+
+// SecurityConfig configures the security headers set on every response.
+// Empty settings leave their header out.
+type SecurityConfig struct {
+	ContentSecurityPolicy string `yaml:"content_security_policy" toml:"content_security_policy" env:"SECURITY_CONTENT_SECURITY_POLICY"`
+	// HSTSMaxAge is the max-age of Strict-Transport-Security; zero leaves
+	// the header out
+	HSTSMaxAge            Duration `yaml:"hsts_max_age" toml:"hsts_max_age" env:"SECURITY_HSTS_MAX_AGE"`
+	HSTSIncludeSubdomains bool     `yaml:"hsts_include_subdomains" toml:"hsts_include_subdomains" env:"SECURITY_HSTS_INCLUDE_SUBDOMAINS"`
+	ReferrerPolicy        string   `yaml:"referrer_policy" toml:"referrer_policy" env:"SECURITY_REFERRER_POLICY"`
+}
+
+// MediaConfig configures the collection of unreferenced uploads
+type MediaConfig struct {
+	GCInterval  Duration `yaml:"gc_interval" toml:"gc_interval" env:"MEDIA_GC_INTERVAL"`
+	GracePeriod Duration `yaml:"grace_period" toml:"grace_period" env:"MEDIA_GC_GRACE_PERIOD"`
+}
+
+// TrashConfig configures how long deleted recipes, categories and accounts
+// are kept before they are purged
+type TrashConfig struct {
+	// Retention is how long deleted records can be restored
+	Retention     Duration `yaml:"retention" toml:"retention" env:"TRASH_RETENTION"`
+	PurgeInterval Duration `yaml:"purge_interval" toml:"purge_interval" env:"TRASH_PURGE_INTERVAL"`
+}
+
+// PublishingConfig configures the worker that publishes scheduled recipes
+type PublishingConfig struct {
+	// Interval is how often scheduled recipes are checked, and so how late
+	// they may be published
+	Interval Duration `yaml:"interval" toml:"interval" env:"PUBLISH_INTERVAL"`
+}
+
+// LogConfig configures the structured logger
+type LogConfig struct {
+	// Format is "json" or "text"
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+	// Level is "debug", "info", "warn" or "error"
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+}
+
+// TracingConfig configures OpenTelemetry tracing. Tracing is disabled when
+// no endpoint is set.
+type TracingConfig struct {
+	// Endpoint is the URL of the OTLP/HTTP collector, e.g.
+	// http://localhost:4318
+	Endpoint    string `yaml:"endpoint" toml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	ServiceName string `yaml:"service_name" toml:"service_name" env:"OTEL_SERVICE_NAME"`
+	// SampleRatio is the fraction of traces started here that are
+	// recorded; requests that carry a sampled parent are always recorded
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// Enabled reports whether spans should be exported
+func (t TracingConfig) Enabled() bool {
+	return t.Endpoint != ""
+}
+
+// RateLimitConfig configures per-client rate limits. Clients are
+// authenticated users, or IP addresses for anonymous requests.
+type RateLimitConfig struct {
+	// Store is "memory" to limit each instance on its own or "postgres" to
+	// share limits between instances
+	Store string `yaml:"store" toml:"store" env:"RATE_LIMIT_STORE"`
+	// TrustForwardedFor takes client IPs from X-Forwarded-For, for
+	// deployments behind a reverse proxy
+	TrustForwardedFor bool `yaml:"trust_forwarded_for" toml:"trust_forwarded_for" env:"RATE_LIMIT_TRUST_FORWARDED_FOR"`
+	// Default applies to every route not listed in Routes
+	Default Rate `yaml:"default" toml:"default" env:"RATE_LIMIT_DEFAULT"`
+	// Routes sets the rate of individual routes, keyed by route template
+	Routes map[string]Rate `yaml:"routes" toml:"routes"`
+}
+
+// CacheConfig sets the Cache-Control header of successful GET responses.
+// Other responses are never cached.
+type CacheConfig struct {
+	// Default applies to every route not listed in Routes
+	Default string `yaml:"default" toml:"default" env:"CACHE_CONTROL_DEFAULT"`
+	// Routes sets the policy of individual routes, keyed by route template
+	Routes map[string]string `yaml:"routes" toml:"routes"`
+}
+
+// ReadCacheConfig configures the read-through cache in front of recipe
+// and category reads
+type ReadCacheConfig struct {
+	// Backend is "memory" for a cache in each instance, "redis" for one
+	// shared by all instances or "off"
+	Backend string `yaml:"backend" toml:"backend" env:"READ_CACHE_BACKEND"`
+	// Size is the number of entries the memory backend holds
+	Size int `yaml:"size" toml:"size" env:"READ_CACHE_SIZE"`
+	// RedisURL locates the server of the redis backend, e.g.
+	// redis://:password@localhost:6379/0
+	RedisURL    string   `yaml:"redis_url" toml:"redis_url" env:"READ_CACHE_REDIS_URL"`
+	RecipeTTL   Duration `yaml:"recipe_ttl" toml:"recipe_ttl" env:"READ_CACHE_RECIPE_TTL"`
+	CategoryTTL Duration `yaml:"category_ttl" toml:"category_ttl" env:"READ_CACHE_CATEGORY_TTL"`
+}
+
+// Rate is a token bucket rate written as "10/1m" (10 requests a minute) or
+// "10/1m burst 20" (the same average, with bursts of up to 20 requests).
+// "off" disables limiting.
+type Rate struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// UnmarshalText parses a rate such as "10/1m burst 20"
+func (r *Rate) UnmarshalText(text []byte) error {
+	fields := strings.Fields(string(text))
+	if len(fields) == 1 && fields[0] == "off" {
+		*r = Rate{}
+		return nil
+	}
+	invalid := fmt.Errorf("invalid rate %q, want e.g. \"10/1m\" or \"10/1m burst 20\"", text)
+	if len(fields) != 1 && (len(fields) != 3 || fields[1] != "burst") {
+		return invalid
+	}
+
+	limit, period, ok := strings.Cut(fields[0], "/")
+	if !ok {
+		return invalid
+	}
+	var parsed Rate
+	var err error
+	if parsed.Limit, err = strconv.Atoi(limit); err != nil || parsed.Limit <= 0 {
+		return invalid
+	}
+	if parsed.Period, err = time.ParseDuration(period); err != nil || parsed.Period <= 0 {
+		return invalid
+	}
+	if len(fields) == 3 {
+		if parsed.Burst, err = strconv.Atoi(fields[2]); err != nil || parsed.Burst <= 0 {
+			return invalid
+		}
+	}
+	*r = parsed
+	return nil
+}
+
+// MarshalText formats the rate the way UnmarshalText reads it
+func (r Rate) MarshalText() ([]byte, error) {
+	if r.Limit == 0 {
+		return []byte("off"), nil
+	}
+	// Drop the zero units time.Duration prints, e.g. "1m0s" becomes "1m"
+	period := r.Period.String()
+	if strings.HasSuffix(period, "m0s") {
+		period = strings.TrimSuffix(period, "0s")
+	}
+	if strings.HasSuffix(period, "h0m") {
+		period = strings.TrimSuffix(period, "0m")
+	}
+	text := strconv.Itoa(r.Limit) + "/" + period
+	if r.Burst > 0 {
+		text += " burst " + strconv.Itoa(r.Burst)
+	}
+	return []byte(text), nil
+}
+
+// Duration is a time.Duration written as a string such as "30s" in files
+// and environment variables
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalText parses a duration such as "1h30m"
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", text)
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// MarshalText formats the duration the way UnmarshalText reads it
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Default returns the configuration used for settings that are neither in
+// the file nor in the environment
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadTimeout:       Duration{30 * time.Second},
+			ReadHeaderTimeout: Duration{5 * time.Second},
+			WriteTimeout:      Duration{60 * time.Second},
+			IdleTimeout:       Duration{2 * time.Minute},
+			MaxHeaderBytes:    1 << 20, // 1 MB
+			ShutdownTimeout:   Duration{30 * time.Second},
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: Duration{30 * time.Minute},
+			ConnMaxIdleTime: Duration{5 * time.Minute},
+			QueryTimeout:    Duration{5 * time.Second},
+			ConnectTimeout:  Duration{30 * time.Second},
+
+			ReadYourWritesWindow: Duration{5 * time.Second},
+			ReplicaCheckInterval: Duration{10 * time.Second},
+		},
+		Auth: AuthConfig{
+			TokenTTL: Duration{24 * time.Hour},
+			Session: SessionConfig{
+				CookieName:     "session",
+				CSRFCookieName: "csrf_token",
+				Secure:         true,
+				SameSite:       "lax",
+			},
+		},
+		Uploads: UploadConfig{
+			Dir:             "uploads",
+			MaxRequestBytes: 50 << 20, // 50 MB
+			MaxMemoryBytes:  10 << 20, // 10 MB
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE"},
+			AllowedHeaders: []string{
+				"Authorization", "Content-Type", "X-CSRF-Token", "X-Request-ID",
+				"If-Match", "If-None-Match", "If-Modified-Since",
+			},
+			ExposedHeaders: []string{
+				"X-Request-ID", "Retry-After", "ETag",
+				"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy",
+			},
+			MaxAge: Duration{10 * time.Minute},
+		},
+		Security: SecurityConfig{
+			// The API serves JSON and images only, so nothing may be loaded
+			// or framed
+			ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+			HSTSMaxAge:            Duration{365 * 24 * time.Hour},
+			ReferrerPolicy:        "no-referrer",
+		},
+		Media: MediaConfig{
+			GCInterval:  Duration{time.Hour},
+			GracePeriod: Duration{24 * time.Hour},
+		},
+		Log: LogConfig{
+			Format: "json",
+			Level:  "info",
+		},
+		Tracing: TracingConfig{
+			ServiceName: "backend-app",
+			SampleRatio: 1,
+		},
+		RateLimit: RateLimitConfig{
+			Store:   "memory",
+			Default: Rate{Limit: 300, Period: time.Minute, Burst: 100},
+			Routes: map[string]Rate{
+				"/signup":            {Limit: 10, Period: time.Hour},
+				"/login":             {Limit: 10, Period: time.Minute},
+				"/recipe/create":     {Limit: 30, Period: time.Minute},
+				"/recipes/{id}/fork": {Limit: 30, Period: time.Minute},
+				// Invitations reveal which usernames and emails have accounts
+				"/recipes/{id}/invitations": {Limit: 30, Period: time.Hour},
+				"/user/restore":             {Limit: 10, Period: time.Minute},
+			},
+		},
+		Cache: CacheConfig{
+			Default: "no-store",
+			Routes: map[string]string{
+				// Recipes change often; clients revalidate with their ETag.
+				// Drafts and shares make the responses depend on the viewer.
+				"/recipe":     "private, no-cache",
+				"/recipes":    "private, no-cache",
+				"/categories": "public, max-age=300",
+				"/static/":    "public, max-age=86400",
+			},
+		},
+		ReadCache: ReadCacheConfig{
+			Backend:     "memory",
+			Size:        10000,
+			RecipeTTL:   Duration{5 * time.Minute},
+			CategoryTTL: Duration{10 * time.Minute},
+		},
+		Trash: TrashConfig{
+			Retention:     Duration{30 * 24 * time.Hour},
+			PurgeInterval: Duration{time.Hour},
+		},
+		Publishing: PublishingConfig{
+			Interval: Duration{time.Minute},
+		},
+	}
+}
+
+// Load reads the configuration file at path, if any, applies environment
+// overrides and validates the result. Validation failures are reported
+// together as a *ValidationError.
+func Load(path string) (*Config, error) {
+	return load(path, os.LookupEnv)
+}
+
+func load(path string, lookup func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := readFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	problems := applyEnv(cfg, lookup)
+	problems = append(problems, cfg.resolveSecrets(lookup)...)
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// DSN returns the connection string for the primary database, with
+// Password applied if it is set
+func (d DatabaseConfig) DSN() string {
+	return d.withPassword(d.URL)
+}
+
+// ReplicaDSNs returns the connection strings for the read replicas, with
+// Password applied if it is set
+func (d DatabaseConfig) ReplicaDSNs() []string {
+	dsns := make([]string, len(d.ReplicaURLs))
+	for i, replica := range d.ReplicaURLs {
+		dsns[i] = d.withPassword(replica)
+	}
+	return dsns
+}
+
+func (d DatabaseConfig) withPassword(dsn string) string {
+	if !d.Password.IsSet() {
+		return dsn
+	}
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return dsn
+		}
+		u.User = url.UserPassword(u.User.Username(), d.Password.Reveal())
+		return u.String()
+	}
+	quoted := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(d.Password.Reveal())
+	return dsn + " password='" + quoted + "'"
+}
+
+// JWTKeyBytes returns the resolved JWT keys, signing key first
+func (a AuthConfig) JWTKeyBytes() [][]byte {
+	keys := make([][]byte, len(a.JWTKeys))
+	for i, key := range a.JWTKeys {
+		keys[i] = []byte(key.Reveal())
+	}
+	return keys
 }
 
-// LoadConfig loads configuration from environment variables
-func LoadConfig() (*Config, error) {
-    dbPassword := os.Getenv("DB_PASSWORD")
-    if dbPassword == "" {
-        return nil, fmt.Errorf("DB_PASSWORD environment variable not set")
-    }
+// Redacted renders the configuration as YAML for logging, with secrets and
+// the database and cache passwords hidden
+func (c *Config) Redacted() string {
+	dump := *c
+	dump.Database.URL = redactDSN(c.Database.URL)
+	dump.Database.ReplicaURLs = make([]string, len(c.Database.ReplicaURLs))
+	for i, replica := range c.Database.ReplicaURLs {
+		dump.Database.ReplicaURLs[i] = redactDSN(replica)
+	}
+	dump.ReadCache.RedisURL = redactDSN(c.ReadCache.RedisURL)
+
+	out, err := yaml.Marshal(&dump)
+	if err != nil {
+		return "unprintable configuration: " + err.Error()
+	}
+	return string(out)
+}
 
-    // Load other configuration parameters
-    config := &Config{
-        DatabaseURL: "postgres://postgres:" + dbPassword + "@localhost:5432/food_recipes",
-        Port:        os.Getenv("PORT"),
-    }
+// readFile decodes a YAML or TOML file, chosen by its extension, over cfg
+func readFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(cfg)
+		if errors.Is(err, io.EOF) {
+			// An empty file leaves the defaults in place
+			err = nil
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(cfg)
+	default:
+		return fmt.Errorf("config file %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// ValidationError lists every missing or invalid setting
+type ValidationError struct {
+	Problems []string
+}
 
-    return config, nil
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
 }
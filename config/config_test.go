@@ -0,0 +1,296 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKey = "0123456789abcdef0123456789abcdef"
+
+// env returns a lookup function over vars
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadYAML(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+server:
+  addr: ":9000"
+database:
+  url: postgres://localhost/food_recipes
+  max_open_conns: 10
+  max_idle_conns: 5
+  query_timeout: 2s
+auth:
+  jwt_keys: ["`+testKey+`"]
+cors:
+  allowed_origins: ["https://recipes.example.com"]
+`)
+
+	cfg, err := load(path, env(nil))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Server.Addr != ":9000" || cfg.Database.MaxOpenConns != 10 || cfg.Database.QueryTimeout.Duration != 2*time.Second {
+		t.Errorf("config = %+v", cfg)
+	}
+	// Settings missing from the file keep their defaults
+	if cfg.Uploads.Dir != "uploads" || cfg.Media.GCInterval.Duration != time.Hour {
+		t.Errorf("defaults not applied: %+v", cfg)
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+[database]
+url = "postgres://localhost/food_recipes"
+conn_max_lifetime = "1h"
+
+[auth]
+jwt_keys = ["`+testKey+`"]
+token_ttl = "2h"
+`)
+
+	cfg, err := load(path, env(nil))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Database.ConnMaxLifetime.Duration != time.Hour || cfg.Auth.TokenTTL.Duration != 2*time.Hour {
+		t.Errorf("config = %+v", cfg)
+	}
+}
+
+func TestEnvironmentOverridesFile(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+database:
+  url: postgres://file/food_recipes
+  max_open_conns: 10
+auth:
+  jwt_keys: ["file-key-file-key-file-key"]
+`)
+
+	cfg, err := load(path, env(map[string]string{
+		"DATABASE_URL":           "postgres://env/food_recipes",
+		"DB_MAX_OPEN_CONNS":      "40",
+		"DB_MAX_IDLE_CONNS":      "4",
+		"JWT_KEYS":               testKey + ", old-key-old-key-old-key",
+		"CORS_ALLOW_CREDENTIALS": "true",
+		"MEDIA_GC_INTERVAL":      "15m",
+		"PORT":                   "3000",
+	}))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Database.URL != "postgres://env/food_recipes" || cfg.Database.MaxOpenConns != 40 {
+		t.Errorf("database = %+v", cfg.Database)
+	}
+	if len(cfg.Auth.JWTKeys) != 2 || cfg.Auth.JWTKeys[0].Reveal() != testKey {
+		t.Errorf("jwt keys = %q", cfg.Auth.JWTKeys)
+	}
+	if !cfg.CORS.AllowCredentials || cfg.Media.GCInterval.Duration != 15*time.Minute || cfg.Server.Addr != ":3000" {
+		t.Errorf("config = %+v", cfg)
+	}
+}
+
+func TestValidationReportsEveryProblem(t *testing.T) {
+	_, err := load("", env(map[string]string{
+		"DB_MAX_OPEN_CONNS":    "many",
+		"DB_QUERY_TIMEOUT":     "soon",
+		"JWT_KEYS":             "short",
+		"UPLOAD_DIR":           "",
+		"CORS_ALLOWED_ORIGINS": "recipes.example.com",
+	}))
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("err = %v, want *ValidationError", err)
+	}
+	for _, want := range []string{
+		"DB_MAX_OPEN_CONNS",
+		"DB_QUERY_TIMEOUT",
+		"database.url",
+		"auth.jwt_keys[0]",
+		"uploads.dir",
+		"cors.allowed_origins[0]",
+	} {
+		found := false
+		for _, problem := range verr.Problems {
+			if strings.HasPrefix(problem, want+":") {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no problem reported for %s in %q", want, verr.Problems)
+		}
+	}
+}
+
+func TestUnknownFileSettingsAreRejected(t *testing.T) {
+	path := writeFile(t, "config.yaml", "database:\n  uri: postgres://localhost/food_recipes\n")
+
+	if _, err := load(path, env(nil)); err == nil || !strings.Contains(err.Error(), "uri") {
+		t.Errorf("err = %v, want an error naming the unknown setting", err)
+	}
+}
+
+func TestUnsupportedFileFormat(t *testing.T) {
+	path := writeFile(t, "config.json", "{}")
+
+	if _, err := load(path, env(nil)); err == nil {
+		t.Error("expected an error for a .json config file")
+	}
+}
+
+func TestExampleConfigIsComplete(t *testing.T) {
+	_, err := load("../config.example.yaml", env(map[string]string{
+		"DB_PASSWORD": "postgres",
+		"JWT_KEYS":    testKey,
+	}))
+	if err != nil {
+		t.Errorf("config.example.yaml: %v", err)
+	}
+}
+
+func TestTLSFilesMustBeSetTogether(t *testing.T) {
+	_, err := load("", env(map[string]string{
+		"DATABASE_URL":  "postgres://db/food_recipes",
+		"JWT_KEYS":      testKey,
+		"TLS_CERT_FILE": "/etc/tls/tls.crt",
+	}))
+	if err == nil || !strings.Contains(err.Error(), "server.tls") {
+		t.Errorf("err = %v, want a server.tls problem", err)
+	}
+}
+
+func TestTracingSettings(t *testing.T) {
+	cfg, err := load("", env(map[string]string{
+		"DATABASE_URL":                "postgres://db/food_recipes",
+		"JWT_KEYS":                    testKey,
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318",
+		"TRACING_SAMPLE_RATIO":        "0.25",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Tracing.Enabled() || cfg.Tracing.SampleRatio != 0.25 {
+		t.Errorf("tracing = %+v, want enabled with ratio 0.25", cfg.Tracing)
+	}
+
+	_, err = load("", env(map[string]string{
+		"DATABASE_URL":                "postgres://db/food_recipes",
+		"JWT_KEYS":                    testKey,
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "collector:4318",
+		"TRACING_SAMPLE_RATIO":        "2",
+	}))
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Problems) != 2 {
+		t.Errorf("err = %v, want tracing.endpoint and tracing.sample_ratio problems", err)
+	}
+}
+
+func TestReadCacheSettings(t *testing.T) {
+	cfg, err := load("", env(map[string]string{
+		"DATABASE_URL":         "postgres://db/food_recipes",
+		"JWT_KEYS":             testKey,
+		"READ_CACHE_BACKEND":   "redis",
+		"READ_CACHE_REDIS_URL": "redis://:hunter2@cache:6379/1",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dump := cfg.Redacted(); strings.Contains(dump, "hunter2") {
+		t.Errorf("redacted configuration shows the cache password:\n%s", dump)
+	}
+
+	_, err = load("", env(map[string]string{
+		"DATABASE_URL":          "postgres://db/food_recipes",
+		"JWT_KEYS":              testKey,
+		"READ_CACHE_BACKEND":    "redis",
+		"READ_CACHE_RECIPE_TTL": "0s",
+	}))
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Problems) != 2 {
+		t.Errorf("err = %v, want read_cache.redis_url and read_cache.recipe_ttl problems", err)
+	}
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		text string
+		want Rate
+	}{
+		{"10/1m", Rate{Limit: 10, Period: time.Minute}},
+		{"10/1m burst 20", Rate{Limit: 10, Period: time.Minute, Burst: 20}},
+		{"5/1h", Rate{Limit: 5, Period: time.Hour}},
+		{"off", Rate{}},
+	}
+	for _, tt := range tests {
+		var got Rate
+		if err := got.UnmarshalText([]byte(tt.text)); err != nil || got != tt.want {
+			t.Errorf("UnmarshalText(%q) = %+v, %v, want %+v", tt.text, got, err, tt.want)
+		}
+		text, _ := got.MarshalText()
+		if string(text) != tt.text {
+			t.Errorf("MarshalText(%+v) = %q, want %q", got, text, tt.text)
+		}
+	}
+
+	for _, text := range []string{"", "10", "10/", "0/1m", "10/1m burst", "10/1m burst -1", "ten/1m"} {
+		var r Rate
+		if err := r.UnmarshalText([]byte(text)); err == nil {
+			t.Errorf("UnmarshalText(%q) = %+v, want an error", text, r)
+		}
+	}
+
+	cfg, err := load(writeFile(t, "config.yaml", `
+rate_limit:
+  routes:
+    /login: 3/1m
+`), env(map[string]string{
+		"DATABASE_URL":       "postgres://db/food_recipes",
+		"JWT_KEYS":           testKey,
+		"RATE_LIMIT_DEFAULT": "off",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.RateLimit.Default != (Rate{}) {
+		t.Errorf("default = %+v, want off", cfg.RateLimit.Default)
+	}
+	if got := cfg.RateLimit.Routes["/login"]; got != (Rate{Limit: 3, Period: time.Minute}) {
+		t.Errorf("/login = %+v, want 3/1m", got)
+	}
+	if _, ok := cfg.RateLimit.Routes["/signup"]; !ok {
+		t.Error("routes from the file replaced the default routes instead of overriding them")
+	}
+}
+
+func TestSessionSettings(t *testing.T) {
+	_, err := load("", env(map[string]string{
+		"DATABASE_URL":             "postgres://db/food_recipes",
+		"JWT_KEYS":                 testKey,
+		"SESSION_ENABLED":          "true",
+		"SESSION_COOKIE_SECURE":    "false",
+		"SESSION_COOKIE_SAME_SITE": "none",
+	}))
+	if err == nil || !strings.Contains(err.Error(), "auth.session.same_site") {
+		t.Errorf("err = %v, want an auth.session.same_site problem", err)
+	}
+}
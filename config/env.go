@@ -0,0 +1,98 @@
+package config
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// applyEnv overrides the settings of cfg whose `env` tag names a variable
+// that is set. It returns a problem for every variable that cannot be
+// parsed.
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) []string {
+	var problems []string
+
+	// PORT predates LISTEN_ADDR and is still honoured by deployments that
+	// only set a port
+	if port, ok := lookup("PORT"); ok && port != "" {
+		cfg.Server.Addr = ":" + port
+	}
+
+	visitEnv(reflect.ValueOf(cfg).Elem(), func(field reflect.Value, name string) {
+		value, ok := lookup(name)
+		if !ok {
+			return
+		}
+		if err := setField(field, value); err != "" {
+			problems = append(problems, name+": "+err)
+		}
+	})
+	return problems
+}
+
+// visitEnv calls fn for every field of v, including nested structs, that has
+// an `env` tag
+func visitEnv(v reflect.Value, fn func(field reflect.Value, name string)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if name := t.Field(i).Tag.Get("env"); name != "" {
+			fn(field, name)
+			continue
+		}
+		if field.Kind() == reflect.Struct {
+			visitEnv(field, fn)
+		}
+	}
+}
+
+// setField parses value into field and describes the problem if it cannot
+func setField(field reflect.Value, value string) string {
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(value)); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "must be an integer"
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "must be a number"
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "must be true or false"
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		// Lists are comma separated
+		items := reflect.MakeSlice(field.Type(), 0, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setField(elem, item); err != "" {
+				return err
+			}
+			items = reflect.Append(items, elem)
+		}
+		field.Set(items)
+	default:
+		return "unsupported setting type " + field.Type().String()
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces secret values in logged configuration
+const redacted = "REDACTED"
+
+// Secret is a sensitive setting. It is either written literally or as a
+// reference resolved when the configuration is loaded:
+//
+//	file:///run/secrets/jwt_key   contents of the file, without the trailing newline
+//	env:JWT_KEY                   value of the environment variable
+//
+// Secrets never print their value; use Reveal to read it.
+type Secret struct {
+	ref   string
+	value string
+}
+
+// NewSecret returns a secret holding value literally
+func NewSecret(value string) Secret {
+	return Secret{ref: value, value: value}
+}
+
+// Reveal returns the secret value
+func (s Secret) Reveal() string {
+	return s.value
+}
+
+// IsSet reports whether the secret was configured
+func (s Secret) IsSet() bool {
+	return s.ref != ""
+}
+
+// UnmarshalText records the literal value or reference. References are
+// resolved by Load.
+func (s *Secret) UnmarshalText(text []byte) error {
+	*s = NewSecret(string(text))
+	return nil
+}
+
+// MarshalText shows references, which are not sensitive, and redacts
+// literal values
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s Secret) String() string {
+	if s.ref == "" || s.isReference() {
+		return s.ref
+	}
+	return redacted
+}
+
+func (s Secret) isReference() bool {
+	return strings.HasPrefix(s.ref, "file://") || strings.HasPrefix(s.ref, "env:")
+}
+
+// resolve loads the value a reference points to
+func (s *Secret) resolve(lookup func(string) (string, bool)) error {
+	switch {
+	case strings.HasPrefix(s.ref, "file://"):
+		u, err := url.Parse(s.ref)
+		if err != nil || u.Host != "" || u.Path == "" {
+			return fmt.Errorf("invalid file reference %q, use file:///absolute/path", s.ref)
+		}
+		data, err := os.ReadFile(u.Path)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", s.ref, err)
+		}
+		s.value = strings.TrimRight(string(data), "\r\n")
+	case strings.HasPrefix(s.ref, "env:"):
+		name := strings.TrimPrefix(s.ref, "env:")
+		value, ok := lookup(name)
+		if !ok {
+			return fmt.Errorf("environment variable %s referenced by %s is not set", name, s.ref)
+		}
+		s.value = value
+	}
+	return nil
+}
+
+// resolveSecrets resolves every secret reference in c and returns a
+// problem for each one that cannot be resolved
+func (c *Config) resolveSecrets(lookup func(string) (string, bool)) []string {
+	var problems []string
+	resolve := func(setting string, s *Secret) {
+		if err := s.resolve(lookup); err != nil {
+			problems = append(problems, setting+": "+err.Error())
+		}
+	}
+
+	resolve("database.password", &c.Database.Password)
+	for i := range c.Auth.JWTKeys {
+		resolve(fmt.Sprintf("auth.jwt_keys[%d]", i), &c.Auth.JWTKeys[i])
+	}
+	return problems
+}
+
+// dsnPassword matches the password of a key=value connection string
+var dsnPassword = regexp.MustCompile(`(password=)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// redactDSN hides the password in a connection URL or key=value string
+func redactDSN(dsn string) string {
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return redacted
+		}
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		q := u.Query()
+		if q.Has("password") {
+			q.Set("password", redacted)
+			u.RawQuery = q.Encode()
+		}
+		return u.String()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+redacted)
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// minJWTKeyLength is the shortest HMAC key accepted, in bytes
+const minJWTKeyLength = 16
+
+// validate returns a problem for every missing or invalid setting
+func (c *Config) validate() []string {
+	var problems []string
+	report := func(setting, format string, args ...interface{}) {
+		problems = append(problems, setting+": "+fmt.Sprintf(format, args...))
+	}
+
+	if c.Server.Addr == "" {
+		report("server.addr", "is required")
+	} else if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		report("server.addr", "must be host:port, got %q", c.Server.Addr)
+	}
+
+	for _, timeout := range []struct {
+		setting string
+		value   Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.read_header_timeout", c.Server.ReadHeaderTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+	} {
+		if timeout.value.Duration <= 0 {
+			report(timeout.setting, "must be positive")
+		}
+	}
+	if c.Server.MaxHeaderBytes <= 0 {
+		report("server.max_header_bytes", "must be positive")
+	}
+	if (c.Server.TLS.CertFile == "") != (c.Server.TLS.KeyFile == "") {
+		report("server.tls", "cert_file and key_file must be set together")
+	}
+
+	if c.Database.URL == "" {
+		report("database.url", "is required")
+	}
+	if c.Database.MaxOpenConns < 0 {
+		report("database.max_open_conns", "must not be negative")
+	}
+	if c.Database.MaxIdleConns < 0 {
+		report("database.max_idle_conns", "must not be negative")
+	} else if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		report("database.max_idle_conns", "must not exceed max_open_conns (%d)", c.Database.MaxOpenConns)
+	}
+	if c.Database.ConnMaxLifetime.Duration < 0 {
+		report("database.conn_max_lifetime", "must not be negative")
+	}
+	if c.Database.ConnMaxIdleTime.Duration < 0 {
+		report("database.conn_max_idle_time", "must not be negative")
+	}
+	if c.Database.QueryTimeout.Duration <= 0 {
+		report("database.query_timeout", "must be positive")
+	}
+	if c.Database.ConnectTimeout.Duration <= 0 {
+		report("database.connect_timeout", "must be positive")
+	}
+	if c.Database.ReadYourWritesWindow.Duration < 0 {
+		report("database.read_your_writes_window", "must not be negative")
+	}
+	if c.Database.ReplicaCheckInterval.Duration <= 0 {
+		report("database.replica_check_interval", "must be positive")
+	}
+
+	if len(c.Auth.JWTKeys) == 0 {
+		report("auth.jwt_keys", "at least one key is required")
+	}
+	for i, key := range c.Auth.JWTKeys {
+		if len(key.Reveal()) < minJWTKeyLength {
+			report(fmt.Sprintf("auth.jwt_keys[%d]", i), "must be at least %d bytes", minJWTKeyLength)
+		}
+	}
+	if c.Auth.TokenTTL.Duration <= 0 {
+		report("auth.token_ttl", "must be positive")
+	}
+	if session := c.Auth.Session; session.Enabled {
+		if session.CookieName == "" || session.CSRFCookieName == "" {
+			report("auth.session", "cookie_name and csrf_cookie_name are required")
+		} else if session.CookieName == session.CSRFCookieName {
+			report("auth.session", "cookie_name and csrf_cookie_name must differ")
+		}
+		switch strings.ToLower(session.SameSite) {
+		case "lax", "strict":
+		case "none":
+			if !session.Secure {
+				report("auth.session.same_site", "none requires secure cookies")
+			}
+		default:
+			report("auth.session.same_site", "must be lax, strict or none, got %q", session.SameSite)
+		}
+	}
+
+	if c.Uploads.Dir == "" {
+		report("uploads.dir", "is required")
+	}
+	if c.Uploads.MaxRequestBytes <= 0 {
+		report("uploads.max_request_bytes", "must be positive")
+	}
+	if c.Uploads.MaxMemoryBytes <= 0 {
+		report("uploads.max_memory_bytes", "must be positive")
+	}
+
+	for i, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			if c.CORS.AllowCredentials {
+				report("cors.allowed_origins", "\"*\" cannot be combined with allow_credentials")
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			report(fmt.Sprintf("cors.allowed_origins[%d]", i), "must be \"*\" or an origin such as https://example.com, got %q", origin)
+		}
+	}
+	if c.CORS.MaxAge.Duration < 0 {
+		report("cors.max_age", "must not be negative")
+	}
+
+	if c.Security.HSTSMaxAge.Duration < 0 {
+		report("security.hsts_max_age", "must not be negative")
+	}
+
+	if c.Media.GCInterval.Duration <= 0 {
+		report("media.gc_interval", "must be positive")
+	}
+	if c.Media.GracePeriod.Duration < 0 {
+		report("media.grace_period", "must not be negative")
+	}
+
+	if c.Trash.Retention.Duration < 0 {
+		report("trash.retention", "must not be negative")
+	}
+	if c.Trash.PurgeInterval.Duration <= 0 {
+		report("trash.purge_interval", "must be positive")
+	}
+	if c.Publishing.Interval.Duration <= 0 {
+		report("publishing.interval", "must be positive")
+	}
+
+	switch c.Log.Format {
+	case "json", "text":
+	default:
+		report("log.format", "must be json or text, got %q", c.Log.Format)
+	}
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		report("log.level", "must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+
+	if c.Tracing.Endpoint != "" {
+		u, err := url.Parse(c.Tracing.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			report("tracing.endpoint", "must be an http or https URL, got %q", c.Tracing.Endpoint)
+		}
+	}
+	if c.Tracing.ServiceName == "" {
+		report("tracing.service_name", "is required")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		report("tracing.sample_ratio", "must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+	}
+
+	switch c.RateLimit.Store {
+	case "memory", "postgres":
+	default:
+		report("rate_limit.store", "must be memory or postgres, got %q", c.RateLimit.Store)
+	}
+	for route := range c.RateLimit.Routes {
+		if !strings.HasPrefix(route, "/") {
+			report("rate_limit.routes", "%q is not a route template such as /login", route)
+		}
+	}
+	for route := range c.Cache.Routes {
+		if !strings.HasPrefix(route, "/") {
+			report("cache.routes", "%q is not a route template such as /recipe", route)
+		}
+	}
+
+	switch c.ReadCache.Backend {
+	case "memory":
+		if c.ReadCache.Size <= 0 {
+			report("read_cache.size", "must be positive, got %d", c.ReadCache.Size)
+		}
+	case "redis":
+		if u, err := url.Parse(c.ReadCache.RedisURL); err != nil || u.Scheme != "redis" || u.Host == "" {
+			report("read_cache.redis_url", "must be a redis:// URL when the backend is redis")
+		}
+	case "off":
+	default:
+		report("read_cache.backend", "must be memory, redis or off, got %q", c.ReadCache.Backend)
+	}
+	if c.ReadCache.Backend != "off" {
+		if c.ReadCache.RecipeTTL.Duration <= 0 {
+			report("read_cache.recipe_ttl", "must be positive")
+		}
+		if c.ReadCache.CategoryTTL.Duration <= 0 {
+			report("read_cache.category_ttl", "must be positive")
+		}
+	}
+
+	return problems
+}
@@ -3,102 +3,96 @@
 package controllers
 
 import (
-	"log"
 	"net/http"
 	"time"
 
+	"backend-app/middleware"
 	"backend-app/models"
-	"backend-app/repository"
-	"github.com/gin-gonic/gin"
+	"backend-app/problem"
+	"backend-app/service"
 	"github.com/dgrijalva/jwt-go"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthController struct {
-	UserRepository *repository.UserRepository
-	JwtSecret      []byte // Secret key for JWT
+	UserService *service.UserService
+	Keys        *middleware.KeySet // Keys for signing JWTs
+	TokenTTL    time.Duration      // Lifetime of issued tokens
+	// Sessions, if set, lets browsers log in with a session cookie
+	Sessions *middleware.Sessions
 }
 
-func NewAuthController(userRepo *repository.UserRepository, jwtSecret []byte) *AuthController {
+func NewAuthController(userService *service.UserService, keys *middleware.KeySet, tokenTTL time.Duration) *AuthController {
 	return &AuthController{
-		UserRepository: userRepo,
-		JwtSecret:      jwtSecret,
+		UserService: userService,
+		Keys:        keys,
+		TokenTTL:    tokenTTL,
 	}
 }
 
 // SignUp handles user registration
-func (ac *AuthController) SignUp(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		log.Println("Error decoding JSON:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+func (ac *AuthController) SignUp(w http.ResponseWriter, r *http.Request) {
+	var req models.SignUpRequest
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	// Validate input
-	if user.Username == "" || user.PasswordHash == "" || user.Email == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username, email, and password are required"})
-		return
-	}
-
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.PasswordHash), bcrypt.DefaultCost)
-	if err != nil {
-		log.Println("Error hashing password:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create user"})
-		return
-	}
-	user.PasswordHash = string(hashedPassword)
-
 	// Create user in database
-	if err := ac.UserRepository.CreateUser(c.Request.Context(), &user); err != nil {
-		log.Println("Error creating user:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create user"})
+	user, err := ac.UserService.SignUp(r.Context(), &req)
+	if err != nil {
+		writeError(w, r, err, "user")
 		return
 	}
 
-	// Clear sensitive information
-	user.PasswordHash = ""
-
-	c.JSON(http.StatusOK, user)
+	writeJSON(w, http.StatusOK, user)
 }
 
 // Login handles user authentication
-func (ac *AuthController) Login(c *gin.Context) {
+func (ac *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 	var creds models.Credentials
-	if err := c.ShouldBindJSON(&creds); err != nil {
-		log.Println("Error decoding JSON:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+	if !decodeJSON(w, r, &creds) {
 		return
 	}
 
-	// Retrieve user from database by email
-	storedUser, err := ac.UserRepository.GetUserByEmail(creds.Email)
+	// Check the credentials against the stored user
+	storedUser, err := ac.UserService.Authenticate(r.Context(), &creds)
 	if err != nil {
-		log.Println("Error retrieving user:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		return
-	}
-
-	// Compare hashed passwords
-	if err := bcrypt.CompareHashAndPassword([]byte(storedUser.PasswordHash), []byte(creds.Password)); err != nil {
-		log.Println("Invalid password:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		writeError(w, r, err, "user")
 		return
 	}
 
 	// Create JWT token
+	expires := time.Now().Add(ac.TokenTTL)
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"id":    storedUser.ID,
 		"email": storedUser.Email,
-		"exp":   time.Now().Add(time.Hour * 24).Unix(), // Token expires in 24 hours
+		"exp":   expires.Unix(),
 	})
-	tokenString, err := token.SignedString(ac.JwtSecret)
+	tokenString, err := token.SignedString(ac.Keys.SigningKey())
 	if err != nil {
-		log.Println("Error generating JWT token:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error signing token"})
+		writeError(w, r, err, "token")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": tokenString})
+	// Browsers asking for a session get the token as an HttpOnly cookie
+	// and only see the CSRF token
+	if r.URL.Query().Get("session") == "true" {
+		if ac.Sessions == nil {
+			problem.Write(w, r, problem.BadRequest("session login is not enabled"))
+			return
+		}
+		csrfToken := ac.Sessions.Start(w, tokenString, expires)
+		writeJSON(w, http.StatusOK, map[string]string{"csrf_token": csrfToken})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"token": tokenString})
+}
+
+// Logout ends a cookie session. Bearer tokens stay valid until they
+// expire; clients discard them.
+func (ac *AuthController) Logout(w http.ResponseWriter, r *http.Request) {
+	if ac.Sessions != nil {
+		ac.Sessions.End(w)
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"backend-app/middleware"
+	"backend-app/models"
+	"backend-app/problem"
+)
+
+func TestSignUpCreatesUserWithoutExposingPassword(t *testing.T) {
+	env := newTestEnv(t)
+
+	rec := serve(t, env.auth.SignUp, "POST", "/signup", models.SignUpRequest{
+		Username: "abebe",
+		Email:    "abebe@example.com",
+		Password: "injera-lover",
+	})
+
+	var body map[string]interface{}
+	decodeBody(t, rec, http.StatusOK, &body)
+	if body["email"] != "abebe@example.com" {
+		t.Errorf("email = %v, want abebe@example.com", body["email"])
+	}
+	for _, key := range []string{"password", "password_hash", "PasswordHash"} {
+		if _, ok := body[key]; ok {
+			t.Errorf("response exposes %q", key)
+		}
+	}
+}
+
+func TestSignUpReportsAllInvalidFields(t *testing.T) {
+	env := newTestEnv(t)
+
+	rec := serve(t, env.auth.SignUp, "POST", "/signup", models.SignUpRequest{
+		Username: "ab",
+		Email:    "not-an-email",
+		Password: "short",
+	})
+
+	p := expectProblem(t, rec, http.StatusUnprocessableEntity, problem.CodeValidationFailed)
+	got := fields(p)
+	for _, field := range []string{"username", "email", "password"} {
+		if !got[field] {
+			t.Errorf("missing field error for %q in %+v", field, p.Errors)
+		}
+	}
+}
+
+func TestSignUpRejectsDuplicateEmail(t *testing.T) {
+	env := newTestEnv(t)
+	env.createUser(t, "abebe", "abebe@example.com")
+
+	rec := serve(t, env.auth.SignUp, "POST", "/signup", models.SignUpRequest{
+		Username: "other",
+		Email:    "abebe@example.com",
+		Password: "injera-lover",
+	})
+
+	expectProblem(t, rec, http.StatusConflict, problem.CodeConflict)
+}
+
+func TestLogin(t *testing.T) {
+	env := newTestEnv(t)
+	serve(t, env.auth.SignUp, "POST", "/signup", models.SignUpRequest{
+		Username: "abebe",
+		Email:    "abebe@example.com",
+		Password: "injera-lover",
+	})
+
+	tests := []struct {
+		name   string
+		creds  models.Credentials
+		status int
+	}{
+		{"valid credentials", models.Credentials{Email: "abebe@example.com", Password: "injera-lover"}, http.StatusOK},
+		{"wrong password", models.Credentials{Email: "abebe@example.com", Password: "wrong"}, http.StatusUnauthorized},
+		{"unknown email", models.Credentials{Email: "nobody@example.com", Password: "injera-lover"}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, env.auth.Login, "POST", "/login", tt.creds)
+			if tt.status != http.StatusOK {
+				expectProblem(t, rec, tt.status, problem.CodeUnauthorized)
+				return
+			}
+
+			var body map[string]string
+			decodeBody(t, rec, http.StatusOK, &body)
+			if body["token"] == "" {
+				t.Error("response has no token")
+			}
+		})
+	}
+}
+
+func TestSessionLogin(t *testing.T) {
+	env := newTestEnv(t)
+	serve(t, env.auth.SignUp, "POST", "/signup", models.SignUpRequest{
+		Username: "abebe",
+		Email:    "abebe@example.com",
+		Password: "injera-lover",
+	})
+	creds := models.Credentials{Email: "abebe@example.com", Password: "injera-lover"}
+
+	// Sessions are off unless configured
+	rec := serve(t, env.auth.Login, "POST", "/login?session=true", creds)
+	expectProblem(t, rec, http.StatusBadRequest, problem.CodeBadRequest)
+
+	env.auth.Sessions = &middleware.Sessions{
+		Keys:           env.auth.Keys,
+		CookieName:     "session",
+		CSRFCookieName: "csrf_token",
+		SameSite:       http.SameSiteLaxMode,
+	}
+	rec = serve(t, env.auth.Login, "POST", "/login?session=true", creds)
+	var body map[string]string
+	decodeBody(t, rec, http.StatusOK, &body)
+	if body["token"] != "" {
+		t.Error("session login exposed the token to scripts")
+	}
+	if body["csrf_token"] == "" {
+		t.Error("response has no CSRF token")
+	}
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range rec.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	if session := cookies["session"]; session == nil || !session.HttpOnly || session.Value == "" {
+		t.Errorf("session cookie = %+v, want an HttpOnly token", session)
+	}
+	if csrf := cookies["csrf_token"]; csrf == nil || csrf.Value != body["csrf_token"] {
+		t.Errorf("CSRF cookie = %+v, want the returned CSRF token", csrf)
+	}
+
+	rec = serve(t, env.auth.Logout, "POST", "/logout", nil)
+	if rec.Code != http.StatusNoContent || len(rec.Result().Cookies()) != 2 {
+		t.Errorf("logout: status %d, %d cookies, want 204 clearing both cookies", rec.Code, len(rec.Result().Cookies()))
+	}
+}
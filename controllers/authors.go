@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend-app/models"
+	"backend-app/problem"
+	"github.com/gorilla/mux"
+)
+
+// ListAuthors lists the creator and co-authors of a recipe
+func (rc *RecipeController) ListAuthors(w http.ResponseWriter, r *http.Request) {
+	recipeID, ok := recipeIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	authors, err := rc.RecipeService.ListAuthors(r.Context(), viewerID(r), recipeID)
+	if err != nil {
+		writeError(w, r, err, "recipe")
+		return
+	}
+	writeJSON(w, http.StatusOK, authors)
+}
+
+// RemoveAuthor removes the co-author in the user_id query parameter from a
+// recipe. Co-authors remove themselves to leave it.
+func (rc *RecipeController) RemoveAuthor(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	recipeID, ok := recipeIDParam(w, r)
+	if !ok {
+		return
+	}
+	userID, ok := userIDQuery(w, r)
+	if !ok {
+		return
+	}
+
+	if err := rc.RecipeService.RemoveAuthor(r.Context(), actorID, recipeID, userID); err != nil {
+		writeError(w, r, err, "author")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// InviteAuthor invites a user, by username or email, to co-author a recipe
+func (rc *RecipeController) InviteAuthor(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	recipeID, ok := recipeIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.InviteAuthorRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	req.RecipeID = recipeID
+
+	invitation, err := rc.RecipeService.InviteAuthor(r.Context(), actorID, &req)
+	if err != nil {
+		writeError(w, r, err, "invitation")
+		return
+	}
+	writeJSON(w, http.StatusCreated, invitation)
+}
+
+// ListInvitations lists the authenticated user's pending invitations
+func (rc *RecipeController) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	invitations, err := rc.RecipeService.ListInvitations(r.Context(), actorID)
+	if err != nil {
+		writeError(w, r, err, "invitation")
+		return
+	}
+	if invitations == nil {
+		invitations = []*models.Invitation{}
+	}
+	writeJSON(w, http.StatusOK, invitations)
+}
+
+// AcceptInvitation makes the authenticated user a co-author of the recipe
+// they were invited to
+func (rc *RecipeController) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	invitationID, ok := invitationIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	author, err := rc.RecipeService.AcceptInvitation(r.Context(), actorID, invitationID)
+	if err != nil {
+		writeError(w, r, err, "invitation")
+		return
+	}
+	writeJSON(w, http.StatusOK, author)
+}
+
+// DeclineInvitation declines an invitation, or withdraws it when a recipe
+// owner calls it
+func (rc *RecipeController) DeclineInvitation(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	invitationID, ok := invitationIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := rc.RecipeService.DeclineInvitation(r.Context(), actorID, invitationID); err != nil {
+		writeError(w, r, err, "invitation")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// invitationIDParam reads the invitation ID from the route's {id} variable.
+// On failure it writes the problem response and returns false.
+func invitationIDParam(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	invitationID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || invitationID <= 0 {
+		problem.Write(w, r, problem.BadRequest("invitation id must be a positive integer"))
+		return 0, false
+	}
+	return invitationID, true
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend-app/models"
+	"backend-app/problem"
+	"github.com/gorilla/mux"
+)
+
+func TestCoAuthors(t *testing.T) {
+	env := newTestEnv(t)
+	owner := env.createUser(t, "abebe", "abebe@example.com")
+	editor := env.createUser(t, "kebede", "kebede@example.com")
+	coOwner := env.createUser(t, "almaz", "almaz@example.com")
+	env.createUser(t, "twin", "twin1@example.com")
+	env.createUser(t, "twin", "twin2@example.com")
+
+	router := mux.NewRouter()
+	router.HandleFunc("/recipes/{id}/authors", env.recipe.ListAuthors).Methods("GET")
+	router.HandleFunc("/recipes/{id}/authors", env.recipe.RemoveAuthor).Methods("DELETE")
+	router.HandleFunc("/recipes/{id}/invitations", env.recipe.InviteAuthor)
+	router.HandleFunc("/recipes/{id}/status", env.recipe.SetRecipeStatus)
+	router.HandleFunc("/invitations", env.recipe.ListInvitations)
+	router.HandleFunc("/invitations/{id}/accept", env.recipe.AcceptInvitation)
+	router.HandleFunc("/invitations/{id}/decline", env.recipe.DeclineInvitation)
+	route := func(user *models.User, method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, authenticate(httptest.NewRequest(method, target, strings.NewReader(body)), user))
+		return rec
+	}
+	update := func(user *models.User, ifMatch, title string) *httptest.ResponseRecorder {
+		return serveAs(t, user, func(w http.ResponseWriter, r *http.Request) {
+			if ifMatch != "" {
+				r.Header.Set("If-Match", ifMatch)
+			}
+			env.recipe.UpdateRecipe(w, r)
+		}, "PUT", "/recipe/update", map[string]interface{}{"id": 1, "title": title})
+	}
+
+	var recipe models.Recipe
+	decodeBody(t, serveAs(t, owner, env.recipe.CreateRecipe, "POST", "/recipe/create", map[string]interface{}{"title": "Shiro"}),
+		http.StatusCreated, &recipe)
+
+	// Invitations name the invitee by username or email
+	var invitation models.Invitation
+	decodeBody(t, route(owner, "POST", "/recipes/1/invitations", `{"username": "kebede", "role": "editor"}`), http.StatusCreated, &invitation)
+	if invitation.InviteeID != int64(editor.ID) || invitation.InvitedBy != int64(owner.ID) || invitation.Role != models.RoleEditor {
+		t.Errorf("invitation = %+v", invitation)
+	}
+	expectProblem(t, route(owner, "POST", "/recipes/1/invitations", `{"email": "kebede@example.com", "role": "editor"}`),
+		http.StatusConflict, problem.CodeConflict)
+	expectProblem(t, route(owner, "POST", "/recipes/1/invitations", `{"username": "twin", "role": "editor"}`),
+		http.StatusConflict, problem.CodeConflict)
+	expectProblem(t, route(owner, "POST", "/recipes/1/invitations", `{"email": "nobody@example.com", "role": "editor"}`),
+		http.StatusUnprocessableEntity, problem.CodeInvalidReference)
+	expectProblem(t, route(owner, "POST", "/recipes/1/invitations", `{"role": "editor"}`),
+		http.StatusUnprocessableEntity, problem.CodeValidationFailed)
+	expectProblem(t, route(editor, "POST", "/recipes/1/invitations", `{"username": "almaz", "role": "owner"}`),
+		http.StatusNotFound, problem.CodeNotFound)
+
+	// The invitee sees the invitation; nobody else may answer it
+	var pending []models.Invitation
+	decodeBody(t, route(editor, "GET", "/invitations", ""), http.StatusOK, &pending)
+	if len(pending) != 1 || pending[0].ID != invitation.ID {
+		t.Fatalf("pending invitations = %+v", pending)
+	}
+	expectProblem(t, route(coOwner, "POST", "/invitations/1/accept", ""), http.StatusNotFound, problem.CodeNotFound)
+	var author models.RecipeAuthor
+	decodeBody(t, route(editor, "POST", "/invitations/1/accept", ""), http.StatusOK, &author)
+	if author.UserID != int64(editor.ID) || author.Role != models.RoleEditor {
+		t.Errorf("author = %+v", author)
+	}
+
+	// Declined invitations are gone
+	decodeBody(t, route(owner, "POST", "/recipes/1/invitations", `{"email": "almaz@example.com", "role": "owner"}`), http.StatusCreated, &invitation)
+	if rec := route(coOwner, "POST", "/invitations/2/decline", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("decline = %d, want 204", rec.Code)
+	}
+	expectProblem(t, route(coOwner, "POST", "/invitations/2/accept", ""), http.StatusNotFound, problem.CodeNotFound)
+	decodeBody(t, route(owner, "POST", "/recipes/1/invitations", `{"email": "almaz@example.com", "role": "owner"}`), http.StatusCreated, &invitation)
+	decodeBody(t, route(coOwner, "POST", "/invitations/3/accept", ""), http.StatusOK, &author)
+
+	var authors []models.RecipeAuthor
+	decodeBody(t, route(editor, "GET", "/recipes/1/authors", ""), http.StatusOK, &authors)
+	if len(authors) != 3 || authors[0].UserID != int64(owner.ID) || authors[0].Role != models.RoleOwner ||
+		authors[1].UserID != int64(editor.ID) || authors[2].Role != models.RoleOwner {
+		t.Errorf("authors = %+v", authors)
+	}
+
+	// Editors edit the draft; only owners publish it
+	expectProblem(t, route(editor, "PUT", "/recipes/1/status", `{"status": "published"}`), http.StatusForbidden, problem.CodeForbidden)
+	if rec := route(coOwner, "PUT", "/recipes/1/status", `{"status": "published"}`); rec.Code != http.StatusOK {
+		t.Errorf("co-owner publishing = %d, want 200", rec.Code)
+	}
+
+	// Two editors working from the same version cannot overwrite each other
+	expectProblem(t, update(editor, "", "Shiro wot"), http.StatusPreconditionRequired, problem.CodePreconditionRequired)
+	var edited models.Recipe
+	decodeBody(t, update(editor, `"v2"`, "Shiro wot"), http.StatusOK, &edited)
+	expectProblem(t, update(owner, `"v2"`, "Tegabino shiro"), http.StatusPreconditionFailed, problem.CodePreconditionFailed)
+	if edited.Title != "Shiro wot" || edited.UpdatedBy != int64(editor.ID) {
+		t.Errorf("edited recipe = %+v", edited)
+	}
+
+	// Co-authors may leave, but the creator stays
+	expectProblem(t, route(editor, "DELETE", "/recipes/1/authors?user_id=3", ""), http.StatusForbidden, problem.CodeForbidden)
+	expectProblem(t, route(coOwner, "DELETE", "/recipes/1/authors?user_id=1", ""), http.StatusForbidden, problem.CodeForbidden)
+	if rec := route(editor, "DELETE", "/recipes/1/authors?user_id=2", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("leaving = %d, want 204", rec.Code)
+	}
+	expectProblem(t, update(editor, `"v3"`, "Mine now"), http.StatusForbidden, problem.CodeForbidden)
+}
+
+func TestDeletedCoOwner(t *testing.T) {
+	env := newTestEnv(t)
+	owner := env.createUser(t, "abebe", "abebe@example.com")
+	coOwner := env.createUser(t, "almaz", "almaz@example.com")
+	env.createUser(t, "kebede", "kebede@example.com")
+
+	router := mux.NewRouter()
+	router.HandleFunc("/recipes/{id}/authors", env.recipe.ListAuthors).Methods("GET")
+	router.HandleFunc("/recipes/{id}/invitations", env.recipe.InviteAuthor)
+	router.HandleFunc("/recipes/{id}/shares", env.recipe.ShareRecipe).Methods("PUT")
+	router.HandleFunc("/invitations/{id}/accept", env.recipe.AcceptInvitation)
+	route := func(user *models.User, method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, authenticate(httptest.NewRequest(method, target, strings.NewReader(body)), user))
+		return rec
+	}
+
+	var recipe models.Recipe
+	decodeBody(t, serveAs(t, owner, env.recipe.CreateRecipe, "POST", "/recipe/create",
+		map[string]interface{}{"title": "Shiro", "status": "published"}), http.StatusCreated, &recipe)
+	decodeBody(t, route(owner, "POST", "/recipes/1/invitations", `{"username": "almaz", "role": "owner"}`), http.StatusCreated,
+		&models.Invitation{})
+	decodeBody(t, route(coOwner, "POST", "/invitations/1/accept", ""), http.StatusOK, &models.RecipeAuthor{})
+	if rec := serveAs(t, coOwner, env.user.DeleteUser, "DELETE", "/user/delete?id=2", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d, want 204", rec.Code)
+	}
+
+	// Tokens issued before the deletion no longer act as an owner
+	expectProblem(t, serveAs(t, coOwner, env.recipe.DeleteRecipe, "DELETE", "/recipe/delete?id=1", nil),
+		http.StatusForbidden, problem.CodeForbidden)
+	expectProblem(t, route(coOwner, "PUT", "/recipes/1/shares", `{"user_id": 3, "permission": "edit"}`),
+		http.StatusForbidden, problem.CodeForbidden)
+	expectProblem(t, route(coOwner, "POST", "/recipes/1/invitations", `{"username": "kebede", "role": "owner"}`),
+		http.StatusForbidden, problem.CodeForbidden)
+	expectProblem(t, serveAs(t, coOwner, env.recipe.UpdateRecipe, "PUT", "/recipe/update",
+		map[string]interface{}{"id": 1, "title": "Mine now"}), http.StatusForbidden, problem.CodeForbidden)
+
+	var authors []models.RecipeAuthor
+	decodeBody(t, route(owner, "GET", "/recipes/1/authors", ""), http.StatusOK, &authors)
+	if len(authors) != 1 || authors[0].UserID != int64(owner.ID) {
+		t.Errorf("authors = %+v, want only the creator", authors)
+	}
+}
@@ -1,122 +1,104 @@
 package controllers
 
 import (
-	"encoding/json"
-	"fmt"
-	"log"
 	"net/http"
 	"strconv"
 
 	"backend-app/models"
-	"backend-app/repository"
+	"backend-app/problem"
+	"backend-app/service"
 )
 
 type CategoryController struct {
-	CategoryRepository *repository.CategoryRepository
+	CategoryService *service.CategoryService
 }
 
-func NewCategoryController(categoryRepo *repository.CategoryRepository) *CategoryController {
+func NewCategoryController(categoryService *service.CategoryService) *CategoryController {
 	return &CategoryController{
-		CategoryRepository: categoryRepo,
+		CategoryService: categoryService,
 	}
 }
 
 // CreateCategory creates a new recipe category
 func (cc *CategoryController) CreateCategory(w http.ResponseWriter, r *http.Request) {
-    var newCategory models.Category
-    err := json.NewDecoder(r.Body).Decode(&newCategory)
-    if err != nil {
-        http.Error(w, "Failed to decode request body", http.StatusBadRequest)
-        return
-    }
-
-    // Validate input
-    if newCategory.Name == "" {
-        http.Error(w, "Category name is required", http.StatusBadRequest)
-        return
-    }
-
+	var req models.CreateCategoryRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
 
 	// Create the category in the database
-	err = cc.CategoryRepository.CreateCategory(&newCategory)
-    if err != nil {
-        log.Println("Error creating category:", err)
-        http.Error(w, "Failed to create category", http.StatusInternalServerError)
-        return
-    }
+	newCategory, err := cc.CategoryService.CreateCategory(r.Context(), &req)
+	if err != nil {
+		writeError(w, r, err, "category")
+		return
+	}
 
 	// Return success response with the created category
-	w.Header().Set("Content-Type", "application/json")
-    w.WriteHeader(http.StatusCreated)
-    json.NewEncoder(w).Encode(newCategory) // Adjust based on how you handle the created category
+	writeJSON(w, http.StatusCreated, newCategory)
 }
 
 // UpdateCategory updates an existing recipe category
 func (cc *CategoryController) UpdateCategory(w http.ResponseWriter, r *http.Request) {
-	var updatedCategory models.Category
-	err := json.NewDecoder(r.Body).Decode(&updatedCategory)
-	if err != nil {
-		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
-		return
-	}
-
-	// Validate input
-	if updatedCategory.ID == 0 {
-		http.Error(w, "Category ID is required", http.StatusBadRequest)
+	var req models.UpdateCategoryRequest
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	// Update the category in the database
-	err = cc.CategoryRepository.UpdateCategory(&updatedCategory)
+	updatedCategory, err := cc.CategoryService.UpdateCategory(r.Context(), &req)
 	if err != nil {
-		log.Println("Error updating category:", err)
-		http.Error(w, "Failed to update category", http.StatusInternalServerError)
+		writeError(w, r, err, "category")
 		return
 	}
 
-	// Return success response
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, "Category updated successfully")
+	// Return success response with the updated category
+	writeJSON(w, http.StatusOK, updatedCategory)
 }
 
 // DeleteCategory deletes a recipe category by ID
 func (cc *CategoryController) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	categoryIDStr := r.URL.Query().Get("id")
 	if categoryIDStr == "" {
-		http.Error(w, "Category ID is required", http.StatusBadRequest)
+		problem.Write(w, r, problem.BadRequest("category id is required"))
 		return
 	}
 
 	categoryID, err := strconv.ParseInt(categoryIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid Category ID", http.StatusBadRequest)
+		problem.Write(w, r, problem.BadRequest("category id must be an integer"))
 		return
 	}
 
+	// Move the category's recipes to another one, if the client names it
+	var reassignTo int64
+	if value := r.URL.Query().Get("reassign_to"); value != "" {
+		reassignTo, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || reassignTo <= 0 {
+			problem.Write(w, r, problem.BadRequest("reassign_to must be a positive integer"))
+			return
+		}
+	}
+
 	// Delete the category from the database
-	err = cc.CategoryRepository.DeleteCategory(categoryID)
+	err = cc.CategoryService.DeleteCategory(r.Context(), categoryID, reassignTo)
 	if err != nil {
-		log.Println("Error deleting category:", err)
-		http.Error(w, "Failed to delete category", http.StatusInternalServerError)
+		writeError(w, r, err, "category")
 		return
 	}
 
 	// Return success response
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, "Category deleted successfully")
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // GetAllCategories retrieves all recipe categories
 func (cc *CategoryController) GetAllCategories(w http.ResponseWriter, r *http.Request) {
 	// Retrieve all categories from the database
-	categories, err := cc.CategoryRepository.GetAllCategories()
+	categories, err := cc.CategoryService.GetAllCategories(r.Context())
 	if err != nil {
-		log.Println("Error retrieving categories:", err)
-		http.Error(w, "Failed to retrieve categories", http.StatusInternalServerError)
+		writeError(w, r, err, "category")
 		return
 	}
 
 	// Return categories as JSON response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(categories)
+	writeJSON(w, http.StatusOK, categories)
 }
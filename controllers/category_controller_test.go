@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"backend-app/models"
+	"backend-app/problem"
+)
+
+func TestCreateCategory(t *testing.T) {
+	env := newTestEnv(t)
+
+	rec := serve(t, env.category.CreateCategory, "POST", "/category/create", models.CreateCategoryRequest{Name: "Breakfast"})
+
+	var category models.Category
+	decodeBody(t, rec, http.StatusCreated, &category)
+	if category.ID == 0 || category.Name != "Breakfast" {
+		t.Errorf("created category = %+v", category)
+	}
+}
+
+func TestCreateCategoryRejectsDuplicateName(t *testing.T) {
+	env := newTestEnv(t)
+	env.createCategory(t, "Breakfast")
+
+	rec := serve(t, env.category.CreateCategory, "POST", "/category/create", models.CreateCategoryRequest{Name: "Breakfast"})
+
+	expectProblem(t, rec, http.StatusConflict, problem.CodeConflict)
+}
+
+func TestUpdateMissingCategory(t *testing.T) {
+	env := newTestEnv(t)
+
+	rec := serve(t, env.category.UpdateCategory, "PUT", "/category/update", models.UpdateCategoryRequest{ID: 42, Name: "Lunch"})
+
+	expectProblem(t, rec, http.StatusNotFound, problem.CodeNotFound)
+}
+
+func TestDeleteCategoryInUse(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "abebe", "abebe@example.com")
+	category := env.createCategory(t, "Breakfast")
+	_, err := env.recipes.CreateRecipe(context.Background(), &models.Recipe{
+		Title:      "Firfir",
+		CreatorID:  int64(user.ID),
+		CategoryID: int64(category.ID),
+	})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+
+	rec := serve(t, env.category.DeleteCategory, "DELETE", "/category/delete?id=1", nil)
+
+	expectProblem(t, rec, http.StatusUnprocessableEntity, problem.CodeInvalidReference)
+}
+
+func TestDeleteCategoryReassignsRecipes(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "abebe", "abebe@example.com")
+	breakfast := env.createCategory(t, "Breakfast")
+	brunch := env.createCategory(t, "Brunch")
+	created, err := env.recipes.CreateRecipe(context.Background(), &models.Recipe{
+		Title:      "Firfir",
+		CreatorID:  int64(user.ID),
+		CategoryID: int64(breakfast.ID),
+	})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+
+	rec := serve(t, env.category.DeleteCategory, "DELETE", "/category/delete?id=1&reassign_to=x", nil)
+	expectProblem(t, rec, http.StatusBadRequest, problem.CodeBadRequest)
+
+	rec = serve(t, env.category.DeleteCategory, "DELETE", "/category/delete?id=1&reassign_to=2", nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", rec.Code)
+	}
+	stored, err := env.recipes.GetRecipeByID(context.Background(), created.ID)
+	if err != nil || stored.CategoryID != int64(brunch.ID) {
+		t.Errorf("recipe = %+v, %v; want it moved to %d", stored, err, brunch.ID)
+	}
+	if _, err := env.categories.GetCategoryByID(context.Background(), int64(breakfast.ID)); err == nil {
+		t.Error("category still exists after delete")
+	}
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend-app/models"
+	"backend-app/problem"
+)
+
+// recipeETag is the strong entity tag of a recipe. The version changes with
+// every update, so it identifies the representation exactly, except that
+// only owners see the share link of an unlisted recipe. Copies without it
+// are tagged "v<version>-r", so that a viewer who loses or gains ownership
+// does not keep the copy they had.
+func recipeETag(recipe *models.Recipe) string {
+	tag := `"v` + strconv.FormatInt(recipe.Version, 10)
+	if recipe.Visibility == models.VisibilityUnlisted && recipe.ShareToken == "" {
+		tag += redactedSuffix
+	}
+	return tag + `"`
+}
+
+// redactedSuffix marks the entity tags of recipes without their share link
+const redactedSuffix = "-r"
+
+// recipesETag is the strong entity tag of a recipe list. It covers the IDs
+// as well as the tags of the recipes so that deletions change it too.
+func recipesETag(recipes []*models.Recipe) string {
+	h := sha256.New()
+	var buf [8]byte
+	for _, recipe := range recipes {
+		binary.BigEndian.PutUint64(buf[:], uint64(recipe.ID))
+		h.Write(buf[:])
+		h.Write([]byte(recipeETag(recipe)))
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// notModified sets the validators of a representation and answers a
+// conditional GET. It writes a 304 response and returns true if the client's
+// copy is current. modified may be zero when the representation has no
+// meaningful modification time.
+func notModified(w http.ResponseWriter, r *http.Request, etag string, modified time.Time) bool {
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	// If-Modified-Since is ignored when If-None-Match is present
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if !etagMatches(match, etag) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || modified.IsZero() || modified.Truncate(time.Second).After(since) {
+			return false
+		}
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// ifMatchVersion reads the recipe version an update expects from If-Match,
+// with or without the share link. It returns zero when the header is absent
+// or "*". Weak tags, lists and
+// anything that is not a recipe tag cannot match a current recipe, so they
+// fail the precondition: it writes the problem response and returns false.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	match := strings.TrimSpace(r.Header.Get("If-Match"))
+	if match == "" || match == "*" {
+		return 0, true
+	}
+	if tag, ok := strings.CutPrefix(match, `"v`); ok {
+		if tag, ok := strings.CutSuffix(tag, `"`); ok {
+			tag = strings.TrimSuffix(tag, redactedSuffix)
+			if version, err := strconv.ParseInt(tag, 10, 64); err == nil && version > 0 {
+				return version, true
+			}
+		}
+	}
+	problem.Write(w, r, problem.New(http.StatusPreconditionFailed, problem.CodePreconditionFailed, "recipe has been modified"))
+	return 0, false
+}
+
+// etagMatches reports whether header, a comma-separated list of entity tags
+// or "*", matches etag. It uses the weak comparison If-None-Match calls for,
+// ignoring the W/ prefix.
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
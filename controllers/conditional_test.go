@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend-app/models"
+	"backend-app/problem"
+)
+
+// conditionalGet sends a GET to handler with the given request headers
+func conditionalGet(handler http.HandlerFunc, target string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", target, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestGetRecipeConditional(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "abebe", "abebe@example.com")
+	created, err := env.recipes.CreateRecipe(context.Background(), &models.Recipe{Title: "Shiro", CreatorID: int64(user.ID), Status: models.StatusPublished})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+
+	rec := conditionalGet(env.recipe.GetRecipe, "/recipe?id=1", nil)
+	etag := rec.Header().Get("ETag")
+	lastModified := rec.Header().Get("Last-Modified")
+	if rec.Code != http.StatusOK || etag != `"v1"` || lastModified == "" {
+		t.Fatalf("status = %d, ETag %q, Last-Modified %q; want 200 with validators", rec.Code, etag, lastModified)
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"matching ETag", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"ETag in list", map[string]string{"If-None-Match": `"v7", ` + etag}, http.StatusNotModified},
+		{"weak ETag", map[string]string{"If-None-Match": "W/" + etag}, http.StatusNotModified},
+		{"star", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"other ETag", map[string]string{"If-None-Match": `"v7"`}, http.StatusOK},
+		{"not modified since", map[string]string{"If-Modified-Since": lastModified}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": created.UpdatedAt.Add(-time.Hour).UTC().Format(http.TimeFormat)}, http.StatusOK},
+		{"ETag wins over date", map[string]string{"If-None-Match": `"v7"`, "If-Modified-Since": lastModified}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := conditionalGet(env.recipe.GetRecipe, "/recipe?id=1", tt.headers)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if rec.Code == http.StatusNotModified && rec.Body.Len() != 0 {
+				t.Errorf("304 response has a body: %s", rec.Body)
+			}
+			if rec.Header().Get("ETag") != etag {
+				t.Errorf("ETag = %q, want %q", rec.Header().Get("ETag"), etag)
+			}
+		})
+	}
+
+	// An update changes the ETag
+	update := map[string]interface{}{"id": created.ID, "title": "Spicy Shiro"}
+	serveAs(t, user, env.recipe.UpdateRecipe, "PUT", "/recipe/update", update)
+	rec = conditionalGet(env.recipe.GetRecipe, "/recipe?id=1", map[string]string{"If-None-Match": etag})
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"v2"` {
+		t.Errorf("after update: status = %d, ETag %q; want 200 with \"v2\"", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestGetAllRecipesConditional(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "abebe", "abebe@example.com")
+	for _, title := range []string{"Shiro", "Kitfo"} {
+		if _, err := env.recipes.CreateRecipe(context.Background(), &models.Recipe{Title: title, CreatorID: int64(user.ID), Status: models.StatusPublished}); err != nil {
+			t.Fatalf("CreateRecipe: %v", err)
+		}
+	}
+
+	rec := conditionalGet(env.recipe.GetAllRecipes, "/recipes", nil)
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d, ETag %q; want 200 with an ETag", rec.Code, etag)
+	}
+	rec = conditionalGet(env.recipe.GetAllRecipes, "/recipes", map[string]string{"If-None-Match": etag})
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("status = %d, want 304", rec.Code)
+	}
+
+	// Deleting a recipe changes the list's ETag
+	if err := env.recipes.DeleteRecipe(context.Background(), 2, 0); err != nil {
+		t.Fatalf("DeleteRecipe: %v", err)
+	}
+	rec = conditionalGet(env.recipe.GetAllRecipes, "/recipes", map[string]string{"If-None-Match": etag})
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("after delete: status = %d, ETag %q; want 200 with a new ETag", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestUpdateRecipeIfMatch(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "abebe", "abebe@example.com")
+	created, err := env.recipes.CreateRecipe(context.Background(), &models.Recipe{Title: "Shiro", CreatorID: int64(user.ID), Status: models.StatusPublished})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+
+	update := func(ifMatch, title string) *httptest.ResponseRecorder {
+		body := map[string]interface{}{"id": created.ID, "title": title}
+		return serveAs(t, user, func(w http.ResponseWriter, r *http.Request) {
+			if ifMatch != "" {
+				r.Header.Set("If-Match", ifMatch)
+			}
+			env.recipe.UpdateRecipe(w, r)
+		}, "PUT", "/recipe/update", body)
+	}
+
+	rec := update(`"v1"`, "Spicy Shiro")
+	var updated models.Recipe
+	decodeBody(t, rec, http.StatusOK, &updated)
+	if updated.Version != 2 || rec.Header().Get("ETag") != `"v2"` {
+		t.Errorf("version = %d, ETag %q; want 2 and \"v2\"", updated.Version, rec.Header().Get("ETag"))
+	}
+
+	// A client still holding version 1 must not overwrite version 2
+	for _, ifMatch := range []string{`"v1"`, `"v1-r"`, `W/"v2"`, `"v2", "v3"`, "garbage"} {
+		rec = update(ifMatch, "Mild Shiro")
+		expectProblem(t, rec, http.StatusPreconditionFailed, problem.CodePreconditionFailed)
+	}
+	stored, err := env.recipes.GetRecipeByID(context.Background(), created.ID)
+	if err != nil || stored.Title != "Spicy Shiro" {
+		t.Errorf("stored recipe = %+v, %v; want the first update kept", stored, err)
+	}
+
+	// Copies without the share link are tagged with the same version
+	decodeBody(t, update(`"v2-r"`, "Shiro Wot"), http.StatusOK, &updated)
+
+	// Without If-Match, or with *, the update is unconditional
+	for _, ifMatch := range []string{"", "*"} {
+		decodeBody(t, update(ifMatch, "Shiro Wat"), http.StatusOK, &updated)
+	}
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import "net/http"
+
+// ForkRecipe copies a recipe into the acting user's ownership
+func (rc *RecipeController) ForkRecipe(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	recipeID, ok := recipeIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	fork, err := rc.RecipeService.ForkRecipe(r.Context(), actorID, recipeID)
+	if err != nil {
+		writeError(w, r, err, "recipe")
+		return
+	}
+	w.Header().Set("ETag", recipeETag(fork))
+	writeJSON(w, http.StatusCreated, fork)
+}
+
+// GetLineage lists the recipes a recipe was forked from and its forks
+func (rc *RecipeController) GetLineage(w http.ResponseWriter, r *http.Request) {
+	recipeID, ok := recipeIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	lineage, err := rc.RecipeService.Lineage(r.Context(), viewerID(r), recipeID)
+	if err != nil {
+		writeError(w, r, err, "recipe")
+		return
+	}
+	writeJSON(w, http.StatusOK, lineage)
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend-app/models"
+	"backend-app/problem"
+	"github.com/gorilla/mux"
+)
+
+func TestForkRecipe(t *testing.T) {
+	env := newTestEnv(t)
+	author := env.createUser(t, "abebe", "abebe@example.com")
+	forker := env.createUser(t, "kebede", "kebede@example.com")
+	original, err := env.recipes.CreateRecipe(context.Background(), &models.Recipe{
+		Title:       "Shiro",
+		Ingredients: []string{"chickpea flour"},
+		Steps:       []string{"Simmer"},
+		CreatorID:   int64(author.ID),
+		Images:      []string{"uploads/shiro.jpg"},
+		Status:      models.StatusPublished,
+	})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/recipes/{id}/fork", env.recipe.ForkRecipe)
+	router.HandleFunc("/recipes/{id}/lineage", env.recipe.GetLineage)
+	router.HandleFunc("/recipes/{id}/status", env.recipe.SetRecipeStatus)
+	route := func(user *models.User, method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, authenticate(httptest.NewRequest(method, target, nil), user))
+		return rec
+	}
+
+	var fork models.Recipe
+	decodeBody(t, route(forker, "POST", "/recipes/1/fork"), http.StatusCreated, &fork)
+	if fork.CreatorID != int64(forker.ID) || fork.Title != "Shiro" || len(fork.Steps) != 1 || len(fork.Images) != 1 ||
+		fork.ForkedFrom == nil || fork.ForkedFrom.RecipeID != original.ID || fork.ForkedFrom.CreatorID != int64(author.ID) {
+		t.Fatalf("fork = %+v, want a copy owned by the forker", fork)
+	}
+	if fork.Status != models.StatusDraft {
+		t.Errorf("fork status = %q, want a draft", fork.Status)
+	}
+
+	// Other users only see the fork once it is published
+	expectProblem(t, route(author, "POST", "/recipes/2/fork"), http.StatusNotFound, problem.CodeNotFound)
+	rec := httptest.NewRecorder()
+	publish := authenticate(httptest.NewRequest("PUT", "/recipes/2/status", strings.NewReader(`{"status": "published"}`)), forker)
+	router.ServeHTTP(rec, publish)
+	decodeBody(t, rec, http.StatusOK, &fork)
+	var second models.Recipe
+	decodeBody(t, route(author, "POST", "/recipes/2/fork"), http.StatusCreated, &second)
+
+	var lineage models.Lineage
+	decodeBody(t, route(nil, "GET", "/recipes/1/lineage"), http.StatusOK, &lineage)
+	if lineage.ForkCount != 1 || lineage.Forks[0].RecipeID != fork.ID || len(lineage.Ancestors) != 0 {
+		t.Errorf("lineage of the original = %+v, want one fork", lineage)
+	}
+
+	// The forks outlive the original, which stays in their lineage
+	rec = serveAs(t, author, env.recipe.DeleteRecipe, "DELETE", "/recipe/delete?id=1", nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d", rec.Code)
+	}
+	decodeBody(t, route(author, "GET", "/recipes/3/lineage"), http.StatusOK, &lineage)
+	if len(lineage.Ancestors) != 2 || lineage.Ancestors[0].RecipeID != fork.ID ||
+		lineage.Ancestors[1].RecipeID != original.ID || !lineage.Ancestors[1].Deleted || lineage.Ancestors[1].CreatorID != int64(author.ID) {
+		t.Errorf("ancestors = %+v, want the fork and the deleted original", lineage.Ancestors)
+	}
+
+	expectProblem(t, route(nil, "POST", "/recipes/1/fork"), http.StatusUnauthorized, problem.CodeUnauthorized)
+	expectProblem(t, route(forker, "POST", "/recipes/1/fork"), http.StatusNotFound, problem.CodeNotFound)
+	expectProblem(t, route(forker, "POST", "/recipes/x/fork"), http.StatusBadRequest, problem.CodeBadRequest)
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend-app/media"
+	"backend-app/middleware"
+	"backend-app/models"
+	"backend-app/problem"
+	"backend-app/service"
+	"backend-app/store/memory"
+)
+
+// testUploadLimits keeps upload tests small
+var testUploadLimits = UploadLimits{MaxRequestBytes: 1 << 20, MaxMemoryBytes: 1 << 10}
+
+// testEnv wires the controllers to services over in-memory stores
+type testEnv struct {
+	db         *memory.DB
+	users      *memory.UserStore
+	categories *memory.CategoryStore
+	recipes    *memory.RecipeStore
+	media      *memory.MediaStore
+	blobs      *media.Store
+
+	auth     *AuthController
+	user     *UserController
+	category *CategoryController
+	recipe   *RecipeController
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	db := memory.NewDB()
+	env := &testEnv{
+		db:         db,
+		users:      memory.NewUserStore(db),
+		categories: memory.NewCategoryStore(db),
+		recipes:    memory.NewRecipeStore(db),
+		media:      memory.NewMediaStore(db),
+		blobs:      media.NewStore(t.TempDir()),
+	}
+	userService := service.NewUserService(env.users)
+	env.auth = NewAuthController(userService, middleware.NewKeySet([][]byte{[]byte("test-secret")}), time.Hour)
+	env.user = NewUserController(userService)
+	env.category = NewCategoryController(service.NewCategoryService(env.categories))
+	recipeService := service.NewRecipeService(env.recipes, env.media, env.blobs)
+	recipeService.Users = env.users
+	env.recipe = NewRecipeController(recipeService, testUploadLimits)
+	return env
+}
+
+// createUser stores a user directly in the store
+func (env *testEnv) createUser(t *testing.T, username, email string) *models.User {
+	t.Helper()
+
+	user := &models.User{Username: username, Email: email, PasswordHash: "hash"}
+	if err := env.users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return user
+}
+
+// createCategory stores a category directly in the store
+func (env *testEnv) createCategory(t *testing.T, name string) *models.Category {
+	t.Helper()
+
+	category := &models.Category{Name: name}
+	if err := env.categories.CreateCategory(context.Background(), category); err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	return category
+}
+
+// serve sends an unauthenticated request with an optional JSON body to
+// handler
+func serve(t *testing.T, handler http.HandlerFunc, method, target string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	return serveAs(t, nil, handler, method, target, body)
+}
+
+// serveAs sends a request on behalf of user, who may be nil for an
+// unauthenticated request
+func serveAs(t *testing.T, user *models.User, handler http.HandlerFunc, method, target string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("encoding request body: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, target, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler(rec, authenticate(req, user))
+	return rec
+}
+
+// authenticate attaches user to the request the way AuthMiddleware does
+func authenticate(req *http.Request, user *models.User) *http.Request {
+	if user == nil {
+		return req
+	}
+	return req.WithContext(middleware.WithUserID(req.Context(), int64(user.ID)))
+}
+
+// decodeBody decodes a JSON response into v after checking its status
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder, status int, v interface{}) {
+	t.Helper()
+
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d; body: %s", rec.Code, status, rec.Body)
+	}
+	if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+		t.Fatalf("decoding response body: %v", err)
+	}
+}
+
+// expectProblem checks that the response is a problem with the given status
+// and code and returns it
+func expectProblem(t *testing.T, rec *httptest.ResponseRecorder, status int, code problem.Code) problem.Problem {
+	t.Helper()
+
+	if ct := rec.Header().Get("Content-Type"); ct != problem.ContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, problem.ContentType)
+	}
+	var p problem.Problem
+	decodeBody(t, rec, status, &p)
+	if p.Code != code {
+		t.Errorf("code = %q, want %q", p.Code, code)
+	}
+	return p
+}
+
+// fields returns the names of the rejected fields of a validation problem
+func fields(p problem.Problem) map[string]bool {
+	names := make(map[string]bool)
+	for _, fe := range p.Errors {
+		names[fe.Field] = true
+	}
+	return names
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"net/http"
+
+	"backend-app/models"
+)
+
+// SetRecipeStatus moves a recipe through its lifecycle: it publishes,
+// schedules or archives it. Like an update it honours If-Match.
+func (rc *RecipeController) SetRecipeStatus(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	recipeID, ok := recipeIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.RecipeStatusRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	req.ID = recipeID
+	if req.ExpectedVersion, ok = ifMatchVersion(w, r); !ok {
+		return
+	}
+
+	recipe, err := rc.RecipeService.SetRecipeStatus(r.Context(), actorID, &req)
+	if err != nil {
+		writeError(w, r, err, "recipe")
+		return
+	}
+	w.Header().Set("ETag", recipeETag(recipe))
+	writeJSON(w, http.StatusOK, recipe)
+}
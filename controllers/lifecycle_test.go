@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend-app/models"
+	"backend-app/problem"
+	"github.com/gorilla/mux"
+)
+
+func TestRecipeLifecycle(t *testing.T) {
+	env := newTestEnv(t)
+	author := env.createUser(t, "abebe", "abebe@example.com")
+	reader := env.createUser(t, "kebede", "kebede@example.com")
+
+	router := mux.NewRouter()
+	router.HandleFunc("/recipes/{id}/status", env.recipe.SetRecipeStatus)
+	setStatus := func(user *models.User, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, authenticate(httptest.NewRequest("PUT", "/recipes/1/status", strings.NewReader(body)), user))
+		return rec
+	}
+	listed := func(user *models.User) int {
+		var recipes []models.Recipe
+		decodeBody(t, serveAs(t, user, env.recipe.GetAllRecipes, "GET", "/recipes", nil), http.StatusOK, &recipes)
+		return len(recipes)
+	}
+
+	var draft models.Recipe
+	decodeBody(t, serveAs(t, author, env.recipe.CreateRecipe, "POST", "/recipe/create", map[string]interface{}{"title": "Shiro"}),
+		http.StatusCreated, &draft)
+	if draft.Status != models.StatusDraft {
+		t.Fatalf("new recipe status = %q, want a draft", draft.Status)
+	}
+
+	// Drafts are hidden from everyone but their author
+	expectProblem(t, serveAs(t, reader, env.recipe.GetRecipe, "GET", "/recipe?id=1", nil), http.StatusNotFound, problem.CodeNotFound)
+	expectProblem(t, serve(t, env.recipe.ListRevisions, "GET", "/recipe/revisions?id=1", nil), http.StatusNotFound, problem.CodeNotFound)
+	if rec := serveAs(t, author, env.recipe.GetRecipe, "GET", "/recipe?id=1", nil); rec.Code != http.StatusOK {
+		t.Errorf("author's GET status = %d, want 200", rec.Code)
+	}
+	if n := listed(reader); n != 0 {
+		t.Errorf("reader lists %d recipes, want none", n)
+	}
+	if n := listed(author); n != 1 {
+		t.Errorf("author lists %d recipes, want the draft", n)
+	}
+
+	// Only the author moves the recipe, and only along the lifecycle
+	expectProblem(t, setStatus(reader, `{"status": "published"}`), http.StatusNotFound, problem.CodeNotFound)
+	expectProblem(t, setStatus(author, `{"status": "archived"}`), http.StatusConflict, problem.CodeInvalidTransition)
+	expectProblem(t, setStatus(author, `{"status": "gone"}`), http.StatusUnprocessableEntity, problem.CodeValidationFailed)
+
+	// A publication in the future is left to the scheduler
+	publishAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	var scheduled models.Recipe
+	decodeBody(t, setStatus(author, `{"status": "published", "publish_at": "`+publishAt+`"}`), http.StatusOK, &scheduled)
+	if scheduled.Status != models.StatusDraft || scheduled.PublishAt == nil {
+		t.Fatalf("scheduled recipe = %+v, want a draft with a publication time", scheduled)
+	}
+	if published, err := env.recipes.PublishDueRecipes(context.Background(), scheduled.PublishAt.Add(time.Second)); err != nil || len(published) != 1 {
+		t.Fatalf("PublishDueRecipes = %v, %v", published, err)
+	}
+	if n := listed(reader); n != 1 {
+		t.Errorf("reader lists %d recipes after publication, want 1", n)
+	}
+
+	// Archived recipes can still be read but are no longer listed
+	rec := setStatus(author, `{"status": "archived"}`)
+	var archived models.Recipe
+	decodeBody(t, rec, http.StatusOK, &archived)
+	if archived.Status != models.StatusArchived || rec.Header().Get("ETag") != recipeETag(&archived) {
+		t.Errorf("archived recipe = %+v, ETag %q", archived, rec.Header().Get("ETag"))
+	}
+	if n := listed(reader); n != 0 {
+		t.Errorf("reader lists %d recipes after archiving, want none", n)
+	}
+	if rec := serve(t, env.recipe.GetRecipe, "GET", "/recipe?id=1", nil); rec.Code != http.StatusOK {
+		t.Errorf("anonymous GET of an archived recipe = %d, want 200", rec.Code)
+	}
+	expectProblem(t, setStatus(author, `{"status": "draft"}`), http.StatusConflict, problem.CodeInvalidTransition)
+}
@@ -1,217 +1,168 @@
 package controllers
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
-	"log"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
+	"time"
 
 	"backend-app/models"
-	"backend-app/repository"
+	"backend-app/problem"
+	"backend-app/service"
+	"github.com/gorilla/mux"
 )
 
 type RecipeController struct {
-    RecipeRepository *repository.RecipeRepository
+    RecipeService *service.RecipeService
+    Uploads       UploadLimits
 }
 
-func NewRecipeController(recipeRepo *repository.RecipeRepository) *RecipeController {
+func NewRecipeController(recipeService *service.RecipeService, uploads UploadLimits) *RecipeController {
     return &RecipeController{
-        RecipeRepository: recipeRepo,
+        RecipeService: recipeService,
+        Uploads:       uploads,
     }
 }
 
 // CreateRecipe creates a new recipe with image upload
 func (rc *RecipeController) CreateRecipe(w http.ResponseWriter, r *http.Request) {
-    // Parse request body for recipe details
-    var newRecipe models.Recipe
-    err := json.NewDecoder(r.Body).Decode(&newRecipe)
-    if err != nil {
-        http.Error(w, "Failed to decode request body", http.StatusBadRequest)
-        return
-    }
-
-    // Validate required fields
-    if newRecipe.Title == "" || newRecipe.CreatorID == 0 {
-        http.Error(w, "Title and creator ID are required", http.StatusBadRequest)
+    actorID, ok := currentUserID(w, r)
+    if !ok {
         return
     }
 
-    // Handle image upload
-    images, err := uploadImages(r)
-    if err != nil {
-        http.Error(w, "Failed to upload images", http.StatusInternalServerError)
+    // Parse and validate the recipe details
+    var req models.CreateRecipeRequest
+    if !decodeRecipe(w, r, rc.Uploads, &req, &req.RecipeFields) {
         return
     }
-    newRecipe.Images = images
 
     // Create recipe in the database
-    createdRecipe, err := rc.RecipeRepository.CreateRecipe(&newRecipe)
+    createdRecipe, err := rc.RecipeService.CreateRecipe(r.Context(), actorID, &req)
     if err != nil {
-        log.Println("Error creating recipe:", err)
-        http.Error(w, "Failed to create recipe", http.StatusInternalServerError)
+        writeError(w, r, err, "recipe")
         return
     }
 
     // Return created recipe as JSON response
-    w.Header().Set("Content-Type", "application/json")
-    w.WriteHeader(http.StatusCreated)
-    json.NewEncoder(w).Encode(createdRecipe)
+    w.Header().Set("ETag", recipeETag(createdRecipe))
+    writeJSON(w, http.StatusCreated, createdRecipe)
 }
 
 // UpdateRecipe updates an existing recipe
 func (rc *RecipeController) UpdateRecipe(w http.ResponseWriter, r *http.Request) {
-    // Parse request body for updated recipe details
-    var updatedRecipe models.Recipe
-    err := json.NewDecoder(r.Body).Decode(&updatedRecipe)
-    if err != nil {
-        http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+    actorID, ok := currentUserID(w, r)
+    if !ok {
         return
     }
 
-    // Validate required fields
-    if updatedRecipe.ID == 0 {
-        http.Error(w, "Recipe ID is required", http.StatusBadRequest)
+    // Parse and validate the updated recipe details
+    var req models.UpdateRecipeRequest
+    if !decodeRecipe(w, r, rc.Uploads, &req, &req.RecipeFields) {
         return
     }
 
-    // Handle image upload
-    images, err := uploadImages(r)
-    if err != nil {
-        http.Error(w, "Failed to upload images", http.StatusInternalServerError)
+    // Only overwrite the version the client has seen, if it says which
+    expectedVersion, ok := ifMatchVersion(w, r)
+    if !ok {
         return
     }
-    updatedRecipe.Images = images
+    req.ExpectedVersion = expectedVersion
 
     // Update recipe in the database
-    err = rc.RecipeRepository.UpdateRecipe(&updatedRecipe)
+    updatedRecipe, err := rc.RecipeService.UpdateRecipe(r.Context(), actorID, &req)
     if err != nil {
-        log.Println("Error updating recipe:", err)
-        http.Error(w, "Failed to update recipe", http.StatusInternalServerError)
+        writeError(w, r, err, "recipe")
         return
     }
 
-    // Return success response
-    w.WriteHeader(http.StatusOK)
-    fmt.Fprintf(w, "Recipe updated successfully")
+    // Return updated recipe as JSON response
+    w.Header().Set("ETag", recipeETag(updatedRecipe))
+    writeJSON(w, http.StatusOK, updatedRecipe)
 }
 
 // DeleteRecipe deletes a recipe
 func (rc *RecipeController) DeleteRecipe(w http.ResponseWriter, r *http.Request) {
-    // Parse request parameters for recipe ID
-    id := r.URL.Query().Get("id")
-    if id == "" {
-        http.Error(w, "Recipe ID is required", http.StatusBadRequest)
+    actorID, ok := currentUserID(w, r)
+    if !ok {
         return
     }
 
-    // Convert ID to int64
-    recipeID := convertToInt64(id)
-    if recipeID == 0 {
-        http.Error(w, "Invalid Recipe ID", http.StatusBadRequest)
+    // Parse request parameters for recipe ID
+    recipeID, ok := recipeIDParam(w, r)
+    if !ok {
         return
     }
 
     // Delete recipe from the database
-    err := rc.RecipeRepository.DeleteRecipe(recipeID)
+    err := rc.RecipeService.DeleteRecipe(r.Context(), actorID, recipeID)
     if err != nil {
-        log.Println("Error deleting recipe:", err)
-        http.Error(w, "Failed to delete recipe", http.StatusInternalServerError)
+        writeError(w, r, err, "recipe")
         return
     }
 
     // Return success response
-    w.WriteHeader(http.StatusOK)
-    fmt.Fprintf(w, "Recipe deleted successfully")
+    w.WriteHeader(http.StatusNoContent)
 }
 
-// GetRecipe retrieves a single recipe by ID
+// GetRecipe retrieves a single recipe by ID. Drafts are only found by their
+// creator.
 func (rc *RecipeController) GetRecipe(w http.ResponseWriter, r *http.Request) {
     // Parse request parameters for recipe ID
-    id := r.URL.Query().Get("id")
-    if id == "" {
-        http.Error(w, "Recipe ID is required", http.StatusBadRequest)
-        return
-    }
-
-    // Convert ID to int64
-    recipeID := convertToInt64(id)
-    if recipeID == 0 {
-        http.Error(w, "Invalid Recipe ID", http.StatusBadRequest)
+    recipeID, ok := recipeIDParam(w, r)
+    if !ok {
         return
     }
 
     // Retrieve recipe from the database
-    recipe, err := rc.RecipeRepository.GetRecipeByID(recipeID)
+    recipe, err := rc.RecipeService.GetRecipe(r.Context(), viewerID(r), recipeID)
     if err != nil {
-        log.Println("Error retrieving recipe:", err)
-        http.Error(w, "Failed to retrieve recipe", http.StatusInternalServerError)
+        writeError(w, r, err, "recipe")
         return
     }
 
-    // Return recipe as JSON response
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(recipe)
+    // Return recipe as JSON response unless the client's copy is current
+    if notModified(w, r, recipeETag(recipe), recipe.UpdatedAt) {
+        return
+    }
+    writeJSON(w, http.StatusOK, recipe)
 }
 
-// GetAllRecipes retrieves all recipes
+// GetAllRecipes retrieves the published public recipes, the published
+// recipes shared with the authenticated user and the user's own recipes
 func (rc *RecipeController) GetAllRecipes(w http.ResponseWriter, r *http.Request) {
     // Retrieve all recipes from the database
-    recipes, err := rc.RecipeRepository.GetAllRecipes()
+    recipes, err := rc.RecipeService.GetAllRecipes(r.Context(), viewerID(r))
     if err != nil {
-        log.Println("Error retrieving recipes:", err)
-        http.Error(w, "Failed to retrieve recipes", http.StatusInternalServerError)
+        writeError(w, r, err, "recipe")
         return
     }
 
-    // Return recipes as JSON response
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(recipes)
-}
-
-// Helper function to convert string to int64
-func convertToInt64(str string) int64 {
-    num, _ := strconv.ParseInt(str, 10, 64)
-    return num
+    // Return recipes as JSON response unless the client's copy is current.
+    // Deleting a recipe does not advance any modification time, so the list
+    // is only validated by its ETag.
+    if notModified(w, r, recipesETag(recipes), time.Time{}) {
+        return
+    }
+    writeJSON(w, http.StatusOK, recipes)
 }
 
-// uploadImages handles image uploads
-func uploadImages(r *http.Request) ([]string, error) {
-    var images []string
-
-    err := r.ParseMultipartForm(10 << 20) // 10 MB maximum file size
-    if err != nil {
-        return images, err
+// recipeIDParam reads the recipe ID from the route's {id} variable or, on
+// routes without one, the "id" query parameter. On failure it writes the
+// problem response and returns false.
+func recipeIDParam(w http.ResponseWriter, r *http.Request) (int64, bool) {
+    id, ok := mux.Vars(r)["id"]
+    if !ok {
+        id = r.URL.Query().Get("id")
     }
-
-    // Retrieve files from form data
-    files := r.MultipartForm.File["images"]
-    for _, file := range files {
-        // Open uploaded file
-        src, err := file.Open()
-        if err != nil {
-            return images, err
-        }
-        defer src.Close()
-
-        // Create destination file
-        dst, err := os.Create(filepath.Join("uploads", file.Filename))
-        if err != nil {
-            return images, err
-        }
-        defer dst.Close()
-
-        // Copy file to destination
-        if _, err := io.Copy(dst, src); err != nil {
-            return images, err
-        }
-
-        // Store image URL or path
-        images = append(images, filepath.Join("uploads", file.Filename))
+    if id == "" {
+        problem.Write(w, r, problem.BadRequest("recipe id is required"))
+        return 0, false
     }
 
-    return images, nil
+    recipeID, err := strconv.ParseInt(id, 10, 64)
+    if err != nil || recipeID <= 0 {
+        problem.Write(w, r, problem.BadRequest("recipe id must be a positive integer"))
+        return 0, false
+    }
+    return recipeID, true
 }
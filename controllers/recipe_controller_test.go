@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"backend-app/models"
+	"backend-app/problem"
+)
+
+// multipartRecipe builds a multipart recipe request by user with the given
+// images
+func multipartRecipe(t *testing.T, user *models.User, method, target string, recipe interface{}, images map[string]string) *http.Request {
+	t.Helper()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	doc, err := json.Marshal(recipe)
+	if err != nil {
+		t.Fatalf("encoding recipe: %v", err)
+	}
+	mw.WriteField("recipe", string(doc))
+	for name, content := range images {
+		part, err := mw.CreateFormFile("images", name)
+		if err != nil {
+			t.Fatalf("CreateFormFile: %v", err)
+		}
+		part.Write([]byte(content))
+	}
+	mw.Close()
+
+	req := httptest.NewRequest(method, target, &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return authenticate(req, user)
+}
+
+func TestCreateRecipe(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "abebe", "abebe@example.com")
+
+	var req models.CreateRecipeRequest
+	req.Title = "Doro Wat"
+	req.Ingredients = []string{"chicken", "berbere"}
+	req.Steps = []string{"Simmer for two hours"}
+	req.PrepTime = 120
+	rec := serveAs(t, user, env.recipe.CreateRecipe, "POST", "/recipe/create", req)
+
+	var created models.Recipe
+	decodeBody(t, rec, http.StatusCreated, &created)
+	if created.ID == 0 || created.Title != "Doro Wat" || created.CreatorID != int64(user.ID) {
+		t.Errorf("created recipe = %+v", created)
+	}
+}
+
+func TestCreateRecipeRequiresAuthentication(t *testing.T) {
+	env := newTestEnv(t)
+
+	rec := serve(t, env.recipe.CreateRecipe, "POST", "/recipe/create", map[string]interface{}{"title": "Doro Wat"})
+
+	expectProblem(t, rec, http.StatusUnauthorized, problem.CodeUnauthorized)
+}
+
+func TestCreateRecipeWithImages(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "abebe", "abebe@example.com")
+
+	recipe := map[string]interface{}{"title": "Kitfo"}
+	rec := httptest.NewRecorder()
+	env.recipe.CreateRecipe(rec, multipartRecipe(t, user, "POST", "/recipe/create", recipe, map[string]string{"kitfo.jpg": "jpeg"}))
+
+	var created models.Recipe
+	decodeBody(t, rec, http.StatusCreated, &created)
+	if len(created.Images) != 1 {
+		t.Fatalf("images = %v, want one image", created.Images)
+	}
+	content, err := os.ReadFile(created.Images[0])
+	if err != nil || string(content) != "jpeg" {
+		t.Errorf("stored image = %q, %v", content, err)
+	}
+
+	// The image is referenced, so it is not an orphan even past the grace period
+	orphans, err := env.media.OrphanedMedia(context.Background(), time.Now().Add(time.Hour))
+	if err != nil || len(orphans) != 0 {
+		t.Errorf("orphans = %v, %v; want none", orphans, err)
+	}
+}
+
+func TestCreateRecipeValidation(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "abebe", "abebe@example.com")
+
+	ingredients := make([]string, 101)
+	for i := range ingredients {
+		ingredients[i] = "salt"
+	}
+	body := map[string]interface{}{
+		"title":       strings.Repeat("a", 256),
+		"ingredients": ingredients,
+		"time":        -5,
+	}
+	rec := serveAs(t, user, env.recipe.CreateRecipe, "POST", "/recipe/create", body)
+
+	p := expectProblem(t, rec, http.StatusUnprocessableEntity, problem.CodeValidationFailed)
+	got := fields(p)
+	for _, field := range []string{"title", "ingredients", "time"} {
+		if !got[field] {
+			t.Errorf("missing field error for %q in %+v", field, p.Errors)
+		}
+	}
+}
+
+func TestCreateRecipeTooManyImages(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "abebe", "abebe@example.com")
+
+	images := make(map[string]string)
+	for _, name := range strings.Split("a b c d e f g h i j k", " ") {
+		images[name+".jpg"] = "jpeg"
+	}
+	recipe := map[string]interface{}{"title": "Tibs"}
+	rec := httptest.NewRecorder()
+	env.recipe.CreateRecipe(rec, multipartRecipe(t, user, "POST", "/recipe/create", recipe, images))
+
+	p := expectProblem(t, rec, http.StatusUnprocessableEntity, problem.CodeValidationFailed)
+	if !fields(p)["images"] {
+		t.Errorf("missing field error for images in %+v", p.Errors)
+	}
+	if entries, _ := os.ReadDir(env.blobs.Dir); len(entries) != 0 {
+		t.Errorf("stored %d images for a rejected request", len(entries))
+	}
+}
+
+func TestCreateRecipeUnknownCategory(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "abebe", "abebe@example.com")
+
+	rec := serveAs(t, user, env.recipe.CreateRecipe, "POST", "/recipe/create", map[string]interface{}{"title": "Shiro", "category_id": 99})
+
+	expectProblem(t, rec, http.StatusUnprocessableEntity, problem.CodeInvalidReference)
+}
+
+func TestGetMissingRecipe(t *testing.T) {
+	env := newTestEnv(t)
+
+	rec := serve(t, env.recipe.GetRecipe, "GET", "/recipe?id=5", nil)
+
+	p := expectProblem(t, rec, http.StatusNotFound, problem.CodeNotFound)
+	if p.Detail != "recipe not found" {
+		t.Errorf("detail = %q, want %q", p.Detail, "recipe not found")
+	}
+}
+
+func TestUpdateRecipeKeepsDroppedImagesForHistory(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "abebe", "abebe@example.com")
+
+	recipe := map[string]interface{}{"title": "Kitfo"}
+	rec := httptest.NewRecorder()
+	env.recipe.CreateRecipe(rec, multipartRecipe(t, user, "POST", "/recipe/create", recipe, map[string]string{"kitfo.jpg": "jpeg"}))
+	var created models.Recipe
+	decodeBody(t, rec, http.StatusCreated, &created)
+
+	update := map[string]interface{}{"id": created.ID, "title": "Kitfo Special"}
+	rec = serveAs(t, user, env.recipe.UpdateRecipe, "PUT", "/recipe/update", update)
+	var updated models.Recipe
+	decodeBody(t, rec, http.StatusOK, &updated)
+
+	// The first revision still shows the image
+	orphans, err := env.media.OrphanedMedia(context.Background(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("OrphanedMedia: %v", err)
+	}
+	if len(orphans) != 0 {
+		t.Errorf("orphans = %v, want none while a revision references them", orphans)
+	}
+
+	// Deleted recipes keep their images until they are purged
+	serveAs(t, user, env.recipe.DeleteRecipe, "DELETE", "/recipe/delete?id=1", nil)
+	if _, err := env.recipes.PurgeRecipes(context.Background(), time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("PurgeRecipes: %v", err)
+	}
+	orphans, err = env.media.OrphanedMedia(context.Background(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("OrphanedMedia: %v", err)
+	}
+	if len(orphans) != 1 || orphans[0] != created.Images[0] {
+		t.Errorf("orphans = %v, want %v", orphans, created.Images)
+	}
+}
+
+func TestRecipeChangesRequireOwnership(t *testing.T) {
+	env := newTestEnv(t)
+	owner := env.createUser(t, "abebe", "abebe@example.com")
+	other := env.createUser(t, "kebede", "kebede@example.com")
+	created, err := env.recipes.CreateRecipe(context.Background(), &models.Recipe{Title: "Shiro", CreatorID: int64(owner.ID), Status: models.StatusPublished})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+
+	rec := serveAs(t, other, env.recipe.UpdateRecipe, "PUT", "/recipe/update", map[string]interface{}{"id": created.ID, "title": "Mine now"})
+	expectProblem(t, rec, http.StatusForbidden, problem.CodeForbidden)
+
+	rec = serveAs(t, other, env.recipe.DeleteRecipe, "DELETE", "/recipe/delete?id=1", nil)
+	expectProblem(t, rec, http.StatusForbidden, problem.CodeForbidden)
+
+	stored, err := env.recipes.GetRecipeByID(context.Background(), created.ID)
+	if err != nil || stored.Title != "Shiro" {
+		t.Errorf("stored recipe = %+v, %v; want it unchanged", stored, err)
+	}
+}
+
+func TestDeleteRecipe(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "abebe", "abebe@example.com")
+	created, err := env.recipes.CreateRecipe(context.Background(), &models.Recipe{Title: "Shiro", CreatorID: int64(user.ID)})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+
+	rec := serveAs(t, user, env.recipe.DeleteRecipe, "DELETE", "/recipe/delete?id=1", nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	rec = serveAs(t, user, env.recipe.DeleteRecipe, "DELETE", "/recipe/delete?id=1", nil)
+	expectProblem(t, rec, http.StatusNotFound, problem.CodeNotFound)
+
+	if _, err := env.recipes.GetRecipeByID(context.Background(), created.ID); err == nil {
+		t.Error("recipe still exists after delete")
+	}
+}
+
+func TestCreateRecipeRejectsOversizedUpload(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "abebe", "abebe@example.com")
+
+	images := map[string]string{"huge.jpg": strings.Repeat("x", int(testUploadLimits.MaxRequestBytes))}
+	rec := httptest.NewRecorder()
+	env.recipe.CreateRecipe(rec, multipartRecipe(t, user, "POST", "/recipe/create", map[string]interface{}{"title": "Tibs"}, images))
+
+	expectProblem(t, rec, http.StatusRequestEntityTooLarge, problem.CodePayloadTooLarge)
+}
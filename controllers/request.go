@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"backend-app/middleware"
+	"backend-app/models"
+	"backend-app/problem"
+	"backend-app/validation"
+)
+
+// UploadLimits bounds the size of multipart upload requests
+type UploadLimits struct {
+	// MaxRequestBytes bounds the whole request body
+	MaxRequestBytes int64
+	// MaxMemoryBytes bounds the part of the form held in memory; the rest
+	// is spooled to temporary files
+	MaxMemoryBytes int64
+}
+
+// decodeJSON decodes the JSON body of r into req and validates it. On failure
+// it writes the problem response and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		problem.Write(w, r, problem.BadRequest("request body is not valid JSON"))
+		return false
+	}
+	if p := validation.Struct(req); p != nil {
+		problem.Write(w, r, p)
+		return false
+	}
+	return true
+}
+
+// decodeRecipe decodes a recipe request into req, whose recipe fields are
+// fields, and validates it. Recipes are sent either as a JSON body or as a
+// multipart form with the JSON document in the "recipe" field and the
+// uploaded files in "images".
+func decodeRecipe(w http.ResponseWriter, r *http.Request, limits UploadLimits, req interface{}, fields *models.RecipeFields) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, limits.MaxRequestBytes)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return decodeJSON(w, r, req)
+	}
+
+	if err := r.ParseMultipartForm(limits.MaxMemoryBytes); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			problem.Write(w, r, problem.New(http.StatusRequestEntityTooLarge, problem.CodePayloadTooLarge,
+				fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit)))
+			return false
+		}
+		problem.Write(w, r, problem.BadRequest("request body is not a valid multipart form"))
+		return false
+	}
+	if err := json.Unmarshal([]byte(r.FormValue("recipe")), req); err != nil {
+		problem.Write(w, r, problem.BadRequest("recipe form field is not valid JSON"))
+		return false
+	}
+	fields.Images = r.MultipartForm.File["images"]
+
+	if p := validation.Struct(req); p != nil {
+		problem.Write(w, r, p)
+		return false
+	}
+	return true
+}
+
+// currentUserID returns the ID of the user authenticated by
+// middleware.AuthMiddleware. If there is none it writes a 401 problem and
+// returns false.
+func currentUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		problem.Write(w, r, problem.Unauthorized("authentication required"))
+		return 0, false
+	}
+	return userID, true
+}
+
+// viewerID returns the ID of the authenticated user, or zero for an
+// anonymous request on a route using AuthMiddleware.Optional
+func viewerID(r *http.Request) int64 {
+	userID, _ := middleware.UserIDFromContext(r.Context())
+	return userID
+}
@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"backend-app/problem"
+)
+
+// writeJSON sends v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError maps err to a problem response. resource names the entity the
+// request operated on. Unexpected errors are logged since their cause is not
+// sent to the client.
+func writeError(w http.ResponseWriter, r *http.Request, err error, resource string) {
+	p := problem.FromError(err, resource)
+	if p.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+	problem.Write(w, r, p)
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend-app/problem"
+)
+
+// ListRevisions lists every revision of a recipe, newest first
+func (rc *RecipeController) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	recipeID, ok := recipeIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := rc.RecipeService.ListRevisions(r.Context(), viewerID(r), recipeID)
+	if err != nil {
+		writeError(w, r, err, "recipe")
+		return
+	}
+	writeJSON(w, http.StatusOK, revisions)
+}
+
+// GetRevision retrieves one revision of a recipe
+func (rc *RecipeController) GetRevision(w http.ResponseWriter, r *http.Request) {
+	recipeID, ok := recipeIDParam(w, r)
+	if !ok {
+		return
+	}
+	version, ok := versionParam(w, r, "version")
+	if !ok {
+		return
+	}
+
+	revision, err := rc.RecipeService.GetRevision(r.Context(), viewerID(r), recipeID, version)
+	if err != nil {
+		writeError(w, r, err, "revision")
+		return
+	}
+	writeJSON(w, http.StatusOK, revision)
+}
+
+// DiffRevisions compares the revisions named by the "from" and "to"
+// parameters field by field
+func (rc *RecipeController) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	recipeID, ok := recipeIDParam(w, r)
+	if !ok {
+		return
+	}
+	from, ok := versionParam(w, r, "from")
+	if !ok {
+		return
+	}
+	to, ok := versionParam(w, r, "to")
+	if !ok {
+		return
+	}
+
+	diff, err := rc.RecipeService.DiffRevisions(r.Context(), viewerID(r), recipeID, from, to)
+	if err != nil {
+		writeError(w, r, err, "revision")
+		return
+	}
+	writeJSON(w, http.StatusOK, diff)
+}
+
+// RestoreRevision makes a previous revision the recipe's next version. Like
+// an update it honours If-Match.
+func (rc *RecipeController) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	recipeID, ok := recipeIDParam(w, r)
+	if !ok {
+		return
+	}
+	version, ok := versionParam(w, r, "version")
+	if !ok {
+		return
+	}
+	expectedVersion, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	recipe, err := rc.RecipeService.RestoreRevision(r.Context(), actorID, recipeID, version, expectedVersion)
+	if err != nil {
+		writeError(w, r, err, "recipe")
+		return
+	}
+	w.Header().Set("ETag", recipeETag(recipe))
+	writeJSON(w, http.StatusOK, recipe)
+}
+
+// versionParam reads a revision version from the query parameter name. On
+// failure it writes the problem response and returns false.
+func versionParam(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		problem.Write(w, r, problem.BadRequest(name+" is required"))
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || version <= 0 {
+		problem.Write(w, r, problem.BadRequest(name+" must be a positive integer"))
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"backend-app/models"
+	"backend-app/problem"
+)
+
+func TestRevisionHistory(t *testing.T) {
+	env := newTestEnv(t)
+	owner := env.createUser(t, "abebe", "abebe@example.com")
+	other := env.createUser(t, "kebede", "kebede@example.com")
+	created, err := env.recipes.CreateRecipe(context.Background(), &models.Recipe{
+		Title:       "Shiro",
+		Ingredients: []string{"chickpea flour"},
+		CreatorID:   int64(owner.ID),
+		Status:      models.StatusPublished,
+	})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+
+	update := map[string]interface{}{"id": created.ID, "title": "Spicy Shiro", "ingredients": []string{"chickpea flour", "berbere"}}
+	decodeBody(t, serveAs(t, owner, env.recipe.UpdateRecipe, "PUT", "/recipe/update", update), http.StatusOK, &models.Recipe{})
+
+	var revisions []models.Revision
+	decodeBody(t, serve(t, env.recipe.ListRevisions, "GET", "/recipe/revisions?id=1", nil), http.StatusOK, &revisions)
+	if len(revisions) != 2 || revisions[0].Version != 2 || revisions[0].EditorID != int64(owner.ID) {
+		t.Fatalf("revisions = %+v, want versions 2 and 1 edited by the owner", revisions)
+	}
+
+	var diff models.RevisionDiff
+	decodeBody(t, serve(t, env.recipe.DiffRevisions, "GET", "/recipe/revisions/diff?id=1&from=1&to=2", nil), http.StatusOK, &diff)
+	if len(diff.Changes) != 2 || diff.Changes[0].Field != "title" || diff.Changes[0].From != "Shiro" ||
+		diff.Changes[1].Field != "ingredients" {
+		t.Errorf("changes = %+v, want title and ingredients", diff.Changes)
+	}
+
+	rec := serve(t, env.recipe.GetRevision, "GET", "/recipe/revision?id=1&version=7", nil)
+	expectProblem(t, rec, http.StatusNotFound, problem.CodeNotFound)
+	rec = serve(t, env.recipe.DiffRevisions, "GET", "/recipe/revisions/diff?id=1&from=1", nil)
+	expectProblem(t, rec, http.StatusBadRequest, problem.CodeBadRequest)
+
+	// Only the owner may restore, and restoring adds a version
+	rec = serveAs(t, other, env.recipe.RestoreRevision, "POST", "/recipe/restore?id=1&version=1", nil)
+	expectProblem(t, rec, http.StatusForbidden, problem.CodeForbidden)
+
+	var restored models.Recipe
+	rec = serveAs(t, owner, env.recipe.RestoreRevision, "POST", "/recipe/restore?id=1&version=1", nil)
+	decodeBody(t, rec, http.StatusOK, &restored)
+	if restored.Title != "Shiro" || restored.Version != 3 || len(restored.Ingredients) != 1 || rec.Header().Get("ETag") != `"v3"` {
+		t.Errorf("restored = %+v, ETag %q; want the first revision as version 3", restored, rec.Header().Get("ETag"))
+	}
+
+	var revision models.Revision
+	decodeBody(t, serve(t, env.recipe.GetRevision, "GET", "/recipe/revision?id=1&version=3", nil), http.StatusOK, &revision)
+	if revision.Title != "Shiro" {
+		t.Errorf("revision 3 = %+v, want the restored content", revision)
+	}
+}
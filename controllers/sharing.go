@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend-app/models"
+	"backend-app/problem"
+	"github.com/gorilla/mux"
+)
+
+// SetRecipeVisibility changes who can read a recipe. Like an update it
+// honours If-Match.
+func (rc *RecipeController) SetRecipeVisibility(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	recipeID, ok := recipeIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.RecipeVisibilityRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	req.ID = recipeID
+	if req.ExpectedVersion, ok = ifMatchVersion(w, r); !ok {
+		return
+	}
+
+	recipe, err := rc.RecipeService.SetRecipeVisibility(r.Context(), actorID, &req)
+	if err != nil {
+		writeError(w, r, err, "recipe")
+		return
+	}
+	w.Header().Set("ETag", recipeETag(recipe))
+	writeJSON(w, http.StatusOK, recipe)
+}
+
+// GetSharedRecipe retrieves an unlisted recipe through its share link
+func (rc *RecipeController) GetSharedRecipe(w http.ResponseWriter, r *http.Request) {
+	recipe, err := rc.RecipeService.GetSharedRecipe(r.Context(), mux.Vars(r)["token"])
+	if err != nil {
+		writeError(w, r, err, "recipe")
+		return
+	}
+
+	if notModified(w, r, recipeETag(recipe), recipe.UpdatedAt) {
+		return
+	}
+	writeJSON(w, http.StatusOK, recipe)
+}
+
+// ListShares lists the users a recipe is shared with
+func (rc *RecipeController) ListShares(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	recipeID, ok := recipeIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	shares, err := rc.RecipeService.ListShares(r.Context(), actorID, recipeID)
+	if err != nil {
+		writeError(w, r, err, "recipe")
+		return
+	}
+	if shares == nil {
+		shares = []*models.RecipeShare{}
+	}
+	writeJSON(w, http.StatusOK, shares)
+}
+
+// ShareRecipe shares a recipe with a user for viewing or editing, or
+// changes the permission it is shared with
+func (rc *RecipeController) ShareRecipe(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	recipeID, ok := recipeIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ShareRecipeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	req.RecipeID = recipeID
+
+	share, err := rc.RecipeService.ShareRecipe(r.Context(), actorID, &req)
+	if err != nil {
+		writeError(w, r, err, "recipe")
+		return
+	}
+	writeJSON(w, http.StatusOK, share)
+}
+
+// UnshareRecipe stops sharing a recipe with the user in the user_id query
+// parameter
+func (rc *RecipeController) UnshareRecipe(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	recipeID, ok := recipeIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDQuery(w, r)
+	if !ok {
+		return
+	}
+
+	if err := rc.RecipeService.UnshareRecipe(r.Context(), actorID, recipeID, userID); err != nil {
+		writeError(w, r, err, "share")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// userIDQuery reads the user ID from the "user_id" query parameter. On
+// failure it writes the problem response and returns false.
+func userIDQuery(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	value := r.URL.Query().Get("user_id")
+	if value == "" {
+		problem.Write(w, r, problem.BadRequest("user_id is required"))
+		return 0, false
+	}
+
+	userID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || userID <= 0 {
+		problem.Write(w, r, problem.BadRequest("user_id must be a positive integer"))
+		return 0, false
+	}
+	return userID, true
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend-app/models"
+	"backend-app/problem"
+	"github.com/gorilla/mux"
+)
+
+func TestRecipeSharing(t *testing.T) {
+	env := newTestEnv(t)
+	author := env.createUser(t, "abebe", "abebe@example.com")
+	friend := env.createUser(t, "kebede", "kebede@example.com")
+	stranger := env.createUser(t, "almaz", "almaz@example.com")
+
+	router := mux.NewRouter()
+	router.HandleFunc("/recipes/{id}/visibility", env.recipe.SetRecipeVisibility)
+	router.HandleFunc("/recipes/{id}/shares", env.recipe.ListShares).Methods("GET")
+	router.HandleFunc("/recipes/{id}/shares", env.recipe.ShareRecipe).Methods("PUT")
+	router.HandleFunc("/recipes/{id}/shares", env.recipe.UnshareRecipe).Methods("DELETE")
+	router.HandleFunc("/shared/{token}", env.recipe.GetSharedRecipe)
+	route := func(user *models.User, method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, authenticate(httptest.NewRequest(method, target, strings.NewReader(body)), user))
+		return rec
+	}
+	get := func(user *models.User) *httptest.ResponseRecorder {
+		return serveAs(t, user, env.recipe.GetRecipe, "GET", "/recipe?id=1", nil)
+	}
+
+	var recipe models.Recipe
+	decodeBody(t, serveAs(t, author, env.recipe.CreateRecipe, "POST", "/recipe/create",
+		map[string]interface{}{"title": "Shiro", "status": "published", "visibility": "private"}), http.StatusCreated, &recipe)
+	if recipe.Visibility != models.VisibilityPrivate || recipe.ShareToken != "" {
+		t.Fatalf("new recipe = %+v, want it private without a share link", recipe)
+	}
+
+	// Private recipes are hidden from everyone but their author
+	expectProblem(t, get(stranger), http.StatusNotFound, problem.CodeNotFound)
+	expectProblem(t, get(nil), http.StatusNotFound, problem.CodeNotFound)
+	expectProblem(t, route(friend, "PUT", "/recipes/1/visibility", `{"visibility": "public"}`), http.StatusNotFound, problem.CodeNotFound)
+
+	// Unlisted recipes are readable by anyone with the link
+	var unlisted models.Recipe
+	decodeBody(t, route(author, "PUT", "/recipes/1/visibility", `{"visibility": "unlisted"}`), http.StatusOK, &unlisted)
+	if unlisted.ShareToken == "" {
+		t.Fatal("unlisted recipe has no share link")
+	}
+	link := "/shared/" + unlisted.ShareToken
+	if rec := route(nil, "GET", link, ""); rec.Code != http.StatusOK {
+		t.Errorf("GET of the share link = %d, want 200", rec.Code)
+	}
+	expectProblem(t, get(stranger), http.StatusNotFound, problem.CodeNotFound)
+	expectProblem(t, route(nil, "GET", "/shared/guess", ""), http.StatusNotFound, problem.CodeNotFound)
+	expectProblem(t, route(author, "PUT", "/recipes/1/visibility", `{"visibility": "hidden"}`), http.StatusUnprocessableEntity,
+		problem.CodeValidationFailed)
+
+	// Making it private again revokes the link
+	decodeBody(t, route(author, "PUT", "/recipes/1/visibility", `{"visibility": "private"}`), http.StatusOK, &recipe)
+	expectProblem(t, route(nil, "GET", link, ""), http.StatusNotFound, problem.CodeNotFound)
+
+	// Viewers may read but not edit; editors may do both
+	expectProblem(t, route(friend, "PUT", "/recipes/1/shares", `{"user_id": 2, "permission": "view"}`), http.StatusNotFound,
+		problem.CodeNotFound)
+	p := expectProblem(t, route(author, "PUT", "/recipes/1/shares", `{"user_id": 1, "permission": "view"}`), http.StatusConflict,
+		problem.CodeConflict)
+	if p.Detail != "the creator already owns this recipe" {
+		t.Errorf("sharing with the creator: %q", p.Detail)
+	}
+	var share models.RecipeShare
+	decodeBody(t, route(author, "PUT", "/recipes/1/shares", `{"user_id": 2, "permission": "view"}`), http.StatusOK, &share)
+	if share.UserID != int64(friend.ID) || share.Permission != models.PermissionView {
+		t.Errorf("share = %+v", share)
+	}
+	if rec := get(friend); rec.Code != http.StatusOK {
+		t.Errorf("viewer's GET = %d, want 200", rec.Code)
+	}
+	update := map[string]interface{}{"id": 1, "title": "Shiro wot"}
+	expectProblem(t, serveAs(t, friend, env.recipe.UpdateRecipe, "PUT", "/recipe/update", update), http.StatusForbidden,
+		problem.CodeForbidden)
+	decodeBody(t, route(author, "PUT", "/recipes/1/shares", `{"user_id": 2, "permission": "edit"}`), http.StatusOK, &share)
+	var edited models.Recipe
+	expectProblem(t, serveAs(t, friend, env.recipe.UpdateRecipe, "PUT", "/recipe/update", update), http.StatusPreconditionRequired,
+		problem.CodePreconditionRequired)
+	decodeBody(t, serveAs(t, friend, func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("If-Match", recipeETag(&recipe))
+		env.recipe.UpdateRecipe(w, r)
+	}, "PUT", "/recipe/update", update), http.StatusOK, &edited)
+	if edited.Title != "Shiro wot" || edited.CreatorID != int64(author.ID) || edited.Visibility != models.VisibilityPrivate {
+		t.Errorf("recipe edited by a collaborator = %+v", edited)
+	}
+	expectProblem(t, route(friend, "GET", "/recipes/1/shares", ""), http.StatusForbidden, problem.CodeForbidden)
+
+	var shares []models.RecipeShare
+	decodeBody(t, route(author, "GET", "/recipes/1/shares", ""), http.StatusOK, &shares)
+	if len(shares) != 1 || shares[0].Permission != models.PermissionEdit {
+		t.Errorf("shares = %+v", shares)
+	}
+
+	// Revoking the share hides the recipe again
+	if rec := route(author, "DELETE", "/recipes/1/shares?user_id=2", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE share = %d, want 204", rec.Code)
+	}
+	expectProblem(t, get(friend), http.StatusNotFound, problem.CodeNotFound)
+	expectProblem(t, route(author, "DELETE", "/recipes/1/shares?user_id=2", ""), http.StatusNotFound, problem.CodeNotFound)
+	expectProblem(t, route(author, "DELETE", "/recipes/1/shares", ""), http.StatusBadRequest, problem.CodeBadRequest)
+
+	// Only owners see the share link
+	decodeBody(t, route(author, "PUT", "/recipes/1/visibility", `{"visibility": "unlisted"}`), http.StatusOK, &unlisted)
+	decodeBody(t, route(author, "PUT", "/recipes/1/shares", `{"user_id": 2, "permission": "view"}`), http.StatusOK, &share)
+	var seen models.Recipe
+	decodeBody(t, get(friend), http.StatusOK, &seen)
+	if seen.ShareToken != "" {
+		t.Error("viewer sees the share link")
+	}
+	decodeBody(t, route(nil, "GET", "/shared/"+unlisted.ShareToken, ""), http.StatusOK, &seen)
+	if seen.ShareToken != "" {
+		t.Error("share link response repeats the share link")
+	}
+	var listed []models.Recipe
+	decodeBody(t, serveAs(t, friend, env.recipe.GetAllRecipes, "GET", "/recipes", nil), http.StatusOK, &listed)
+	if len(listed) != 1 || listed[0].ShareToken != "" {
+		t.Errorf("recipes listed for a viewer = %+v, want one without its share link", listed)
+	}
+	owners := get(author)
+	decodeBody(t, owners, http.StatusOK, &seen)
+	if seen.ShareToken != unlisted.ShareToken {
+		t.Errorf("owner sees share link %q, want %q", seen.ShareToken, unlisted.ShareToken)
+	}
+
+	// Copies with and without the share link have different tags
+	ownerTag, viewerTag := owners.Header().Get("ETag"), get(friend).Header().Get("ETag")
+	if ownerTag == viewerTag {
+		t.Errorf("owner and viewer both get ETag %s", ownerTag)
+	}
+	rec := serveAs(t, friend, func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("If-None-Match", ownerTag)
+		env.recipe.GetRecipe(w, r)
+	}, "GET", "/recipe?id=1", nil)
+	if rec.Code != http.StatusOK {
+		t.Errorf("viewer revalidating the owner's copy = %d, want 200", rec.Code)
+	}
+
+	// Drafts stay with their authors even when shared
+	var draft models.Recipe
+	decodeBody(t, serveAs(t, author, env.recipe.CreateRecipe, "POST", "/recipe/create", map[string]interface{}{"title": "Kitfo"}),
+		http.StatusCreated, &draft)
+	decodeBody(t, route(author, "PUT", "/recipes/2/shares", `{"user_id": 2, "permission": "edit"}`), http.StatusOK, &share)
+	expectProblem(t, serveAs(t, friend, env.recipe.GetRecipe, "GET", "/recipe?id=2", nil), http.StatusNotFound, problem.CodeNotFound)
+	expectProblem(t, serveAs(t, friend, func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("If-Match", recipeETag(&draft))
+		env.recipe.UpdateRecipe(w, r)
+	}, "PUT", "/recipe/update", map[string]interface{}{"id": 2, "title": "Kitfo leb leb"}), http.StatusNotFound, problem.CodeNotFound)
+}
@@ -0,0 +1,40 @@
+package controllers
+
+import "net/http"
+
+// Trash lists the authenticated user's deleted recipes that can still be
+// restored
+func (rc *RecipeController) Trash(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	recipes, err := rc.RecipeService.Trash(r.Context(), actorID)
+	if err != nil {
+		writeError(w, r, err, "recipe")
+		return
+	}
+	writeJSON(w, http.StatusOK, recipes)
+}
+
+// RestoreRecipe takes one of the authenticated user's recipes out of the
+// trash
+func (rc *RecipeController) RestoreRecipe(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	recipeID, ok := recipeIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	recipe, err := rc.RecipeService.RestoreRecipe(r.Context(), actorID, recipeID)
+	if err != nil {
+		writeError(w, r, err, "recipe")
+		return
+	}
+	w.Header().Set("ETag", recipeETag(recipe))
+	writeJSON(w, http.StatusOK, recipe)
+}
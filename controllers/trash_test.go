@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"backend-app/models"
+	"backend-app/problem"
+)
+
+func TestTrashAndRestoreRecipe(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "abebe", "abebe@example.com")
+	other := env.createUser(t, "kebede", "kebede@example.com")
+	created, err := env.recipes.CreateRecipe(context.Background(), &models.Recipe{Title: "Shiro", CreatorID: int64(user.ID), Status: models.StatusPublished})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+
+	rec := serveAs(t, user, env.recipe.DeleteRecipe, "DELETE", "/recipe/delete?id=1", nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d, want 204", rec.Code)
+	}
+
+	var trash []models.Recipe
+	decodeBody(t, serveAs(t, user, env.recipe.Trash, "GET", "/trash", nil), http.StatusOK, &trash)
+	if len(trash) != 1 || trash[0].ID != created.ID || trash[0].DeletedAt == nil {
+		t.Fatalf("trash = %+v, want the deleted recipe", trash)
+	}
+	decodeBody(t, serveAs(t, other, env.recipe.Trash, "GET", "/trash", nil), http.StatusOK, &trash)
+	if len(trash) != 0 {
+		t.Errorf("another user's trash = %+v, want it empty", trash)
+	}
+
+	// Only the creator can restore the recipe
+	rec = serveAs(t, other, env.recipe.RestoreRecipe, "POST", "/trash/restore?id=1", nil)
+	expectProblem(t, rec, http.StatusNotFound, problem.CodeNotFound)
+
+	var restored models.Recipe
+	decodeBody(t, serveAs(t, user, env.recipe.RestoreRecipe, "POST", "/trash/restore?id=1", nil), http.StatusOK, &restored)
+	if restored.ID != created.ID || restored.DeletedAt != nil {
+		t.Errorf("restored recipe = %+v", restored)
+	}
+	if rec := serve(t, env.recipe.GetRecipe, "GET", "/recipe?id=1", nil); rec.Code != http.StatusOK {
+		t.Errorf("get after restore status = %d, want 200", rec.Code)
+	}
+
+	rec = serveAs(t, user, env.recipe.RestoreRecipe, "POST", "/trash/restore?id=1", nil)
+	expectProblem(t, rec, http.StatusNotFound, problem.CodeNotFound)
+}
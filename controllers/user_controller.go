@@ -1,22 +1,21 @@
 package controllers
 
 import (
-	"encoding/json"
-	"fmt"
 	"net/http"
 	"strconv"
 
 	"backend-app/models"
-	"backend-app/repository"
+	"backend-app/problem"
+	"backend-app/service"
 )
 
 type UserController struct {
-	UserRepository *repository.UserRepository
+	UserService *service.UserService
 }
 
-func NewUserController(userRepo *repository.UserRepository) *UserController {
+func NewUserController(userService *service.UserService) *UserController {
 	return &UserController{
-		UserRepository: userRepo,
+		UserService: userService,
 	}
 }
 
@@ -24,83 +23,88 @@ func NewUserController(userRepo *repository.UserRepository) *UserController {
 func (uc *UserController) GetUser(w http.ResponseWriter, r *http.Request) {
 	userIDStr := r.URL.Query().Get("id")
 	if userIDStr == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+		problem.Write(w, r, problem.BadRequest("user id is required"))
 		return
 	}
 
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid User ID", http.StatusBadRequest)
+		problem.Write(w, r, problem.BadRequest("user id must be an integer"))
 		return
 	}
 
 	// Retrieve user data from the database
-	user, err := uc.UserRepository.GetUserByID(userID)
+	user, err := uc.UserService.GetUser(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "Failed to retrieve user", http.StatusInternalServerError)
+		writeError(w, r, err, "user")
 		return
 	}
 
-	// Marshal user data to JSON
-	userJSON, err := json.Marshal(user)
-	if err != nil {
-		http.Error(w, "Failed to marshal user data", http.StatusInternalServerError)
-		return
-	}
-
-	// Set Content-Type header and write response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(userJSON)
+	writeJSON(w, http.StatusOK, user)
 }
 
-// UpdateUser updates user data
+// UpdateUser updates the authenticated user's profile
 func (uc *UserController) UpdateUser(w http.ResponseWriter, r *http.Request) {
-	var updatedUser models.User
-	err := json.NewDecoder(r.Body).Decode(&updatedUser)
-	if err != nil {
-		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+	actorID, ok := currentUserID(w, r)
+	if !ok {
 		return
 	}
 
-	// Validate input
-	if updatedUser.ID == 0 {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+	var req models.UpdateUserRequest
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	// TODO: Implement logic to update user data in the database
-	err = uc.UserRepository.UpdateUser(&updatedUser)
+	updatedUser, err := uc.UserService.UpdateUser(r.Context(), actorID, &req)
 	if err != nil {
-		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		writeError(w, r, err, "user")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, "User data updated successfully")
+	writeJSON(w, http.StatusOK, updatedUser)
 }
 
-// DeleteUser deletes a user by ID
+// DeleteUser deletes the authenticated user's account
 func (uc *UserController) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
 	userIDStr := r.URL.Query().Get("id")
 	if userIDStr == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+		problem.Write(w, r, problem.BadRequest("user id is required"))
 		return
 	}
 
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid User ID", http.StatusBadRequest)
+		problem.Write(w, r, problem.BadRequest("user id must be an integer"))
+		return
+	}
+
+	err = uc.UserService.DeleteUser(r.Context(), actorID, userID)
+	if err != nil {
+		writeError(w, r, err, "user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreUser restores a deleted account for a user who proves they own it
+// with its credentials
+func (uc *UserController) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	var creds models.Credentials
+	if !decodeJSON(w, r, &creds) {
 		return
 	}
 
-	// TODO: Implement logic to delete user from the database
-	err = uc.UserRepository.DeleteUser(userID)
+	user, err := uc.UserService.RestoreUser(r.Context(), &creds)
 	if err != nil {
-		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+		writeError(w, r, err, "user")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, "User deleted successfully")
+	writeJSON(w, http.StatusOK, user)
 }
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"backend-app/models"
+	"backend-app/problem"
+)
+
+func TestGetMissingUser(t *testing.T) {
+	env := newTestEnv(t)
+
+	rec := serve(t, env.user.GetUser, "GET", "/user?id=7", nil)
+
+	expectProblem(t, rec, http.StatusNotFound, problem.CodeNotFound)
+}
+
+func TestUpdateUserKeepsPassword(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "abebe", "abebe@example.com")
+
+	rec := serveAs(t, user, env.user.UpdateUser, "PUT", "/user/update", models.UpdateUserRequest{
+		ID:       user.ID,
+		Username: "abebe-b",
+		Email:    "abebe.b@example.com",
+	})
+
+	var updated models.User
+	decodeBody(t, rec, http.StatusOK, &updated)
+	if updated.Username != "abebe-b" {
+		t.Errorf("username = %q, want abebe-b", updated.Username)
+	}
+	stored, err := env.users.GetUserByID(context.Background(), int64(user.ID))
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if stored.PasswordHash != "hash" {
+		t.Errorf("password hash = %q, want it unchanged", stored.PasswordHash)
+	}
+}
+
+func TestDeleteAndRestoreUser(t *testing.T) {
+	env := newTestEnv(t)
+	signUp := models.SignUpRequest{Username: "abebe", Email: "abebe@example.com", Password: "injera-1234"}
+	var user models.User
+	decodeBody(t, serve(t, env.auth.SignUp, "POST", "/signup", signUp), http.StatusOK, &user)
+	recipe, err := env.recipes.CreateRecipe(context.Background(), &models.Recipe{Title: "Shiro", CreatorID: int64(user.ID)})
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+
+	// The account's recipes go with it
+	rec := serveAs(t, &user, env.user.DeleteUser, "DELETE", "/user/delete?id=1", nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d, want 204", rec.Code)
+	}
+	expectProblem(t, serve(t, env.recipe.GetRecipe, "GET", "/recipe?id=1", nil), http.StatusNotFound, problem.CodeNotFound)
+	login := models.Credentials{Email: "abebe@example.com", Password: "injera-1234"}
+	expectProblem(t, serve(t, env.auth.Login, "POST", "/login", login), http.StatusUnauthorized, problem.CodeUnauthorized)
+
+	// Tokens issued before the deletion cannot add recipes
+	expectProblem(t, serveAs(t, &user, env.recipe.CreateRecipe, "POST", "/recipe/create", map[string]interface{}{"title": "Kitfo"}),
+		http.StatusUnprocessableEntity, problem.CodeInvalidReference)
+
+	wrong := models.Credentials{Email: "abebe@example.com", Password: "wrong-password"}
+	expectProblem(t, serve(t, env.user.RestoreUser, "POST", "/user/restore", wrong), http.StatusUnauthorized, problem.CodeUnauthorized)
+
+	var restored models.User
+	decodeBody(t, serve(t, env.user.RestoreUser, "POST", "/user/restore", login), http.StatusOK, &restored)
+	if restored.ID != user.ID {
+		t.Errorf("restored user = %+v, want %d", restored, user.ID)
+	}
+	if _, err := env.recipes.GetRecipeByID(context.Background(), recipe.ID); err != nil {
+		t.Errorf("recipe after restore: %v", err)
+	}
+	decodeBody(t, serve(t, env.auth.Login, "POST", "/login", login), http.StatusOK, &map[string]string{})
+}
+
+func TestUsersCannotChangeOtherAccounts(t *testing.T) {
+	env := newTestEnv(t)
+	victim := env.createUser(t, "abebe", "abebe@example.com")
+	attacker := env.createUser(t, "kebede", "kebede@example.com")
+
+	rec := serveAs(t, attacker, env.user.UpdateUser, "PUT", "/user/update", models.UpdateUserRequest{
+		ID:       victim.ID,
+		Username: "pwned",
+		Email:    "pwned@example.com",
+	})
+	expectProblem(t, rec, http.StatusForbidden, problem.CodeForbidden)
+
+	rec = serveAs(t, attacker, env.user.DeleteUser, "DELETE", "/user/delete?id=1", nil)
+	expectProblem(t, rec, http.StatusForbidden, problem.CodeForbidden)
+}
@@ -0,0 +1,105 @@
+// Package health serves the liveness and readiness endpoints used by load
+// balancers and orchestrators.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"backend-app/migrations"
+)
+
+// Check reports whether a dependency is usable
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Handler serves /healthz and /readyz
+type Handler struct {
+	// Timeout bounds each readiness check
+	Timeout time.Duration
+
+	checks []namedCheck
+}
+
+// NewHandler creates a Handler whose checks each get timeout to complete
+func NewHandler(timeout time.Duration) *Handler {
+	return &Handler{
+		Timeout: timeout,
+	}
+}
+
+// Add registers a readiness check
+func (h *Handler) Add(name string, check Check) {
+	h.checks = append(h.checks, namedCheck{name, check})
+}
+
+// status is the body of both endpoints
+type status struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Live reports that the process is up. It checks no dependencies, so a
+// database outage does not get the process restarted.
+func (h *Handler) Live(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, http.StatusOK, status{Status: "ok"})
+}
+
+// Ready reports whether the service can handle requests. It answers 503
+// unless every check passes. Failures are logged; clients only learn which
+// check failed, not why.
+func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
+	result := status{Status: "ok", Checks: make(map[string]string)}
+	code := http.StatusOK
+
+	for _, c := range h.checks {
+		ctx, cancel := context.WithTimeout(r.Context(), h.Timeout)
+		err := c.check(ctx)
+		cancel()
+
+		if err != nil {
+			slog.ErrorContext(r.Context(), "readiness check failed", "check", c.name, "error", err)
+			result.Status = "unavailable"
+			result.Checks[c.name] = "unavailable"
+			code = http.StatusServiceUnavailable
+			continue
+		}
+		result.Checks[c.name] = "ok"
+	}
+	writeStatus(w, code, result)
+}
+
+func writeStatus(w http.ResponseWriter, code int, body status) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
+
+// Database checks that db answers
+func Database(db *sql.DB) Check {
+	return db.PingContext
+}
+
+// Migrations checks that every migration has been applied to db
+func Migrations(db *sql.DB) Check {
+	return func(ctx context.Context) error {
+		pending, err := migrations.Pending(ctx, db)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d migrations pending, first is %s", len(pending), pending[0].Name)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,58 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReady(t *testing.T) {
+	h := NewHandler(time.Second)
+	h.Add("database", func(context.Context) error { return nil })
+	h.Add("migrations", func(context.Context) error { return errors.New("1 migrations pending") })
+
+	rec := httptest.NewRecorder()
+	h.Ready(rec, httptest.NewRequest("GET", "/readyz", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	var body status
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Checks["database"] != "ok" || body.Checks["migrations"] != "unavailable" {
+		t.Errorf("checks = %v", body.Checks)
+	}
+}
+
+func TestReadyChecksAreBounded(t *testing.T) {
+	h := NewHandler(10 * time.Millisecond)
+	h.Add("database", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	rec := httptest.NewRecorder()
+	h.Ready(rec, httptest.NewRequest("GET", "/readyz", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestLiveIgnoresChecks(t *testing.T) {
+	h := NewHandler(time.Second)
+	h.Add("database", func(context.Context) error { return errors.New("down") })
+
+	rec := httptest.NewRecorder()
+	h.Live(rec, httptest.NewRequest("GET", "/healthz", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestRecordsCarryRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	ctx := WithRequestID(context.Background(), "req-42")
+	logger.With("component", "store").InfoContext(ctx, "query failed")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("decoding %q: %v", buf.String(), err)
+	}
+	if record["request_id"] != "req-42" || record["component"] != "store" {
+		t.Errorf("record = %v", record)
+	}
+}
+
+func TestLevelFiltersRecords(t *testing.T) {
+	var buf bytes.Buffer
+	level, err := ParseLevel("warn")
+	if err != nil {
+		t.Fatalf("ParseLevel: %v", err)
+	}
+	logger, _ := New(&buf, "text", level)
+
+	logger.Info("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("info record logged at warn level: %q", buf.String())
+	}
+}
@@ -1,62 +1,357 @@
 package main
 
 import (
+    "context"
     "database/sql"
+    "database/sql/driver"
+    "flag"
     "fmt"
     "log"
+    "log/slog"
+    "net"
     "net/http"
     "os"
+    "os/signal"
+    "strings"
+    "syscall"
+    "time"
 
     "github.com/gorilla/mux"
-    _ "github.com/lib/pq" // PostgreSQL driver
+    "github.com/lib/pq"
+    "backend-app/cache"
+    "backend-app/config"
     "backend-app/controllers"
-    "backend-app/repository"
+    "backend-app/health"
+    "backend-app/logging"
+    "backend-app/media"
+    "backend-app/metrics"
+    "backend-app/middleware"
+    "backend-app/publishing"
+    "backend-app/ratelimit"
     "backend-app/routes"
+    "backend-app/server"
+    "backend-app/service"
+    "backend-app/store"
+    "backend-app/tracing"
+    "backend-app/trash"
 )
 
-// Config represents the application configuration structure
-type Config struct {
-    DatabaseURL string
-    Port        string
-}
-
 func main() {
-    // Load configuration from environment variables
-    cfg := Config{
-        DatabaseURL: os.Getenv("DATABASE_URL"),
-        Port:        os.Getenv("PORT"),
+    configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML configuration file")
+    flag.Parse()
+
+    // Load configuration from the config file and environment variables
+    cfg, err := config.Load(*configPath)
+    if err != nil {
+        log.Fatalf("Failed to load configuration: %v", err)
     }
 
-    // Initialize database connection
-    db, err := sql.Open("postgres", cfg.DatabaseURL)
+    // Initialize structured logging; the standard logger writes through it too
+    level, _ := logging.ParseLevel(cfg.Log.Level)
+    logger, err := logging.New(os.Stderr, cfg.Log.Format, level)
     if err != nil {
-        log.Fatalf("Failed to connect to database: %v", err)
+        log.Fatalf("Failed to initialize logging: %v", err)
+    }
+    slog.SetDefault(logger)
+    slog.Info("loaded configuration", "config", cfg.Redacted())
+
+    // Export traces if a collector is configured
+    shutdownTracing := func(context.Context) error { return nil }
+    if cfg.Tracing.Enabled() {
+        shutdownTracing, err = tracing.Setup(context.Background(), tracing.Options{
+            Endpoint:    cfg.Tracing.Endpoint,
+            ServiceName: cfg.Tracing.ServiceName,
+            SampleRatio: cfg.Tracing.SampleRatio,
+        })
+        if err != nil {
+            fatal("failed to initialize tracing", err)
+        }
+    }
+
+    // Initialize database connections
+    db := openDB(cfg.Database.DSN(), cfg.Database, cfg.Tracing.Enabled())
+    var replicas []*sql.DB
+    for _, dsn := range cfg.Database.ReplicaDSNs() {
+        replicas = append(replicas, openDB(dsn, cfg.Database, cfg.Tracing.Enabled()))
+    }
+    cluster := store.NewCluster(db, replicas...)
+    cluster.ReadYourWritesWindow = cfg.Database.ReadYourWritesWindow.Duration
+    cluster.Actor = middleware.UserIDFromContext
+
+    // Fail fast on a bad DATABASE_URL instead of on the first request.
+    // Replicas are not required to start; they are used once healthy.
+    if err := store.Ping(context.Background(), db, cfg.Database.ConnectTimeout.Duration); err != nil {
+        fatal("failed to connect to database", err)
+    }
+    cluster.CheckReplicas(context.Background(), 2*time.Second)
+
+    // Initialize metrics
+    appMetrics := metrics.New()
+    appMetrics.RegisterDB("primary", db)
+    for i, replica := range replicas {
+        appMetrics.RegisterDB(fmt.Sprintf("replica%d", i), replica)
+    }
+
+    // Initialize stores
+    postgresUsers := store.NewPostgresUserStore(db)
+    postgresUsers.Timeout = cfg.Database.QueryTimeout.Duration
+    postgresCategories := store.NewPostgresCategoryStore(cluster)
+    postgresCategories.Timeout = cfg.Database.QueryTimeout.Duration
+    postgresRecipes := store.NewPostgresRecipeStore(cluster)
+    postgresRecipes.Timeout = cfg.Database.QueryTimeout.Duration
+    postgresMedia := store.NewPostgresMediaStore(db)
+    postgresMedia.Timeout = cfg.Database.QueryTimeout.Duration
+
+    // Time and trace every store call
+    userStore := &store.InstrumentedUserStore{Next: postgresUsers, Observer: appMetrics}
+    categoryStore := &store.InstrumentedCategoryStore{Next: postgresCategories, Observer: appMetrics}
+    recipeStore := &store.InstrumentedRecipeStore{Next: postgresRecipes, Observer: appMetrics}
+    mediaStore := &store.InstrumentedMediaStore{Next: postgresMedia, Observer: appMetrics}
+
+    // Serve recipe and category reads from the read-through cache
+    var users store.UserStore = userStore
+    var categories store.CategoryStore = categoryStore
+    var recipes store.RecipeStore = recipeStore
+    if backend := cacheBackend(cfg.ReadCache); backend != nil {
+        readCache := cache.New(backend)
+        readCache.Observer = appMetrics
+        users = &store.CachedUserStore{Next: userStore, Recipes: recipeStore, Cache: readCache}
+        categories = &store.CachedCategoryStore{Next: categoryStore, Cache: readCache, TTL: cfg.ReadCache.CategoryTTL.Duration}
+        recipes = &store.CachedRecipeStore{Next: recipeStore, Cache: readCache, TTL: cfg.ReadCache.RecipeTTL.Duration}
     }
-    defer db.Close()
 
-    // Initialize repositories
-    userRepo := repository.NewUserRepository(db)
-    recipeRepo := repository.NewRecipeRepository(db)
+    // Initialize media storage
+    blobs := media.NewStore(cfg.Uploads.Dir)
+
+    // Initialize services
+    userService := service.NewUserService(users)
+    userService.Observer = appMetrics
+    userService.Retention = cfg.Trash.Retention.Duration
+    categoryService := service.NewCategoryService(categories)
+    recipeService := service.NewRecipeService(recipes, mediaStore, blobs)
+    recipeService.Observer = appMetrics
+    recipeService.Retention = cfg.Trash.Retention.Duration
+    recipeService.Users = users
 
     // Initialize controllers
-    authController := controllers.NewAuthController(userRepo)
-    userController := controllers.NewUserController(userRepo)
-    recipeController := controllers.NewRecipeController(recipeRepo)
+    jwtKeys := middleware.NewKeySet(cfg.Auth.JWTKeyBytes())
+    var sessions *middleware.Sessions
+    if cfg.Auth.Session.Enabled {
+        sessions = &middleware.Sessions{
+            Keys:           jwtKeys,
+            CookieName:     cfg.Auth.Session.CookieName,
+            CSRFCookieName: cfg.Auth.Session.CSRFCookieName,
+            Secure:         cfg.Auth.Session.Secure,
+            SameSite:       sameSite(cfg.Auth.Session.SameSite),
+        }
+    }
+    authController := controllers.NewAuthController(userService, jwtKeys, cfg.Auth.TokenTTL.Duration)
+    authController.Sessions = sessions
+    userController := controllers.NewUserController(userService)
+    recipeController := controllers.NewRecipeController(recipeService, controllers.UploadLimits{
+        MaxRequestBytes: cfg.Uploads.MaxRequestBytes,
+        MaxMemoryBytes:  cfg.Uploads.MaxMemoryBytes,
+    })
+    categoryController := controllers.NewCategoryController(categoryService)
+
+    // Stop on SIGTERM or Ctrl-C
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+    defer stop()
 
-    // Initialize middleware
-    authMiddleware := controllers.NewAuthMiddleware()
+    // Limit request rates per user, or per client IP for anonymous requests
+    authMiddleware := middleware.NewAuthMiddleware(jwtKeys)
+    authMiddleware.Sessions = sessions
+    var rateLimits ratelimit.Store = ratelimit.NewMemoryStore()
+    if cfg.RateLimit.Store == "postgres" {
+        sharedLimits := ratelimit.NewPostgresStore(db)
+        go sharedLimits.Run(ctx, time.Hour)
+        rateLimits = sharedLimits
+    }
+    limiter := &middleware.RateLimiter{
+        Store:             rateLimits,
+        Auth:              authMiddleware,
+        Default:           ratePolicy(cfg.RateLimit.Default),
+        Routes:            make(map[string]ratelimit.Policy),
+        TrustForwardedFor: cfg.RateLimit.TrustForwardedFor,
+    }
+    for route, rate := range cfg.RateLimit.Routes {
+        limiter.Routes[route] = ratePolicy(rate)
+    }
 
     // Initialize router
     router := mux.NewRouter()
+    cacheControl := &middleware.CacheControl{Default: cfg.Cache.Default, Routes: cfg.Cache.Routes}
+    router.Use(middleware.RecordRoute, limiter.Limit, cacheControl.Handler)
 
     // Register routes
-    routes.RegisterRoutes(router, authMiddleware, authController, userController, recipeController)
+    routes.RegisterRoutes(router, authController, userController, recipeController, categoryController, authMiddleware)
+
+    // Register health checks
+    healthHandler := health.NewHandler(2 * time.Second)
+    healthHandler.Add("database", health.Database(db))
+    healthHandler.Add("migrations", health.Migrations(db))
+    routes.RegisterHealthRoutes(router, healthHandler)
+    router.Handle("/metrics", appMetrics.Handler()).Methods("GET")
+
+    // Answer cross-origin requests before they are routed
+    cors := &middleware.CORS{
+        AllowedOrigins:   cfg.CORS.AllowedOrigins,
+        AllowedMethods:   cfg.CORS.AllowedMethods,
+        AllowedHeaders:   cfg.CORS.AllowedHeaders,
+        AllowCredentials: cfg.CORS.AllowCredentials,
+        MaxAge:           cfg.CORS.MaxAge.Duration,
+        ExposedHeaders:   cfg.CORS.ExposedHeaders,
+    }
+
+    // Set security headers on every response, including static files
+    security := &middleware.SecurityHeaders{
+        ContentSecurityPolicy: cfg.Security.ContentSecurityPolicy,
+        HSTSMaxAge:            cfg.Security.HSTSMaxAge.Duration,
+        HSTSIncludeSubdomains: cfg.Security.HSTSIncludeSubdomains,
+        ReferrerPolicy:        cfg.Security.ReferrerPolicy,
+    }
+
+    // Collect uploaded images that no recipe references anymore
+    sweeper := media.NewSweeper(blobs, mediaStore, cfg.Media.GracePeriod.Duration)
+    go sweeper.Run(ctx, cfg.Media.GCInterval.Duration)
+
+    // Empty the trash of what can no longer be restored
+    purger := trash.NewPurger(recipeStore, userStore, categoryStore, cfg.Trash.Retention.Duration)
+    go purger.Run(ctx, cfg.Trash.PurgeInterval.Duration)
+
+    // Publish drafts whose scheduled publication time has come
+    scheduler := publishing.NewScheduler(recipes)
+    go scheduler.Run(ctx, cfg.Publishing.Interval.Duration)
 
-    // Serve static files (images)
-    router.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("./static/"))))
+    // Take failed replicas out of rotation and bring recovered ones back
+    go cluster.MonitorReplicas(ctx, cfg.Database.ReplicaCheckInterval.Duration, 2*time.Second)
+
+    // Configure server
+    srv := &http.Server{
+        Handler:           middleware.RequestID(middleware.AccessLog(logger)(middleware.Instrument(appMetrics)(middleware.Trace(security.Handler(cors.Handler(router)))))),
+        ReadTimeout:       cfg.Server.ReadTimeout.Duration,
+        ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout.Duration,
+        WriteTimeout:      cfg.Server.WriteTimeout.Duration,
+        IdleTimeout:       cfg.Server.IdleTimeout.Duration,
+        MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+    }
+    var certs *server.CertReloader
+    if cfg.Server.TLS.Enabled() {
+        certs, err = server.NewCertReloader(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+        if err != nil {
+            fatal("failed to load TLS certificate", err)
+        }
+        srv.TLSConfig = certs.TLSConfig()
+    }
+    go reloadOnSIGHUP(*configPath, jwtKeys, certs)
 
     // Start server
-    port := cfg.Port
-    fmt.Printf("Server running on port %s...\n", port)
-    log.Fatal(http.ListenAndServe(":"+port, router))
+    ln, err := net.Listen("tcp", cfg.Server.Addr)
+    if err != nil {
+        fatal("failed to listen", err)
+    }
+    slog.Info("server listening", "addr", ln.Addr().String(), "tls", srv.TLSConfig != nil)
+    err = server.Serve(ctx, srv, ln, cfg.Server.ShutdownTimeout.Duration)
+
+    // In-flight requests have finished, so the pool can be released
+    if closeErr := cluster.Close(); closeErr != nil {
+        slog.Error("failed to close database", "error", closeErr)
+    }
+    flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    if flushErr := shutdownTracing(flushCtx); flushErr != nil {
+        slog.Error("failed to flush traces", "error", flushErr)
+    }
+    cancel()
+    if err != nil {
+        fatal("server failed", err)
+    }
+    slog.Info("server stopped")
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+    slog.Error(msg, "error", err)
+    os.Exit(1)
+}
+
+// openDB opens a connection pool configured with the pool settings. If
+// traced is set, every statement is recorded as a span.
+func openDB(dsn string, cfg config.DatabaseConfig, traced bool) *sql.DB {
+    var connector driver.Connector
+    connector, err := pq.NewConnector(dsn)
+    if err != nil {
+        fatal("failed to open database", err)
+    }
+    if traced {
+        connector = tracing.WrapConnector(connector)
+    }
+    db := sql.OpenDB(connector)
+    db.SetMaxOpenConns(cfg.MaxOpenConns)
+    db.SetMaxIdleConns(cfg.MaxIdleConns)
+    db.SetConnMaxLifetime(cfg.ConnMaxLifetime.Duration)
+    db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime.Duration)
+    return db
+}
+
+// sameSite converts a configured SameSite mode to the cookie attribute
+func sameSite(mode string) http.SameSite {
+    switch strings.ToLower(mode) {
+    case "strict":
+        return http.SameSiteStrictMode
+    case "none":
+        return http.SameSiteNoneMode
+    }
+    return http.SameSiteLaxMode
+}
+
+// ratePolicy converts a configured rate to a rate limiting policy
+func ratePolicy(rate config.Rate) ratelimit.Policy {
+    return ratelimit.Policy{Limit: rate.Limit, Period: rate.Period, Burst: rate.Burst}
+}
+
+// cacheBackend creates the backend of the read-through cache, or returns
+// nil if the cache is off. An unreachable Redis server is only logged: the
+// cache is bypassed until it comes back.
+func cacheBackend(cfg config.ReadCacheConfig) cache.Backend {
+    switch cfg.Backend {
+    case "memory":
+        return cache.NewLRU(cfg.Size)
+    case "redis":
+        redis, err := cache.NewRedis(cfg.RedisURL, 16)
+        if err != nil {
+            fatal("invalid read cache URL", err)
+        }
+        if err := redis.Ping(context.Background()); err != nil {
+            slog.Warn("read cache is unreachable", "addr", redis.Addr, "error", err)
+        }
+        return redis
+    }
+    return nil
+}
+
+// reloadOnSIGHUP reloads the configuration whenever the process receives
+// SIGHUP and swaps in its JWT keys, and reloads the TLS certificate if
+// certs is set. Other settings only take effect on restart. An invalid
+// configuration or certificate is logged and the current one is kept.
+func reloadOnSIGHUP(configPath string, keys *middleware.KeySet, certs *server.CertReloader) {
+    hup := make(chan os.Signal, 1)
+    signal.Notify(hup, syscall.SIGHUP)
+
+    for range hup {
+        if certs != nil {
+            if err := certs.Reload(); err != nil {
+                slog.Error("failed to reload TLS certificate, keeping current one", "error", err)
+            } else {
+                slog.Info("reloaded TLS certificate")
+            }
+        }
+
+        cfg, err := config.Load(configPath)
+        if err != nil {
+            slog.Error("failed to reload configuration, keeping current JWT keys", "error", err)
+            continue
+        }
+        keys.Replace(cfg.Auth.JWTKeyBytes())
+        slog.Info("reloaded JWT keys", "count", len(cfg.Auth.JWTKeys))
+    }
 }
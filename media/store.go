@@ -0,0 +1,72 @@
+package media
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store keeps uploaded media blobs in a directory on the local filesystem.
+// Paths handed out by the store are relative to the working directory
+// (e.g. "uploads/3f9c...-cake.jpg") and are what recipes reference.
+type Store struct {
+	Dir string
+}
+
+// NewStore initializes a new Store rooted at dir
+func NewStore(dir string) *Store {
+	return &Store{
+		Dir: dir,
+	}
+}
+
+// Save writes src under a unique name derived from filename and returns the
+// stored path
+func (s *Store) Save(filename string, src io.Reader) (string, error) {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return "", err
+	}
+
+	prefix := make([]byte, 8)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", err
+	}
+	path := filepath.Join(s.Dir, hex.EncodeToString(prefix)+"-"+filepath.Base(filename))
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// Remove deletes a stored blob. Removing a blob that is already gone is not
+// an error.
+func (s *Store) Remove(path string) error {
+	if !s.Contains(path) {
+		return errors.New("media: path outside of store: " + path)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Contains reports whether path points inside the store directory
+func (s *Store) Contains(path string) bool {
+	rel, err := filepath.Rel(s.Dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != "." && !strings.HasPrefix(rel, "..")
+}
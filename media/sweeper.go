@@ -0,0 +1,87 @@
+package media
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Tracker records which stored blobs exist and when they stopped being
+// referenced by a recipe
+type Tracker interface {
+	// OrphanedMedia returns the paths of tracked blobs that are not referenced
+	// by any recipe and have been unreferenced since before the given time
+	OrphanedMedia(ctx context.Context, before time.Time) ([]string, error)
+	// ForgetMedia drops the tracking record of a deleted blob
+	ForgetMedia(ctx context.Context, path string) error
+}
+
+// Sweeper deletes blobs that are no longer referenced by any recipe once
+// they have been orphaned for longer than GracePeriod. The grace period
+// protects uploads that have not been attached to a recipe yet and gives
+// concurrent edits a chance to settle.
+type Sweeper struct {
+	Store       *Store
+	Tracker     Tracker
+	GracePeriod time.Duration
+	DryRun      bool
+}
+
+// NewSweeper initializes a new Sweeper
+func NewSweeper(store *Store, tracker Tracker, gracePeriod time.Duration) *Sweeper {
+	return &Sweeper{
+		Store:       store,
+		Tracker:     tracker,
+		GracePeriod: gracePeriod,
+	}
+}
+
+// Sweep performs a single collection pass and returns the paths that were
+// deleted, or that would have been deleted in dry-run mode
+func (s *Sweeper) Sweep(ctx context.Context) ([]string, error) {
+	orphans, err := s.Tracker.OrphanedMedia(ctx, time.Now().Add(-s.GracePeriod))
+	if err != nil {
+		return nil, err
+	}
+	if s.DryRun {
+		return orphans, nil
+	}
+
+	var deleted []string
+	for _, path := range orphans {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		if err := s.Store.Remove(path); err != nil {
+			slog.ErrorContext(ctx, "removing orphaned media", "path", path, "error", err)
+			continue
+		}
+		if err := s.Tracker.ForgetMedia(ctx, path); err != nil {
+			slog.ErrorContext(ctx, "forgetting orphaned media", "path", path, "error", err)
+			continue
+		}
+		deleted = append(deleted, path)
+	}
+	return deleted, nil
+}
+
+// Run sweeps every interval until ctx is cancelled
+func (s *Sweeper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.Sweep(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "sweeping orphaned media", "error", err)
+			}
+			if len(deleted) > 0 {
+				slog.InfoContext(ctx, "removed orphaned media", "count", len(deleted))
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Package metrics collects the Prometheus metrics exposed on /metrics.
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend-app/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the application's collectors and the registry they are
+// exposed from
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	storeDuration *prometheus.HistogramVec
+	uploadBytes   prometheus.Counter
+	logins        *prometheus.CounterVec
+	cacheLookups  *prometheus.CounterVec
+}
+
+// New creates the application's collectors, together with the Go runtime
+// and process collectors, in a fresh registry
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method and route template.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		storeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "store_query_duration_seconds",
+			Help:    "Duration of store methods. Expected outcomes such as not found count as success.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"store", "method", "result"}),
+		uploadBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "upload_bytes_total",
+			Help: "Bytes of uploaded images stored.",
+		}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "login_attempts_total",
+			Help: "Login attempts by result: success, failure (invalid credentials) or error.",
+		}, []string{"result"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_lookups_total",
+			Help: "Read-through cache lookups by cache and result: hit, miss or error.",
+		}, []string{"cache", "result"}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.storeDuration,
+		m.uploadBytes,
+		m.logins,
+		m.cacheLookups,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB exposes the connection pool statistics of db, labelled with
+// name
+func (m *Metrics) RegisterDB(name string, db *sql.DB) {
+	m.Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveRequest records a served HTTP request. route is the matched route
+// template, or "" if no route matched.
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		// Keep arbitrary paths of unmatched requests out of the label set
+		route = "unmatched"
+	}
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// ObserveQuery implements store.Observer
+func (m *Metrics) ObserveQuery(storeName, method string, duration time.Duration, err error) {
+	result := "success"
+	if err != nil && !expected(err) {
+		result = "error"
+	}
+	m.storeDuration.WithLabelValues(storeName, method, result).Observe(duration.Seconds())
+}
+
+// expected reports whether err is a normal outcome of a store call rather
+// than a failure
+func expected(err error) bool {
+	return errors.Is(err, store.ErrNotFound) ||
+		errors.Is(err, store.ErrConflict) ||
+		errors.Is(err, store.ErrInvalidReference) ||
+		errors.Is(err, store.ErrStale)
+}
+
+// UploadedBytes implements service.Observer
+func (m *Metrics) UploadedBytes(n int64) {
+	m.uploadBytes.Add(float64(n))
+}
+
+// LoginAttempt implements service.Observer
+func (m *Metrics) LoginAttempt(result string) {
+	m.logins.WithLabelValues(result).Inc()
+}
+
+// ObserveCache implements cache.Observer
+func (m *Metrics) ObserveCache(name, result string) {
+	m.cacheLookups.WithLabelValues(name, result).Inc()
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend-app/cache"
+	"backend-app/middleware"
+	"backend-app/service"
+	"backend-app/store"
+	"backend-app/store/memory"
+	"github.com/gorilla/mux"
+)
+
+// scrape returns the metrics exposition served by m
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	return string(body)
+}
+
+func expectMetric(t *testing.T, exposition, line string) {
+	t.Helper()
+
+	if !strings.Contains(exposition, line) {
+		t.Errorf("metrics do not contain %q", line)
+	}
+}
+
+func TestRequestMetrics(t *testing.T) {
+	m := New()
+	router := mux.NewRouter()
+	router.Use(middleware.RecordRoute)
+	router.HandleFunc("/recipe/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler := middleware.Instrument(m)(router)
+
+	for _, path := range []string{"/recipe/1", "/recipe/2", "/nowhere"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	exposition := scrape(t, m)
+	expectMetric(t, exposition, `http_requests_total{method="GET",route="/recipe/{id}",status="404"} 2`)
+	expectMetric(t, exposition, `http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	expectMetric(t, exposition, `http_request_duration_seconds_count{method="GET",route="/recipe/{id}"} 2`)
+}
+
+func TestStoreMetrics(t *testing.T) {
+	m := New()
+	recipes := &store.InstrumentedRecipeStore{Next: memory.NewRecipeStore(memory.NewDB()), Observer: m}
+
+	recipes.GetRecipeByID(context.Background(), 1)
+	recipes.GetAllRecipes(context.Background(), 0)
+
+	exposition := scrape(t, m)
+	// Not found is an expected outcome, not a store failure
+	expectMetric(t, exposition, `store_query_duration_seconds_count{method="GetRecipeByID",result="success",store="recipe"} 1`)
+	expectMetric(t, exposition, `store_query_duration_seconds_count{method="GetAllRecipes",result="success",store="recipe"} 1`)
+}
+
+func TestCacheMetrics(t *testing.T) {
+	m := New()
+	c := cache.New(cache.NewLRU(10))
+	c.Observer = m
+	recipes := &store.CachedRecipeStore{Next: memory.NewRecipeStore(memory.NewDB()), Cache: c, TTL: time.Minute}
+
+	recipes.GetRecipeByID(context.Background(), 1)
+
+	exposition := scrape(t, m)
+	expectMetric(t, exposition, `cache_lookups_total{cache="recipe",result="miss"} 1`)
+}
+
+func TestServiceMetrics(t *testing.T) {
+	m := New()
+
+	m.UploadedBytes(1024)
+	m.UploadedBytes(512)
+	m.LoginAttempt(service.LoginSuccess)
+	m.LoginAttempt(service.LoginFailure)
+	m.LoginAttempt(service.LoginFailure)
+
+	exposition := scrape(t, m)
+	expectMetric(t, exposition, "upload_bytes_total 1536")
+	expectMetric(t, exposition, `login_attempts_total{result="success"} 1`)
+	expectMetric(t, exposition, `login_attempts_total{result="failure"} 2`)
+}
+
+func TestDBStats(t *testing.T) {
+	m := New()
+	db, err := sql.Open("postgres", "postgres://localhost/unused")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	m.RegisterDB("primary", db)
+
+	expectMetric(t, scrape(t, m), `go_sql_open_connections{db_name="primary"} 0`)
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const accessEntryKey contextKey = "accessEntry"
+
+// accessEntry collects what inner handlers learn about a request, such as
+// the matched route and the authenticated user, for the access log
+type accessEntry struct {
+	route  string
+	userID int64
+}
+
+// AccessLog logs one record per request with its method, route template,
+// status, latency, response size and user ID. It must wrap the router, and
+// the router must use RecordRoute for the route template to be known.
+func AccessLog(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			r, entry := withAccessEntry(r)
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(rec, r)
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rec.status),
+				slog.Duration("latency", time.Since(start)),
+				slog.Int64("bytes", rec.bytes),
+			}
+			if entry.route != "" {
+				attrs = append(attrs, slog.String("route", entry.route))
+			}
+			if entry.userID != 0 {
+				attrs = append(attrs, slog.Int64("user_id", entry.userID))
+			}
+			logger.LogAttrs(r.Context(), slog.LevelInfo, "request", attrs...)
+		})
+	}
+}
+
+// RequestObserver is told about every served request
+type RequestObserver interface {
+	ObserveRequest(method, route string, status int, duration time.Duration)
+}
+
+// Instrument reports every request to obs with its route template, status
+// and latency. Like AccessLog it must wrap the router, which must use
+// RecordRoute.
+func Instrument(obs RequestObserver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			r, entry := withAccessEntry(r)
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(rec, r)
+
+			obs.ObserveRequest(r.Method, entry.route, rec.status, time.Since(start))
+		})
+	}
+}
+
+// withAccessEntry returns the request's access entry, adding one if an
+// outer middleware has not already
+func withAccessEntry(r *http.Request) (*http.Request, *accessEntry) {
+	if entry, ok := r.Context().Value(accessEntryKey).(*accessEntry); ok {
+		return r, entry
+	}
+	entry := &accessEntry{}
+	return r.WithContext(context.WithValue(r.Context(), accessEntryKey, entry)), entry
+}
+
+// RecordRoute records the template of the matched route, e.g. "/recipe",
+// for the access log. Register it with mux.Router.Use.
+func RecordRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if entry, ok := r.Context().Value(accessEntryKey).(*accessEntry); ok {
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					entry.route = template
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recordUser notes the authenticated user for the access log
+func recordUser(ctx context.Context, userID int64) {
+	if entry, ok := ctx.Value(accessEntryKey).(*accessEntry); ok {
+		entry.userID = userID
+	}
+}
+
+// statusRecorder captures the status code and size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
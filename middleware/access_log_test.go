@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend-app/logging"
+	"github.com/gorilla/mux"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "json", slog.LevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := NewAuthMiddleware(NewKeySet([][]byte{[]byte("current-key")}))
+
+	router := mux.NewRouter()
+	router.Use(RecordRoute)
+	router.HandleFunc("/recipe/{id}", auth.Require(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "created")
+	})).Methods("POST")
+	handler := RequestID(AccessLog(logger)(router))
+
+	req := httptest.NewRequest("POST", "/recipe/12", nil)
+	req.Header.Set("Authorization", "Bearer "+signedToken(t, "current-key", 7))
+	req.Header.Set(RequestIDHeader, "client-id-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(RequestIDHeader); got != "client-id-1" {
+		t.Errorf("%s = %q, want the client's ID", RequestIDHeader, got)
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("decoding %q: %v", buf.String(), err)
+	}
+	want := map[string]interface{}{
+		"method":     "POST",
+		"route":      "/recipe/{id}",
+		"status":     float64(http.StatusCreated),
+		"bytes":      float64(len("created")),
+		"user_id":    float64(7),
+		"request_id": "client-id-1",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, want %v", key, record[key], value)
+		}
+	}
+	if _, ok := record["latency"]; !ok {
+		t.Error("record has no latency")
+	}
+}
+
+func TestRequestIDReplacesInvalidIDs(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestID(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "forged\nlog line")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if seen == "" || seen == "forged\nlog line" {
+		t.Errorf("request ID = %q, want a generated ID", seen)
+	}
+	if rec.Header().Get(RequestIDHeader) != seen {
+		t.Errorf("response ID %q does not match context ID %q", rec.Header().Get(RequestIDHeader), seen)
+	}
+}
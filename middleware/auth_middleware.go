@@ -5,47 +5,156 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"backend-app/problem"
 	"github.com/dgrijalva/jwt-go"
 )
 
-// AuthMiddleware is a middleware function to authenticate requests
-func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+type contextKey string
+
+const userIDKey contextKey = "userID"
+
+// AuthMiddleware authenticates requests carrying a bearer token signed
+// with one of Keys
+type AuthMiddleware struct {
+	Keys *KeySet
+	// Sessions, if set, also accepts session cookies from requests without
+	// an Authorization header
+	Sessions *Sessions
+}
+
+// NewAuthMiddleware creates an AuthMiddleware accepting tokens signed with
+// any key in keys
+func NewAuthMiddleware(keys *KeySet) *AuthMiddleware {
+	return &AuthMiddleware{
+		Keys: keys,
+	}
+}
+
+// Require wraps next so that it is only called for authenticated requests
+func (am *AuthMiddleware) Require(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Extract the token from the Authorization header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header is missing", http.StatusUnauthorized)
+		// Extract the token from the Authorization header or session cookie
+		tokenString, p := am.credentials(r)
+		if p != nil {
+			problem.Write(w, r, p)
 			return
 		}
 
-		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-
 		// Verify the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Check token signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte("azme07"), nil // Replace with ac.JwtSecret in a real application
-		})
+		token, err := am.parse(tokenString)
 		if err != nil || !token.Valid {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			problem.Write(w, r, problem.Unauthorized("invalid token"))
 			return
 		}
 
 		// Store user information in request context
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+			problem.Write(w, r, problem.Unauthorized("invalid token claims"))
 			return
 		}
-		userID := claims["id"].(string) // Assuming userID is stored as a string in claims
-		ctx := context.WithValue(r.Context(), "userID", userID)
-		r = r.WithContext(ctx)
+		userID, ok := claimUserID(claims)
+		if !ok {
+			problem.Write(w, r, problem.Unauthorized("invalid token claims"))
+			return
+		}
+		r = r.WithContext(WithUserID(r.Context(), userID))
 
 		// Authentication successful, proceed to the next handler
 		next.ServeHTTP(w, r)
 	}
 }
+
+// Optional wraps next so that requests carrying a valid token are
+// attributed to their user, while anonymous requests and requests with an
+// invalid token are served anonymously
+func (am *AuthMiddleware) Optional(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if userID, ok := am.identify(r); ok {
+			r = r.WithContext(WithUserID(r.Context(), userID))
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// credentials returns the token r authenticates with: the bearer token, or
+// the session token if r has no Authorization header
+func (am *AuthMiddleware) credentials(r *http.Request) (string, *problem.Problem) {
+	if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+		return strings.Replace(authHeader, "Bearer ", "", 1), nil
+	}
+	if am.Sessions != nil {
+		if token, csrfOK := am.Sessions.token(r); token != "" {
+			if !csrfOK {
+				return "", problem.New(http.StatusForbidden, problem.CodeForbidden, "missing or invalid CSRF token")
+			}
+			return token, nil
+		}
+	}
+	return "", problem.Unauthorized("authorization header is missing")
+}
+
+// identify returns the user of a request carrying a valid token
+func (am *AuthMiddleware) identify(r *http.Request) (int64, bool) {
+	tokenString, p := am.credentials(r)
+	if p != nil {
+		return 0, false
+	}
+	token, err := am.parse(tokenString)
+	if err != nil || !token.Valid {
+		return 0, false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, false
+	}
+	return claimUserID(claims)
+}
+
+// parse verifies tokenString against each key in turn, so tokens signed
+// with a previous key stay valid while keys are rotated
+func (am *AuthMiddleware) parse(tokenString string) (*jwt.Token, error) {
+	err := jwt.ErrSignatureInvalid
+	for _, key := range am.Keys.VerificationKeys() {
+		var token *jwt.Token
+		token, err = jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+			// Check token signing method
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, jwt.ErrSignatureInvalid
+			}
+			return key, nil
+		})
+		if err == nil {
+			return token, nil
+		}
+	}
+	return nil, err
+}
+
+// WithUserID returns a context carrying the authenticated user's ID
+func WithUserID(ctx context.Context, userID int64) context.Context {
+	recordUser(ctx, userID)
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext returns the authenticated user's ID stored by
+// AuthMiddleware
+func UserIDFromContext(ctx context.Context) (int64, bool) {
+	userID, ok := ctx.Value(userIDKey).(int64)
+	return userID, ok
+}
+
+// claimUserID reads the "id" claim, which JSON decodes as a number
+func claimUserID(claims jwt.MapClaims) (int64, bool) {
+	switch id := claims["id"].(type) {
+	case float64:
+		return int64(id), id > 0
+	case string:
+		userID, err := strconv.ParseInt(id, 10, 64)
+		return userID, err == nil && userID > 0
+	}
+	return 0, false
+}
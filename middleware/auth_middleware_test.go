@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func signedToken(t *testing.T, key string, userID int) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id":  userID,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(key))
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return token
+}
+
+func TestRequireAcceptsEveryConfiguredKey(t *testing.T) {
+	auth := NewAuthMiddleware(NewKeySet([][]byte{[]byte("current-key"), []byte("previous-key")}))
+
+	for _, tc := range []struct {
+		key    string
+		status int
+	}{
+		{"current-key", http.StatusOK},
+		{"previous-key", http.StatusOK},
+		{"unknown-key", http.StatusUnauthorized},
+	} {
+		var userID int64
+		handler := auth.Require(func(w http.ResponseWriter, r *http.Request) {
+			userID, _ = UserIDFromContext(r.Context())
+		})
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signedToken(t, tc.key, 7))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("token signed with %s: status = %d, want %d", tc.key, rec.Code, tc.status)
+		}
+		if tc.status == http.StatusOK && userID != 7 {
+			t.Errorf("token signed with %s: user ID = %d, want 7", tc.key, userID)
+		}
+	}
+}
+
+func TestRequireUsesReplacedKeys(t *testing.T) {
+	keys := NewKeySet([][]byte{[]byte("old-key")})
+	auth := NewAuthMiddleware(keys)
+	handler := auth.Require(func(w http.ResponseWriter, r *http.Request) {})
+	token := signedToken(t, "old-key", 7)
+
+	keys.Replace([][]byte{[]byte("new-key")})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("token signed with a removed key: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestOptionalAttributesValidTokens(t *testing.T) {
+	auth := NewAuthMiddleware(NewKeySet([][]byte{[]byte("current-key")}))
+
+	for _, tc := range []struct {
+		name   string
+		header string
+		userID int64
+	}{
+		{"anonymous", "", 0},
+		{"valid token", "Bearer " + signedToken(t, "current-key", 7), 7},
+		{"invalid token", "Bearer " + signedToken(t, "unknown-key", 7), 0},
+	} {
+		var userID int64
+		handler := auth.Optional(func(w http.ResponseWriter, r *http.Request) {
+			userID, _ = UserIDFromContext(r.Context())
+		})
+
+		req := httptest.NewRequest("GET", "/recipes", nil)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != http.StatusOK || userID != tc.userID {
+			t.Errorf("%s: status %d, user ID %d; want 200 and %d", tc.name, rec.Code, userID, tc.userID)
+		}
+	}
+}
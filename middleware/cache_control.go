@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// CacheControl sets the Cache-Control header of successful GET and HEAD
+// responses from per-route policies. Other methods and error responses are
+// marked "no-store", and a header the handler sets itself is left alone.
+type CacheControl struct {
+	// Default applies to every route not listed in Routes
+	Default string
+	// Routes holds per-route policies keyed by route template, e.g. "/recipe"
+	Routes map[string]string
+}
+
+// Handler applies the policies. Register it with mux.Router.Use.
+func (c *CacheControl) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := "no-store"
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			policy = c.policy(r)
+		}
+		cw := &cacheControlWriter{ResponseWriter: w, policy: policy}
+		next.ServeHTTP(cw, r)
+		// A handler that writes nothing sends an empty 200
+		if !cw.wroteHeader {
+			cw.WriteHeader(http.StatusOK)
+		}
+	})
+}
+
+// policy returns the policy of the route r matched
+func (c *CacheControl) policy(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			if policy, ok := c.Routes[template]; ok {
+				return policy
+			}
+		}
+	}
+	return c.Default
+}
+
+// cacheControlWriter sets Cache-Control once the status is known
+type cacheControlWriter struct {
+	http.ResponseWriter
+	policy      string
+	wroteHeader bool
+}
+
+func (cw *cacheControlWriter) WriteHeader(status int) {
+	if !cw.wroteHeader {
+		cw.wroteHeader = true
+		header := cw.Header()
+		if header.Get("Cache-Control") == "" {
+			policy := cw.policy
+			if status >= http.StatusBadRequest {
+				policy = "no-store"
+			}
+			if policy != "" {
+				header.Set("Cache-Control", policy)
+			}
+		}
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *cacheControlWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (cw *cacheControlWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
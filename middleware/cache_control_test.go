@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestCacheControl(t *testing.T) {
+	cache := &CacheControl{
+		Default: "no-store",
+		Routes: map[string]string{
+			"/categories": "public, max-age=300",
+			"/recipe":     "no-cache",
+		},
+	}
+	router := mux.NewRouter()
+	router.Use(cache.Handler)
+	ok := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("{}")) }
+	router.HandleFunc("/categories", ok).Methods("GET", "HEAD", "POST")
+	router.HandleFunc("/recipe", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}).Methods("GET")
+	router.HandleFunc("/user", ok).Methods("GET")
+	router.HandleFunc("/own", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "private")
+	}).Methods("GET")
+
+	tests := []struct {
+		method, target, want string
+	}{
+		{"GET", "/categories", "public, max-age=300"},
+		{"HEAD", "/categories", "public, max-age=300"},
+		{"POST", "/categories", "no-store"},
+		{"GET", "/recipe?id=1", "no-cache"},
+		{"GET", "/recipe", "no-store"}, // errors are never cached
+		{"GET", "/user", "no-store"},
+		{"GET", "/own", "private"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+		if got := rec.Header().Get("Cache-Control"); got != tt.want {
+			t.Errorf("%s %s: Cache-Control = %q, want %q", tt.method, tt.target, got, tt.want)
+		}
+	}
+}
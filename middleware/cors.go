@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS adds cross-origin headers for requests from allowed origins and
+// answers their preflight requests. No headers are added when
+// AllowedOrigins is empty.
+type CORS struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+	// ExposedHeaders are the response headers scripts may read besides
+	// the few browsers always expose
+	ExposedHeaders []string
+}
+
+// Handler wraps next with CORS handling. It must wrap the router itself so
+// that preflight requests, which match no route, are answered.
+func (c *CORS) Handler(next http.Handler) http.Handler {
+	if len(c.AllowedOrigins) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		if !c.allowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if c.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Set("Access-Control-Allow-Credentials", "true")
+		} else if c.allowsAnyOrigin() {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+
+		// Preflight requests are answered here and never reach the router
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", strings.Join(c.AllowedMethods, ", "))
+			header.Set("Access-Control-Allow-Headers", strings.Join(c.AllowedHeaders, ", "))
+			if c.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if len(c.ExposedHeaders) > 0 {
+			header.Set("Access-Control-Expose-Headers", strings.Join(c.ExposedHeaders, ", "))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *CORS) allowed(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CORS) allowsAnyOrigin() bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	cors := &CORS{
+		AllowedOrigins: []string{"https://recipes.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization"},
+		MaxAge:         time.Minute,
+		ExposedHeaders: []string{"X-Request-ID", "RateLimit-Remaining"},
+	}
+	reached := false
+	handler := cors.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+
+	// Preflight from an allowed origin is answered without reaching the router
+	req := httptest.NewRequest("OPTIONS", "/recipe/create", nil)
+	req.Header.Set("Origin", "https://recipes.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent || reached {
+		t.Errorf("preflight: status %d, reached handler %v", rec.Code, reached)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://recipes.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Max-Age"); got != "60" {
+		t.Errorf("Access-Control-Max-Age = %q, want 60", got)
+	}
+
+	// Actual requests may read the exposed headers
+	req = httptest.NewRequest("GET", "/recipes", nil)
+	req.Header.Set("Origin", "https://recipes.example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Expose-Headers"); got != "X-Request-ID, RateLimit-Remaining" {
+		t.Errorf("Access-Control-Expose-Headers = %q", got)
+	}
+	reached = false
+
+	// Requests from other origins get no CORS headers
+	req = httptest.NewRequest("GET", "/recipes", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !reached {
+		t.Error("request from another origin did not reach the handler")
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q for a disallowed origin", got)
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import "sync"
+
+// KeySet holds the HMAC keys used for tokens. The first key signs new
+// tokens and every key is accepted when verifying, so a key can be rotated
+// by prepending its successor. Keys can be replaced while serving requests.
+type KeySet struct {
+	mu   sync.RWMutex
+	keys [][]byte
+}
+
+// NewKeySet creates a KeySet holding keys
+func NewKeySet(keys [][]byte) *KeySet {
+	ks := &KeySet{}
+	ks.Replace(keys)
+	return ks
+}
+
+// SigningKey returns the key new tokens are signed with
+func (ks *KeySet) SigningKey() []byte {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if len(ks.keys) == 0 {
+		return nil
+	}
+	return ks.keys[0]
+}
+
+// VerificationKeys returns every key tokens may be signed with
+func (ks *KeySet) VerificationKeys() [][]byte {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.keys
+}
+
+// Replace swaps in a new set of keys
+func (ks *KeySet) Replace(keys [][]byte) {
+	copied := make([][]byte, len(keys))
+	copy(copied, keys)
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys = copied
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"backend-app/problem"
+	"backend-app/ratelimit"
+)
+
+// RateLimiter limits requests with a token bucket per route and client.
+// Clients are the authenticated user if the request carries a valid token
+// and the client IP otherwise. Responses carry RateLimit-Limit,
+// RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers, and
+// denied requests get 429 with Retry-After.
+type RateLimiter struct {
+	Store ratelimit.Store
+	// Auth identifies users; without it every client is limited by IP
+	Auth *AuthMiddleware
+	// Default applies to routes without a policy of their own
+	Default ratelimit.Policy
+	// Routes holds per-route policies keyed by route template, e.g. "/login"
+	Routes map[string]ratelimit.Policy
+	// TrustForwardedFor takes the client IP from the last X-Forwarded-For
+	// entry. Only enable it behind a proxy that sets the header.
+	TrustForwardedFor bool
+}
+
+// Limit applies the rate limits. Register it with mux.Router.Use; requests
+// that match no route are not limited. If the store fails, requests are
+// let through rather than rejected.
+func (l *RateLimiter) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		policy, ok := l.Routes[template]
+		if !ok {
+			policy = l.Default
+		}
+		if !policy.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		result, err := l.Store.Take(r.Context(), template+" "+l.client(r), policy)
+		if err != nil {
+			slog.WarnContext(r.Context(), "rate limit store failed, allowing request", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(policy.Capacity()))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", policy.Limit, seconds(policy.Period), policy.Capacity()))
+		if !result.Allowed {
+			retryAfter := seconds(result.RetryAfter)
+			header.Set("Retry-After", strconv.Itoa(retryAfter))
+			problem.Write(w, r, problem.New(http.StatusTooManyRequests, problem.CodeRateLimited,
+				fmt.Sprintf("too many requests, retry in %d seconds", retryAfter)))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// client identifies who sent r
+func (l *RateLimiter) client(r *http.Request) string {
+	if l.Auth != nil {
+		if userID, ok := l.Auth.identify(r); ok {
+			return "user:" + strconv.FormatInt(userID, 10)
+		}
+	}
+	return "ip:" + l.clientIP(r)
+}
+
+// clientIP returns the address r came from
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	if l.TrustForwardedFor {
+		// The proxy appends the address it received the request from, so
+		// earlier entries may be forged by the client
+		forwarded := r.Header.Values("X-Forwarded-For")
+		if len(forwarded) > 0 {
+			entries := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(entries[len(entries)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// seconds rounds d up to whole seconds, as the RateLimit headers expect
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"backend-app/problem"
+	"backend-app/ratelimit"
+)
+
+func rateLimitedRouter(limiter *RateLimiter) http.Handler {
+	router := mux.NewRouter()
+	router.Use(limiter.Limit)
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	router.HandleFunc("/login", ok).Methods("POST")
+	router.HandleFunc("/recipes", ok).Methods("GET")
+	return router
+}
+
+func TestRateLimiterAppliesRoutePolicies(t *testing.T) {
+	limiter := &RateLimiter{
+		Store:   ratelimit.NewMemoryStore(),
+		Default: ratelimit.Policy{Limit: 100, Period: time.Minute},
+		Routes: map[string]ratelimit.Policy{
+			"/login": {Limit: 2, Period: time.Minute},
+		},
+	}
+	handler := rateLimitedRouter(limiter)
+
+	var rec *httptest.ResponseRecorder
+	for i := 0; i < 3; i++ {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("POST", "/login", nil))
+	}
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("third login status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != problem.ContentType {
+		t.Errorf("Content-Type = %q, want a problem", got)
+	}
+	want := map[string]string{
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "60",
+		"RateLimit-Policy":    "2;w=60;burst=2",
+		"Retry-After":         "30",
+	}
+	for name, value := range want {
+		if got := rec.Header().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+
+	// Other routes have buckets of their own
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/recipes", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Remaining") != "99" {
+		t.Errorf("recipes status = %d, remaining %s, want 200 with 99 remaining", rec.Code, rec.Header().Get("RateLimit-Remaining"))
+	}
+}
+
+func TestRateLimiterKeysByUserThenIP(t *testing.T) {
+	limiter := &RateLimiter{
+		Store:   ratelimit.NewMemoryStore(),
+		Auth:    NewAuthMiddleware(NewKeySet([][]byte{[]byte("current-key")})),
+		Default: ratelimit.Policy{Limit: 1, Period: time.Minute},
+	}
+	handler := rateLimitedRouter(limiter)
+
+	send := func(remoteAddr, token, forwardedFor string) int {
+		req := httptest.NewRequest("GET", "/recipes", nil)
+		req.RemoteAddr = remoteAddr
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if send("10.0.0.1:1234", "", "") != http.StatusOK {
+		t.Fatal("first anonymous request was limited")
+	}
+	if send("10.0.0.1:5678", "", "") != http.StatusTooManyRequests {
+		t.Error("second request from the same IP was not limited")
+	}
+	if send("10.0.0.2:1234", "", "") != http.StatusOK {
+		t.Error("request from another IP was limited")
+	}
+
+	// A user is limited on their own, wherever they connect from
+	token := signedToken(t, "current-key", 7)
+	if send("10.0.0.1:1234", token, "") != http.StatusOK {
+		t.Error("first request of a user was limited by their IP")
+	}
+	if send("10.0.0.3:1234", token, "") != http.StatusTooManyRequests {
+		t.Error("second request of a user from another IP was not limited")
+	}
+
+	// X-Forwarded-For is ignored unless trusted
+	if send("10.0.0.1:1234", "", "192.0.2.1") != http.StatusTooManyRequests {
+		t.Error("untrusted X-Forwarded-For bypassed the limit")
+	}
+	limiter.TrustForwardedFor = true
+	if send("10.0.0.1:1234", "", "198.51.100.9, 192.0.2.1") != http.StatusOK {
+		t.Error("trusted X-Forwarded-For was not used")
+	}
+	if send("10.0.0.1:1234", "", "203.0.113.5, 192.0.2.1") != http.StatusTooManyRequests {
+		t.Error("forged X-Forwarded-For entry was used instead of the proxy's")
+	}
+}
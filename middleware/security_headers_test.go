@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSecurityHeadersCoverStaticFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "image.png"), []byte("png"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	security := &SecurityHeaders{
+		ContentSecurityPolicy: "default-src 'none'",
+		HSTSMaxAge:            time.Hour,
+		HSTSIncludeSubdomains: true,
+		ReferrerPolicy:        "no-referrer",
+	}
+	handler := security.Handler(http.StripPrefix("/static/", http.FileServer(http.Dir(dir))))
+
+	for _, target := range []string{"/static/image.png", "/static/missing.png"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+
+		want := map[string]string{
+			"Content-Security-Policy":   "default-src 'none'",
+			"Strict-Transport-Security": "max-age=3600; includeSubDomains",
+			"X-Content-Type-Options":    "nosniff",
+			"X-Frame-Options":           "DENY",
+			"Referrer-Policy":           "no-referrer",
+		}
+		for name, value := range want {
+			if got := rec.Header().Get(name); got != value {
+				t.Errorf("%s: %s = %q, want %q", target, name, got, value)
+			}
+		}
+	}
+}
+
+func TestSecurityHeadersLeaveOutEmptySettings(t *testing.T) {
+	handler := (&SecurityHeaders{}).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	for _, name := range []string{"Content-Security-Policy", "Strict-Transport-Security", "Referrer-Policy"} {
+		if got := rec.Header().Get(name); got != "" {
+			t.Errorf("%s = %q, want it left out", name, got)
+		}
+	}
+	if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"time"
+)
+
+// CSRFHeader carries the CSRF token on requests authenticated by a session
+// cookie
+const CSRFHeader = "X-CSRF-Token"
+
+// Sessions lets browsers authenticate with a session cookie instead of a
+// bearer token. The cookie holds the same signed token a login returns and
+// is HttpOnly, so scripts cannot read it.
+//
+// Cookies are sent with cross-site requests too, so requests that change
+// state must also carry the CSRF token in the X-CSRF-Token header. The
+// token is set in a second cookie that scripts on the frontend's origin can
+// read (double submit); it is an HMAC of the session token, so it cannot be
+// forged by planting a cookie.
+type Sessions struct {
+	Keys       *KeySet
+	CookieName string
+	// CSRFCookieName is the cookie holding the CSRF token
+	CSRFCookieName string
+	// Secure restricts the cookies to HTTPS
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// Start sets the session and CSRF cookies for token, which expires at
+// expires, and returns the CSRF token
+func (s *Sessions) Start(w http.ResponseWriter, token string, expires time.Time) string {
+	csrfToken := s.csrfToken(token, s.Keys.SigningKey())
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.CookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   s.Secure,
+		SameSite: s.SameSite,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.CSRFCookieName,
+		Value:    csrfToken,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   s.Secure,
+		SameSite: s.SameSite,
+	})
+	return csrfToken
+}
+
+// End clears the session and CSRF cookies
+func (s *Sessions) End(w http.ResponseWriter) {
+	for _, name := range []string{s.CookieName, s.CSRFCookieName} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Path:     "/",
+			MaxAge:   -1,
+			HttpOnly: name == s.CookieName,
+			Secure:   s.Secure,
+			SameSite: s.SameSite,
+		})
+	}
+}
+
+// token returns the session token of r, if it has one. csrfOK reports
+// whether r may use it: safe methods always may, other methods only with a
+// CSRF header matching both the CSRF cookie and the session token.
+func (s *Sessions) token(r *http.Request) (token string, csrfOK bool) {
+	cookie, err := r.Cookie(s.CookieName)
+	if err != nil || cookie.Value == "" {
+		return "", false
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return cookie.Value, true
+	}
+
+	header := r.Header.Get(CSRFHeader)
+	csrfCookie, err := r.Cookie(s.CSRFCookieName)
+	if header == "" || err != nil || !hmac.Equal([]byte(header), []byte(csrfCookie.Value)) {
+		return cookie.Value, false
+	}
+	// Tokens signed with a previous key stay valid while keys are rotated
+	for _, key := range s.Keys.VerificationKeys() {
+		if hmac.Equal([]byte(header), []byte(s.csrfToken(cookie.Value, key))) {
+			return cookie.Value, true
+		}
+	}
+	return cookie.Value, false
+}
+
+// csrfToken derives the CSRF token of a session token with key
+func (s *Sessions) csrfToken(token string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("csrf:" + token))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionCookiesRequireCSRFTokenForWrites(t *testing.T) {
+	keys := NewKeySet([][]byte{[]byte("current-key")})
+	sessions := &Sessions{Keys: keys, CookieName: "session", CSRFCookieName: "csrf_token", Secure: true, SameSite: http.SameSiteLaxMode}
+	auth := NewAuthMiddleware(keys)
+	auth.Sessions = sessions
+
+	var userID int64
+	handler := auth.Require(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ = UserIDFromContext(r.Context())
+	})
+
+	// Log in: the session cookie is HttpOnly, the CSRF cookie is not
+	rec := httptest.NewRecorder()
+	csrfToken := sessions.Start(rec, signedToken(t, "current-key", 7), time.Now().Add(time.Hour))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 2 || !cookies[0].HttpOnly || cookies[1].HttpOnly || cookies[1].Value != csrfToken {
+		t.Fatalf("cookies = %+v, want an HttpOnly session and a readable CSRF token", cookies)
+	}
+
+	send := func(method, csrfHeader string, cookies ...*http.Cookie) int {
+		req := httptest.NewRequest(method, "/recipe/create", nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		if csrfHeader != "" {
+			req.Header.Set(CSRFHeader, csrfHeader)
+		}
+		rec := httptest.NewRecorder()
+		userID = 0
+		handler(rec, req)
+		return rec.Code
+	}
+
+	if status := send("GET", "", cookies...); status != http.StatusOK || userID != 7 {
+		t.Errorf("GET with session: status %d, user %d, want 200 for user 7", status, userID)
+	}
+	if status := send("POST", "", cookies...); status != http.StatusForbidden {
+		t.Errorf("POST without CSRF token: status %d, want 403", status)
+	}
+	if status := send("POST", csrfToken, cookies...); status != http.StatusOK || userID != 7 {
+		t.Errorf("POST with CSRF token: status %d, user %d, want 200 for user 7", status, userID)
+	}
+
+	// A planted CSRF cookie with a matching header is not enough: the token
+	// must belong to the session
+	forged := &http.Cookie{Name: "csrf_token", Value: "forged"}
+	if status := send("POST", "forged", cookies[0], forged); status != http.StatusForbidden {
+		t.Errorf("POST with forged CSRF pair: status %d, want 403", status)
+	}
+
+	// Logging out clears both cookies
+	rec = httptest.NewRecorder()
+	sessions.End(rec)
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.MaxAge >= 0 {
+			t.Errorf("cookie %s not expired by End", cookie.Name)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"backend-app/logging"
+)
+
+// Trace records a server span for every request, continuing the trace of
+// the caller if the request carries a traceparent header. The span is named
+// after the route template, so like AccessLog it must wrap the router,
+// which must use RecordRoute.
+func Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := otel.Tracer("backend-app/middleware").Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+		if requestID := logging.RequestID(ctx); requestID != "" {
+			span.SetAttributes(attribute.String("request_id", requestID))
+		}
+
+		r, entry := withAccessEntry(r.WithContext(ctx))
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		if entry.route != "" {
+			span.SetName(r.Method + " " + entry.route)
+			span.SetAttributes(semconv.HTTPRoute(entry.route))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTraceNamesSpansAfterRoutes(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	router := mux.NewRouter()
+	router.Use(RecordRoute)
+	router.HandleFunc("/recipe/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	handler := RequestID(Trace(router))
+
+	for _, target := range []string{"/recipe/12", "/missing"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	if got := spans[0].Name(); got != "GET /recipe/{id}" {
+		t.Errorf("span name = %q, want the route template", got)
+	}
+	if got := spans[0].Status().Code; got != codes.Error {
+		t.Errorf("status of a 500 = %v, want error", got)
+	}
+	if got := spans[1].Name(); got != "GET" {
+		t.Errorf("unmatched span name = %q, want just the method", got)
+	}
+	if got := spans[1].Status().Code; got == codes.Error {
+		t.Error("404 marked as an error")
+	}
+}
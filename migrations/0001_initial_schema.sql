@@ -0,0 +1,41 @@
+-- Schema originally created by utils/database.go
+CREATE TABLE IF NOT EXISTS users (
+	id SERIAL PRIMARY KEY,
+	username VARCHAR(100) NOT NULL,
+	email VARCHAR(255) UNIQUE NOT NULL,
+	password_hash VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS categories (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(100) UNIQUE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS recipes (
+	id SERIAL PRIMARY KEY,
+	title VARCHAR(255) NOT NULL,
+	description TEXT,
+	prep_time INT,
+	category_id INT,
+	creator_id INT NOT NULL,
+	created_at TIMESTAMP DEFAULT current_timestamp,
+	updated_at TIMESTAMP DEFAULT current_timestamp,
+	FOREIGN KEY (category_id) REFERENCES categories(id),
+	FOREIGN KEY (creator_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS steps (
+	id SERIAL PRIMARY KEY,
+	recipe_id INT NOT NULL,
+	step_number INT NOT NULL,
+	description TEXT,
+	FOREIGN KEY (recipe_id) REFERENCES recipes(id)
+);
+
+CREATE TABLE IF NOT EXISTS ingredients (
+	id SERIAL PRIMARY KEY,
+	recipe_id INT NOT NULL,
+	name VARCHAR(255),
+	quantity VARCHAR(50),
+	FOREIGN KEY (recipe_id) REFERENCES recipes(id)
+);
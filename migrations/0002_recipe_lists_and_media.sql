@@ -0,0 +1,12 @@
+-- RecipeRepository stores ingredients, steps and image paths on the recipe
+-- row itself
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS ingredients TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS steps TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS images TEXT[] NOT NULL DEFAULT '{}';
+
+-- Uploaded media tracked for garbage collection
+CREATE TABLE IF NOT EXISTS media (
+	path TEXT PRIMARY KEY,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+	released_at TIMESTAMPTZ
+);
@@ -0,0 +1,11 @@
+-- Token buckets shared by every instance when rate limits are kept in
+-- PostgreSQL. Rows whose bucket has refilled (full_at has passed) carry no
+-- state and are purged periodically.
+CREATE TABLE IF NOT EXISTS rate_limits (
+	key TEXT PRIMARY KEY,
+	tokens DOUBLE PRECISION NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL,
+	full_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rate_limits_full_at_idx ON rate_limits (full_at);
@@ -0,0 +1,6 @@
+-- Every update bumps a recipe's version, which serves as its ETag and
+-- guards against lost updates
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE recipes ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
+UPDATE recipes SET updated_at = COALESCE(created_at, current_timestamp) WHERE updated_at IS NULL;
+ALTER TABLE recipes ALTER COLUMN updated_at SET NOT NULL;
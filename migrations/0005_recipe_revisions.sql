@@ -0,0 +1,26 @@
+-- Every version of a recipe is kept as an immutable revision, so edits can
+-- be reviewed and undone
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS updated_by INT REFERENCES users(id) ON DELETE SET NULL;
+UPDATE recipes SET updated_by = creator_id WHERE updated_by IS NULL;
+
+CREATE TABLE IF NOT EXISTS recipe_revisions (
+	recipe_id INT NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+	version BIGINT NOT NULL,
+	title VARCHAR(255) NOT NULL,
+	description TEXT,
+	ingredients TEXT[] NOT NULL DEFAULT '{}',
+	steps TEXT[] NOT NULL DEFAULT '{}',
+	prep_time INT,
+	-- Not a foreign key: a revision outlives the category it was filed under
+	category_id INT,
+	images TEXT[] NOT NULL DEFAULT '{}',
+	editor_id INT REFERENCES users(id) ON DELETE SET NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+	PRIMARY KEY (recipe_id, version)
+);
+
+-- The current version of every existing recipe is its first revision
+INSERT INTO recipe_revisions (recipe_id, version, title, description, ingredients, steps, prep_time, category_id, images, editor_id, created_at)
+SELECT id, version, title, description, ingredients, steps, prep_time, category_id, images, updated_by, updated_at
+FROM recipes
+ON CONFLICT DO NOTHING;
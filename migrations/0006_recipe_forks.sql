@@ -0,0 +1,7 @@
+-- A fork records the recipe and version it was copied from and who wrote
+-- it. The recipe ID is not a foreign key so forks keep their attribution
+-- when the original is deleted.
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS forked_from INT;
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS forked_from_version BIGINT;
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS forked_from_creator INT REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS recipes_forked_from_idx ON recipes (forked_from) WHERE forked_from IS NOT NULL;
@@ -0,0 +1,24 @@
+-- Deleted users, categories and recipes are kept until the trash purger
+-- removes them after the retention window
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS users_deleted_at_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS categories_deleted_at_idx ON categories (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS recipes_deleted_at_idx ON recipes (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS recipes_creator_id_idx ON recipes (creator_id);
+
+-- Emails and category names only need to be unique among live rows, so a
+-- deleted account or category does not hold on to them
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_live_idx ON users (email) WHERE deleted_at IS NULL;
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS categories_name_live_idx ON categories (name) WHERE deleted_at IS NULL;
+
+-- Rows of the legacy steps and ingredients tables must not keep a purged
+-- recipe from being deleted
+ALTER TABLE steps DROP CONSTRAINT IF EXISTS steps_recipe_id_fkey;
+ALTER TABLE steps ADD CONSTRAINT steps_recipe_id_fkey FOREIGN KEY (recipe_id) REFERENCES recipes(id) ON DELETE CASCADE;
+ALTER TABLE ingredients DROP CONSTRAINT IF EXISTS ingredients_recipe_id_fkey;
+ALTER TABLE ingredients ADD CONSTRAINT ingredients_recipe_id_fkey FOREIGN KEY (recipe_id) REFERENCES recipes(id) ON DELETE CASCADE;
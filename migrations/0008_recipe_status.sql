@@ -0,0 +1,9 @@
+-- Recipes move from draft to published to archived. Drafts may be scheduled
+-- for publication at publish_at. Existing recipes were public, so they
+-- start out published.
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'published'
+	CHECK (status IN ('draft', 'published', 'archived'));
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ;
+UPDATE recipes SET published_at = created_at WHERE status = 'published' AND published_at IS NULL;
+CREATE INDEX IF NOT EXISTS recipes_publish_at_idx ON recipes (publish_at) WHERE status = 'draft' AND publish_at IS NOT NULL;
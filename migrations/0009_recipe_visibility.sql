@@ -0,0 +1,15 @@
+-- Recipes are public, unlisted (readable by whoever holds the share link)
+-- or private. Any recipe can also be shared with specific users, who may
+-- view or edit it. Existing recipes were public.
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT 'public'
+	CHECK (visibility IN ('private', 'unlisted', 'public'));
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS share_token TEXT UNIQUE;
+
+CREATE TABLE IF NOT EXISTS recipe_shares (
+	recipe_id INT NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	permission TEXT NOT NULL CHECK (permission IN ('view', 'edit')),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+	PRIMARY KEY (recipe_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS recipe_shares_user_id_idx ON recipe_shares (user_id);
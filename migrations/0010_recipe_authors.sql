@@ -0,0 +1,22 @@
+-- Recipes can have co-authors besides their creator, who is always an
+-- owner. Users become co-authors by accepting an invitation.
+CREATE TABLE IF NOT EXISTS recipe_authors (
+	recipe_id INT NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	role TEXT NOT NULL CHECK (role IN ('owner', 'editor')),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+	PRIMARY KEY (recipe_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS recipe_authors_user_id_idx ON recipe_authors (user_id);
+
+-- A user has at most one pending invitation per recipe
+CREATE TABLE IF NOT EXISTS recipe_invitations (
+	id BIGSERIAL PRIMARY KEY,
+	recipe_id INT NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+	invitee_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	invited_by INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	role TEXT NOT NULL CHECK (role IN ('owner', 'editor')),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+	UNIQUE (recipe_id, invitee_id)
+);
+CREATE INDEX IF NOT EXISTS recipe_invitations_invitee_id_idx ON recipe_invitations (invitee_id);
@@ -0,0 +1,158 @@
+// Package migrations holds the database schema as an ordered list of SQL
+// files and applies the ones a database has not seen yet.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Migration is a single schema change
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// advisoryLockID serializes concurrent Apply calls across processes
+const advisoryLockID = 7245001
+
+// All returns every migration ordered by version
+func All() ([]Migration, error) {
+	entries, err := files.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, entry := range entries {
+		name := entry.Name()
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: name must start with a version", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", name, err)
+		}
+		content, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(content)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Apply runs every migration that has not been applied to db yet, each in
+// its own transaction, and returns the number of migrations applied
+func Apply(ctx context.Context, db *sql.DB) (int, error) {
+	migrations, err := All()
+	if err != nil {
+		return 0, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, advisoryLockID); err != nil {
+		return 0, fmt.Errorf("locking migrations: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, advisoryLockID)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+		)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		var exists bool
+		err := conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, m.Version).Scan(&exists)
+		if err != nil {
+			return applied, fmt.Errorf("checking migration %s: %w", m.Name, err)
+		}
+		if exists {
+			continue
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return applied, err
+		}
+		if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+			tx.Rollback()
+			return applied, fmt.Errorf("applying migration %s: %w", m.Name, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, m.Version); err != nil {
+			tx.Rollback()
+			return applied, fmt.Errorf("recording migration %s: %w", m.Name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return applied, fmt.Errorf("committing migration %s: %w", m.Name, err)
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// Pending returns the migrations that have not been applied to db, ordered
+// by version
+func Pending(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+
+	// A database that was never migrated has no schema_migrations table
+	var tracked bool
+	if err := db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&tracked); err != nil {
+		return nil, fmt.Errorf("checking schema_migrations: %w", err)
+	}
+	if !tracked {
+		return migrations, nil
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("listing applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,37 @@
+package migrations_test
+
+import (
+	"context"
+	"testing"
+
+	"backend-app/migrations"
+	"backend-app/store/pgtest"
+)
+
+func TestMain(m *testing.M) {
+	pgtest.Main(m)
+}
+
+func TestPending(t *testing.T) {
+	ctx := context.Background()
+	db := pgtest.NewDB(t)
+
+	pending, err := migrations.Pending(ctx, db)
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("pending after Apply = %v, want none", pending)
+	}
+
+	if _, err := db.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = 2`); err != nil {
+		t.Fatal(err)
+	}
+	pending, err = migrations.Pending(ctx, db)
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Version != 2 {
+		t.Errorf("pending = %v, want migration 2", pending)
+	}
+}
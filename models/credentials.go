@@ -3,6 +3,6 @@
 package models
 
 type Credentials struct {
-    Email    string `json:"email"`
-    Password string `json:"password"`
+    Email    string `json:"email" validate:"required,email"`
+    Password string `json:"password" validate:"required"`
 }
@@ -1,13 +1,111 @@
 package models
 
+import "time"
+
 type Recipe struct {
-	ID          int64    `json:"id"`
-	Title       string   `json:"title"`
-	Description string   `json:"description"`
-	Ingredients []string `json:"ingredients"`
-	Steps       []string `json:"steps"`
-	PrepTime    int      `json:"time"`
-	CategoryID  int64    `json:"category_id"`
-	CreatorID   int64    `json:"creator_id"`
-	Images      []string `json:"images"`
+	ID          int64       `json:"id"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Ingredients []string    `json:"ingredients"`
+	Steps       []string    `json:"steps"`
+	PrepTime    int         `json:"time"`
+	CategoryID  int64       `json:"category_id"`
+	CreatorID   int64       `json:"creator_id"`
+	Images      []string    `json:"images"`
+	Version     int64       `json:"version"` // Incremented by every update
+	UpdatedAt   time.Time   `json:"updated_at"`
+	UpdatedBy   int64       `json:"updated_by"`           // Editor of the current version
+	ForkedFrom  *ForkOrigin `json:"forked_from"`          // Nil unless the recipe is a fork
+	DeletedAt   *time.Time  `json:"deleted_at,omitempty"` // Set on recipes in the trash
+	Status      string      `json:"status"`
+	PublishAt   *time.Time  `json:"publish_at,omitempty"`   // When a scheduled draft will be published
+	PublishedAt *time.Time  `json:"published_at,omitempty"` // When the recipe was first published
+	Visibility  string      `json:"visibility"`
+	ShareToken  string      `json:"share_token,omitempty"` // Secret of an unlisted recipe's share link, shown to its owners only
+}
+
+// Recipes move from draft to published to archived. Drafts are only
+// visible to their authors, the creator and co-authors, even when shared;
+// archived recipes can still be read but are no longer listed.
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+)
+
+// Public recipes can be read by anyone, unlisted ones by whoever has their
+// share link and private ones only by their authors. Recipes of any
+// visibility can also be shared with specific users, who may read them
+// once they are no longer drafts.
+const (
+	VisibilityPrivate  = "private"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPublic   = "public"
+)
+
+// RecipeShare grants a user access to a recipe someone else created
+type RecipeShare struct {
+	RecipeID   int64     `json:"recipe_id"`
+	UserID     int64     `json:"user_id"`
+	Permission string    `json:"permission"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Users a recipe is shared with may view it, or view and edit its content
+const (
+	PermissionView = "view"
+	PermissionEdit = "edit"
+)
+
+// RecipeAuthor is a user who writes a recipe together with its creator
+type RecipeAuthor struct {
+	RecipeID  int64     `json:"recipe_id"`
+	UserID    int64     `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Editors change the content of a recipe. Owners may also publish, share
+// and delete it and manage its authors. The creator is always an owner.
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+)
+
+// Invitation asks a user to become an author of a recipe
+type Invitation struct {
+	ID        int64     `json:"id"`
+	RecipeID  int64     `json:"recipe_id"`
+	InviteeID int64     `json:"invitee_id"`
+	InvitedBy int64     `json:"invited_by"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ForkOrigin attributes a fork to the recipe it was copied from. It is kept
+// when that recipe is deleted.
+type ForkOrigin struct {
+	RecipeID  int64 `json:"recipe_id"`
+	Version   int64 `json:"version"`
+	CreatorID int64 `json:"creator_id"` // Zero once the author's account is deleted
+}
+
+// Lineage places a recipe among the recipes it was forked from and the
+// forks made of it
+type Lineage struct {
+	RecipeID int64 `json:"recipe_id"`
+	// Ancestors lists the recipe it was forked from first, up to the
+	// original or the first ancestor that has been deleted
+	Ancestors []LineageEntry `json:"ancestors"`
+	Forks     []LineageEntry `json:"forks"`
+	ForkCount int            `json:"fork_count"`
+}
+
+// LineageEntry is one recipe in a lineage. Deleted ancestors are listed
+// with only the attribution their forks kept.
+type LineageEntry struct {
+	RecipeID  int64  `json:"recipe_id"`
+	Title     string `json:"title,omitempty"`
+	CreatorID int64  `json:"creator_id"`
+	Deleted   bool   `json:"deleted,omitempty"`
 }
\ No newline at end of file
@@ -0,0 +1,113 @@
+package models
+
+import (
+    "mime/multipart"
+    "time"
+)
+
+// SignUpRequest is the payload of a user registration
+type SignUpRequest struct {
+    Username string `json:"username" validate:"required,min=3,max=100"`
+    Email    string `json:"email" validate:"required,email,max=255"`
+    Password string `json:"password" validate:"required,min=8,max=72"` // bcrypt ignores bytes past 72
+}
+
+// UpdateUserRequest is the payload of a profile update
+type UpdateUserRequest struct {
+    ID       int    `json:"id" validate:"required,gt=0"`
+    Username string `json:"username" validate:"required,min=3,max=100"`
+    Email    string `json:"email" validate:"required,email,max=255"`
+}
+
+// CreateCategoryRequest is the payload of a category creation
+type CreateCategoryRequest struct {
+    Name string `json:"name" validate:"required,max=100"`
+}
+
+// UpdateCategoryRequest is the payload of a category rename
+type UpdateCategoryRequest struct {
+    ID   int    `json:"id" validate:"required,gt=0"`
+    Name string `json:"name" validate:"required,max=100"`
+}
+
+// RecipeFields are the user-editable fields of a recipe. Images are uploaded
+// as multipart files alongside the JSON document.
+type RecipeFields struct {
+    Title       string                  `json:"title" validate:"required,max=255"`
+    Description string                  `json:"description" validate:"max=5000"`
+    Ingredients []string                `json:"ingredients" validate:"max=100,dive,required,max=255"`
+    Steps       []string                `json:"steps" validate:"max=100,dive,required,max=2000"`
+    PrepTime    int                     `json:"time" validate:"min=0,max=1440"` // minutes, up to a day
+    CategoryID  int64                   `json:"category_id" validate:"min=0"`
+    Images      []*multipart.FileHeader `json:"-" form:"images" validate:"max=10"`
+}
+
+// CreateRecipeRequest is the payload of a recipe creation. The creator is
+// the authenticated user. Recipes start out as drafts unless the request
+// publishes them, at PublishAt if that is in the future.
+type CreateRecipeRequest struct {
+    RecipeFields
+    Status     string     `json:"status" validate:"omitempty,oneof=draft published"`
+    PublishAt  *time.Time `json:"publish_at"`
+    Visibility string     `json:"visibility" validate:"omitempty,oneof=private unlisted public"` // public if empty
+}
+
+// UpdateRecipeRequest is the payload of a recipe update
+type UpdateRecipeRequest struct {
+    RecipeFields
+    ID int64 `json:"id" validate:"required,gt=0"`
+    // ExpectedVersion, when set, is the version the client last saw; the
+    // update fails if the recipe has changed since
+    ExpectedVersion int64 `json:"-"`
+}
+
+// RecipeStatusRequest moves a recipe through its lifecycle. Publishing a
+// draft with a future PublishAt schedules the publication instead; PublishAt
+// is ignored for the other statuses, so moving a scheduled draft to draft
+// cancels its publication.
+type RecipeStatusRequest struct {
+    Status    string     `json:"status" validate:"required,oneof=draft published archived"`
+    PublishAt *time.Time `json:"publish_at"`
+    ID        int64      `json:"-"`
+    // ExpectedVersion, when set, is the version the client last saw
+    ExpectedVersion int64 `json:"-"`
+}
+
+// RecipeVisibilityRequest changes who can read a recipe. Making a recipe
+// unlisted gives it a share link, which is revoked when it stops being
+// unlisted.
+type RecipeVisibilityRequest struct {
+    Visibility string `json:"visibility" validate:"required,oneof=private unlisted public"`
+    ID         int64  `json:"-"`
+    // ExpectedVersion, when set, is the version the client last saw
+    ExpectedVersion int64 `json:"-"`
+}
+
+// ShareRecipeRequest shares a recipe with a user, or changes the
+// permission it is shared with
+type ShareRecipeRequest struct {
+    UserID     int64  `json:"user_id" validate:"required,gt=0"`
+    Permission string `json:"permission" validate:"required,oneof=view edit"`
+    RecipeID   int64  `json:"-"`
+}
+
+// InviteAuthorRequest invites a user, named by username or email, to
+// become an author of a recipe
+type InviteAuthorRequest struct {
+    Username string `json:"username" validate:"required_without=Email,excluded_with=Email,max=100"`
+    Email    string `json:"email" validate:"omitempty,email"`
+    Role     string `json:"role" validate:"required,oneof=owner editor"`
+    RecipeID int64  `json:"-"`
+}
+
+// Recipe builds the recipe described by the request fields
+func (f *RecipeFields) Recipe() *Recipe {
+    return &Recipe{
+        Title:       f.Title,
+        Description: f.Description,
+        Ingredients: f.Ingredients,
+        Steps:       f.Steps,
+        PrepTime:    f.PrepTime,
+        CategoryID:  f.CategoryID,
+    }
+}
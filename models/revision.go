@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Revision is an immutable snapshot of a recipe as of one version
+type Revision struct {
+	RecipeID    int64     `json:"recipe_id"`
+	Version     int64     `json:"version"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Ingredients []string  `json:"ingredients"`
+	Steps       []string  `json:"steps"`
+	PrepTime    int       `json:"time"`
+	CategoryID  int64     `json:"category_id"`
+	Images      []string  `json:"images"`
+	EditorID    int64     `json:"editor_id"` // Zero once the editor's account is deleted
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RevisionDiff lists the fields that differ between two revisions of a
+// recipe
+type RevisionDiff struct {
+	RecipeID int64         `json:"recipe_id"`
+	From     int64         `json:"from"`
+	To       int64         `json:"to"`
+	Changes  []FieldChange `json:"changes"`
+}
+
+// FieldChange is the value of a field in two revisions. Fields are named
+// as in the recipe's JSON.
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
@@ -0,0 +1,147 @@
+// Package problem implements the error model of the API: RFC 7807 problem
+// details with a stable, machine-readable code.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"backend-app/service"
+	"backend-app/store"
+)
+
+// ContentType is the media type of problem responses
+const ContentType = "application/problem+json"
+
+// Code identifies a kind of problem. Codes are part of the API contract and
+// must not change once published.
+type Code string
+
+const (
+	CodeBadRequest           Code = "bad_request"
+	CodeValidationFailed     Code = "validation_failed"
+	CodeUnauthorized         Code = "unauthorized"
+	CodeForbidden            Code = "forbidden"
+	CodeNotFound             Code = "not_found"
+	CodeConflict             Code = "conflict"
+	CodeInvalidReference     Code = "invalid_reference"
+	CodePayloadTooLarge      Code = "payload_too_large"
+	CodeRateLimited          Code = "rate_limited"
+	CodePreconditionFailed   Code = "precondition_failed"
+	CodeInvalidTransition    Code = "invalid_transition"
+	CodePreconditionRequired Code = "precondition_required"
+	CodeInternal             Code = "internal_error"
+)
+
+// Problem is an RFC 7807 problem details object
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     Code         `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes why a single request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// New creates a problem with the given status, code and detail
+func New(status int, code Code, detail string) *Problem {
+	return &Problem{
+		Type:   "/problems/" + string(code),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// BadRequest reports a malformed request
+func BadRequest(detail string) *Problem {
+	return New(http.StatusBadRequest, CodeBadRequest, detail)
+}
+
+// Unauthorized reports missing or invalid credentials
+func Unauthorized(detail string) *Problem {
+	return New(http.StatusUnauthorized, CodeUnauthorized, detail)
+}
+
+// NotFound reports a missing resource
+func NotFound(detail string) *Problem {
+	return New(http.StatusNotFound, CodeNotFound, detail)
+}
+
+// Internal reports an unexpected server error. The cause is never exposed to
+// the client.
+func Internal() *Problem {
+	return New(http.StatusInternalServerError, CodeInternal, "")
+}
+
+// Validation reports every rejected field of a request at once
+func Validation(errs ...FieldError) *Problem {
+	p := New(http.StatusUnprocessableEntity, CodeValidationFailed, "request validation failed")
+	p.Errors = errs
+	return p
+}
+
+// FromError maps a store or service error to a problem. resource names the entity
+// the request operated on (e.g. "recipe") and is used in the detail message.
+// Errors without a known mapping become internal errors.
+func FromError(err error, resource string) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return NotFound(resource + " not found")
+	case errors.Is(err, store.ErrConflict):
+		return New(http.StatusConflict, CodeConflict, resource+" already exists")
+	case errors.Is(err, store.ErrStale):
+		return New(http.StatusPreconditionFailed, CodePreconditionFailed, resource+" has been modified")
+	case errors.Is(err, store.ErrInvalidReference):
+		return New(http.StatusUnprocessableEntity, CodeInvalidReference, resource+" references a record that does not exist")
+	case errors.Is(err, service.ErrForbidden):
+		return New(http.StatusForbidden, CodeForbidden, "you are not allowed to modify this "+resource)
+	case errors.Is(err, service.ErrInvalidCredentials):
+		return Unauthorized("invalid credentials")
+	case errors.Is(err, service.ErrInvalidTransition):
+		return New(http.StatusConflict, CodeInvalidTransition, resource+" cannot move from its current status to the requested one")
+	case errors.Is(err, service.ErrVersionRequired):
+		return New(http.StatusPreconditionRequired, CodePreconditionRequired,
+			resource+" has several editors; send If-Match with the version the change is based on")
+	case errors.Is(err, service.ErrAmbiguousUsername):
+		return New(http.StatusConflict, CodeConflict, "several users have this username; use their email instead")
+	case errors.Is(err, service.ErrSharedWithCreator):
+		return New(http.StatusConflict, CodeConflict, "the creator already owns this "+resource)
+	}
+
+	return Internal()
+}
+
+// Error implements the error interface so problems can be returned through
+// ordinary error paths
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return p.Title + ": " + p.Detail
+}
+
+// Write sends p as an application/problem+json response
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if p.Instance == "" && r != nil {
+		p.Instance = r.URL.Path
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,52 @@
+// Package publishing publishes draft recipes once their scheduled
+// publication time has come.
+package publishing
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RecipePublisher publishes the drafts scheduled at or before a time
+type RecipePublisher interface {
+	PublishDueRecipes(ctx context.Context, now time.Time) ([]int64, error)
+}
+
+// Scheduler publishes scheduled drafts as they fall due. Recipes are
+// published at most one interval late.
+type Scheduler struct {
+	Recipes RecipePublisher
+}
+
+// NewScheduler initializes a new Scheduler
+func NewScheduler(recipes RecipePublisher) *Scheduler {
+	return &Scheduler{Recipes: recipes}
+}
+
+// Publish performs a single pass and returns the IDs of the recipes it
+// published
+func (s *Scheduler) Publish(ctx context.Context) ([]int64, error) {
+	return s.Recipes.PublishDueRecipes(ctx, time.Now())
+}
+
+// Run publishes every interval until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			published, err := s.Publish(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "publishing scheduled recipes", "error", err)
+			}
+			if len(published) > 0 {
+				slog.InfoContext(ctx, "published scheduled recipes", "recipes", published)
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryStore forgets full buckets
+const sweepInterval = time.Minute
+
+// MemoryStore keeps buckets in memory, so each instance enforces its limits
+// on its own
+type MemoryStore struct {
+	// Now returns the current time; tests replace it
+	Now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	Bucket
+	fullAt time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		Now:     time.Now,
+		buckets: make(map[string]*memoryBucket),
+	}
+}
+
+// Take takes a token from the bucket for key
+func (s *MemoryStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	now := s.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{Bucket: NewBucket(policy, now)}
+		s.buckets[key] = b
+	}
+	result := b.Take(policy, now)
+	b.fullAt = b.FullAt(policy)
+	return result, nil
+}
+
+// Len returns the number of buckets held
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
+
+// sweep forgets the buckets that have refilled, so clients that stopped
+// sending requests do not hold memory
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if !b.fullAt.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// PostgresStore keeps buckets in the rate_limits table, so every instance
+// using the same database shares one limit per key
+type PostgresStore struct {
+	DB *sql.DB
+	// Now returns the current time; tests replace it
+	Now func() time.Time
+}
+
+// NewPostgresStore creates a PostgresStore on db
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{
+		DB:  db,
+		Now: time.Now,
+	}
+}
+
+// Take takes a token from the bucket for key. The bucket row is locked for
+// the duration of the update.
+func (s *PostgresStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	now := s.Now()
+	full := NewBucket(policy, now)
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return Result{}, fmt.Errorf("taking rate limit token: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO rate_limits (key, tokens, updated_at, full_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (key) DO NOTHING
+	`, key, full.Tokens, now)
+	if err != nil {
+		return Result{}, fmt.Errorf("taking rate limit token: %w", err)
+	}
+
+	var b Bucket
+	err = tx.QueryRowContext(ctx, `
+		SELECT tokens, updated_at
+		FROM rate_limits
+		WHERE key = $1
+		FOR UPDATE
+	`, key).Scan(&b.Tokens, &b.Updated)
+	if err != nil {
+		return Result{}, fmt.Errorf("taking rate limit token: %w", err)
+	}
+
+	result := b.Take(policy, now)
+	_, err = tx.ExecContext(ctx, `
+		UPDATE rate_limits
+		SET tokens = $2, updated_at = $3, full_at = $4
+		WHERE key = $1
+	`, key, b.Tokens, b.Updated, b.FullAt(policy))
+	if err != nil {
+		return Result{}, fmt.Errorf("taking rate limit token: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return Result{}, fmt.Errorf("taking rate limit token: %w", err)
+	}
+	return result, nil
+}
+
+// Purge deletes the buckets that have refilled and returns how many it
+// deleted
+func (s *PostgresStore) Purge(ctx context.Context) (int64, error) {
+	result, err := s.DB.ExecContext(ctx, `DELETE FROM rate_limits WHERE full_at <= $1`, s.Now())
+	if err != nil {
+		return 0, fmt.Errorf("purging rate limits: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Run purges refilled buckets every interval until ctx is done
+func (s *PostgresStore) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Purge(ctx); err != nil {
+				slog.ErrorContext(ctx, "purging rate limits", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Package ratelimit implements token bucket rate limiting. Buckets are kept
+// in a Store: MemoryStore limits each instance on its own, while a shared
+// store such as PostgresStore enforces one limit across instances.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Policy allows Limit requests per Period on average, with bursts of up to
+// Burst requests. The zero Policy allows everything.
+type Policy struct {
+	Limit  int
+	Period time.Duration
+	// Burst is the capacity of the bucket, Limit if zero
+	Burst int
+}
+
+// Enabled reports whether the policy limits anything
+func (p Policy) Enabled() bool {
+	return p.Limit > 0 && p.Period > 0
+}
+
+// Capacity is the number of requests a full bucket allows at once
+func (p Policy) Capacity() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Limit
+}
+
+// interval is the time it takes to refill one token
+func (p Policy) interval() time.Duration {
+	return p.Period / time.Duration(p.Limit)
+}
+
+// Result describes the outcome of taking a token
+type Result struct {
+	Allowed bool
+	// Remaining is the number of requests the bucket allows right now
+	Remaining int
+	// RetryAfter is how long to wait for the next token if the request was
+	// denied
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// Store keeps one bucket per key. Take must be atomic for a key, so that
+// concurrent requests cannot spend the same token.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// Bucket is the state of a token bucket. Stores persist it between calls
+// and use Take to update it.
+type Bucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+// NewBucket returns a full bucket for policy
+func NewBucket(policy Policy, now time.Time) Bucket {
+	return Bucket{Tokens: float64(policy.Capacity()), Updated: now}
+}
+
+// Take refills the bucket for the time passed since it was last updated
+// and takes a token if one is available
+func (b *Bucket) Take(policy Policy, now time.Time) Result {
+	capacity := float64(policy.Capacity())
+	interval := policy.interval()
+
+	if elapsed := now.Sub(b.Updated); elapsed > 0 {
+		b.Tokens = math.Min(capacity, b.Tokens+float64(elapsed)/float64(interval))
+	}
+	b.Updated = now
+
+	var result Result
+	if b.Tokens >= 1 {
+		b.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.Tokens) * float64(interval))
+	}
+	result.Remaining = int(b.Tokens)
+	result.Reset = b.FullAt(policy).Sub(now)
+	return result
+}
+
+// FullAt returns when the bucket will be full again. A bucket that is full
+// can be forgotten, since a new bucket is equivalent.
+func (b *Bucket) FullAt(policy Policy) time.Time {
+	missing := float64(policy.Capacity()) - b.Tokens
+	return b.Updated.Add(time.Duration(missing * float64(policy.interval())))
+}
@@ -0,0 +1,158 @@
+package ratelimit_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"backend-app/ratelimit"
+	"backend-app/store/pgtest"
+)
+
+func TestMain(m *testing.M) {
+	pgtest.Main(m)
+}
+
+// clock is a manually advanced time source
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func TestBucket(t *testing.T) {
+	policy := ratelimit.Policy{Limit: 2, Period: time.Second, Burst: 3}
+	now := time.Unix(1700000000, 0)
+	b := ratelimit.NewBucket(policy, now)
+
+	for i := 0; i < 3; i++ {
+		if result := b.Take(policy, now); !result.Allowed || result.Remaining != 2-i {
+			t.Fatalf("take %d = %+v, want allowed with %d remaining", i, result, 2-i)
+		}
+	}
+	result := b.Take(policy, now)
+	if result.Allowed {
+		t.Fatal("take beyond the burst was allowed")
+	}
+	if result.RetryAfter != 500*time.Millisecond {
+		t.Errorf("RetryAfter = %s, want one refill interval", result.RetryAfter)
+	}
+	if result.Reset != 1500*time.Millisecond {
+		t.Errorf("Reset = %s, want the time to refill 3 tokens", result.Reset)
+	}
+
+	// Tokens refill at Limit per Period but never beyond the burst
+	if result := b.Take(policy, now.Add(500*time.Millisecond)); !result.Allowed {
+		t.Error("take after one refill interval was denied")
+	}
+	if result := b.Take(policy, now.Add(time.Hour)); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("take after an hour = %+v, want allowed with 2 remaining", result)
+	}
+}
+
+// testStore checks the behavior every Store must have
+func testStore(t *testing.T, s ratelimit.Store, c *clock) {
+	ctx := context.Background()
+	policy := ratelimit.Policy{Limit: 2, Period: time.Minute}
+
+	for i := 0; i < 2; i++ {
+		if result, err := s.Take(ctx, "login ip:10.0.0.1", policy); err != nil || !result.Allowed {
+			t.Fatalf("take %d = %+v, %v, want allowed", i, result, err)
+		}
+	}
+	result, err := s.Take(ctx, "login ip:10.0.0.1", policy)
+	if err != nil || result.Allowed {
+		t.Fatalf("third take = %+v, %v, want denied", result, err)
+	}
+	if result.RetryAfter <= 0 || result.RetryAfter > 30*time.Second {
+		t.Errorf("RetryAfter = %s, want at most one refill interval", result.RetryAfter)
+	}
+
+	// Keys have separate buckets
+	if result, err := s.Take(ctx, "login ip:10.0.0.2", policy); err != nil || !result.Allowed {
+		t.Errorf("take for another key = %+v, %v, want allowed", result, err)
+	}
+
+	c.Advance(30 * time.Second)
+	if result, err := s.Take(ctx, "login ip:10.0.0.1", policy); err != nil || !result.Allowed {
+		t.Errorf("take after a refill = %+v, %v, want allowed", result, err)
+	}
+
+	// Concurrent takes cannot spend the same token
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := s.Take(ctx, "create user:7", policy)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if result.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != 2 {
+		t.Errorf("%d concurrent takes allowed, want 2", allowed)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	s := ratelimit.NewMemoryStore()
+	s.Now = c.Now
+	testStore(t, s, c)
+}
+
+func TestMemoryStoreForgetsFullBuckets(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	s := ratelimit.NewMemoryStore()
+	s.Now = c.Now
+	policy := ratelimit.Policy{Limit: 10, Period: time.Minute}
+
+	for _, key := range []string{"a", "b", "c"} {
+		s.Take(context.Background(), key, policy)
+	}
+	c.Advance(2 * time.Minute)
+	s.Take(context.Background(), "d", policy)
+
+	if got := s.Len(); got != 1 {
+		t.Errorf("%d buckets held, want only the new one", got)
+	}
+}
+
+func TestPostgresStore(t *testing.T) {
+	db := pgtest.NewDB(t)
+	c := &clock{now: time.Unix(1700000000, 0)}
+	s := ratelimit.NewPostgresStore(db)
+	s.Now = c.Now
+	testStore(t, s, c)
+
+	c.Advance(time.Hour)
+	purged, err := s.Purge(context.Background())
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if purged != 3 {
+		t.Errorf("purged %d buckets, want 3", purged)
+	}
+}
@@ -6,42 +6,70 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
-	"github.com/gin-gonic/gin" // Import the gin package
 	"backend-app/controllers"
-	"backend-app/middleware" // Import the package that contains AuthMiddleware
+	"backend-app/health"
+	"backend-app/middleware"
 )
 
 // RegisterRoutes registers all routes for the application
 func RegisterRoutes(router *mux.Router, authController *controllers.AuthController,
-	userController *controllers.UserController, recipeController *controllers.RecipeController) {
+	userController *controllers.UserController, recipeController *controllers.RecipeController,
+	categoryController *controllers.CategoryController, auth *middleware.AuthMiddleware) {
 
 	// Auth routes
-router.HandleFunc("/signup", func(w http.ResponseWriter, r *http.Request) {
-	ctx := &gin.Context{
-		Request: r,
-		Writer:  gin.ResponseWriter(w.(http.ResponseWriter)),
-	}
-	authController.SignUp(ctx)
-}).Methods("POST")
-router.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
-	ctx := &gin.Context{
-		Request: r,
-		Writer:  w,
-	}
-	authController.Login(ctx)
-}).Methods("POST")
-
+	router.HandleFunc("/signup", authController.SignUp).Methods("POST")
+	router.HandleFunc("/login", authController.Login).Methods("POST")
+	router.HandleFunc("/logout", auth.Require(authController.Logout)).Methods("POST")
 
 	// User routes
 	router.HandleFunc("/user", userController.GetUser).Methods("GET")
-	router.HandleFunc("/user/update", middleware.AuthMiddleware(userController.UpdateUser)).Methods("PUT")
-	router.HandleFunc("/user/delete", middleware.AuthMiddleware(userController.DeleteUser)).Methods("DELETE")
+	router.HandleFunc("/user/update", auth.Require(userController.UpdateUser)).Methods("PUT")
+	router.HandleFunc("/user/delete", auth.Require(userController.DeleteUser)).Methods("DELETE")
+	router.HandleFunc("/user/restore", userController.RestoreUser).Methods("POST")
 
 	// Recipe routes
-	router.HandleFunc("/recipe/create", middleware.AuthMiddleware(recipeController.CreateRecipe)).Methods("POST")
-	router.HandleFunc("/recipe/update", middleware.AuthMiddleware(recipeController.UpdateRecipe)).Methods("PUT")
-	router.HandleFunc("/recipe/delete", middleware.AuthMiddleware(recipeController.DeleteRecipe)).Methods("DELETE")
+	router.HandleFunc("/recipe", auth.Optional(recipeController.GetRecipe)).Methods("GET")
+	router.HandleFunc("/recipes", auth.Optional(recipeController.GetAllRecipes)).Methods("GET")
+	router.HandleFunc("/recipe/create", auth.Require(recipeController.CreateRecipe)).Methods("POST")
+	router.HandleFunc("/recipe/update", auth.Require(recipeController.UpdateRecipe)).Methods("PUT")
+	router.HandleFunc("/recipe/delete", auth.Require(recipeController.DeleteRecipe)).Methods("DELETE")
+	router.HandleFunc("/recipe/revisions", auth.Optional(recipeController.ListRevisions)).Methods("GET")
+	router.HandleFunc("/recipe/revisions/diff", auth.Optional(recipeController.DiffRevisions)).Methods("GET")
+	router.HandleFunc("/recipe/revision", auth.Optional(recipeController.GetRevision)).Methods("GET")
+	router.HandleFunc("/recipe/restore", auth.Require(recipeController.RestoreRevision)).Methods("POST")
+	router.HandleFunc("/recipes/{id}/fork", auth.Require(recipeController.ForkRecipe)).Methods("POST")
+	router.HandleFunc("/recipes/{id}/lineage", auth.Optional(recipeController.GetLineage)).Methods("GET")
+	router.HandleFunc("/recipes/{id}/status", auth.Require(recipeController.SetRecipeStatus)).Methods("PUT")
+	router.HandleFunc("/recipes/{id}/visibility", auth.Require(recipeController.SetRecipeVisibility)).Methods("PUT")
+	router.HandleFunc("/recipes/{id}/shares", auth.Require(recipeController.ListShares)).Methods("GET")
+	router.HandleFunc("/recipes/{id}/shares", auth.Require(recipeController.ShareRecipe)).Methods("PUT")
+	router.HandleFunc("/recipes/{id}/shares", auth.Require(recipeController.UnshareRecipe)).Methods("DELETE")
+	router.HandleFunc("/shared/{token}", recipeController.GetSharedRecipe).Methods("GET")
+	router.HandleFunc("/recipes/{id}/authors", auth.Optional(recipeController.ListAuthors)).Methods("GET")
+	router.HandleFunc("/recipes/{id}/authors", auth.Require(recipeController.RemoveAuthor)).Methods("DELETE")
+	router.HandleFunc("/recipes/{id}/invitations", auth.Require(recipeController.InviteAuthor)).Methods("POST")
+
+	// Invitation routes
+	router.HandleFunc("/invitations", auth.Require(recipeController.ListInvitations)).Methods("GET")
+	router.HandleFunc("/invitations/{id}/accept", auth.Require(recipeController.AcceptInvitation)).Methods("POST")
+	router.HandleFunc("/invitations/{id}/decline", auth.Require(recipeController.DeclineInvitation)).Methods("POST")
+
+	// Trash routes
+	router.HandleFunc("/trash", auth.Require(recipeController.Trash)).Methods("GET")
+	router.HandleFunc("/trash/restore", auth.Require(recipeController.RestoreRecipe)).Methods("POST")
+
+	// Category routes
+	router.HandleFunc("/categories", auth.Optional(categoryController.GetAllCategories)).Methods("GET")
+	router.HandleFunc("/category/create", auth.Require(categoryController.CreateCategory)).Methods("POST")
+	router.HandleFunc("/category/update", auth.Require(categoryController.UpdateCategory)).Methods("PUT")
+	router.HandleFunc("/category/delete", auth.Require(categoryController.DeleteCategory)).Methods("DELETE")
 
 	// Serve static files (images)
 	router.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("./static/"))))
 }
+
+// RegisterHealthRoutes registers the liveness and readiness endpoints
+func RegisterHealthRoutes(router *mux.Router, h *health.Handler) {
+	router.HandleFunc("/healthz", h.Live).Methods("GET")
+	router.HandleFunc("/readyz", h.Ready).Methods("GET")
+}
@@ -0,0 +1,47 @@
+// Package server runs the HTTP server and shuts it down gracefully.
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Serve serves srv on ln until ctx is cancelled, then stops accepting
+// connections and waits up to shutdownTimeout for in-flight requests to
+// finish. Connections still open after the deadline are closed. If
+// srv.TLSConfig is set, ln is served over TLS.
+func Serve(ctx context.Context, srv *http.Server, ln net.Listener, shutdownTimeout time.Duration) error {
+	errc := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			errc <- srv.ServeTLS(ln, "", "")
+		} else {
+			errc <- srv.Serve(ln)
+		}
+	}()
+
+	select {
+	case err := <-errc:
+		// The server failed before shutdown was requested
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down, waiting for in-flight requests", "timeout", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := srv.Shutdown(shutdownCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		slog.Warn("shutdown deadline exceeded, closing remaining connections")
+		srv.Close()
+	}
+	if serveErr := <-errc; !errors.Is(serveErr, http.ErrServerClosed) {
+		return serveErr
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"crypto/tls"
+	"sync"
+)
+
+// CertReloader serves a TLS certificate that can be reloaded from disk
+// without restarting the server
+type CertReloader struct {
+	CertFile string
+	KeyFile  string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// NewCertReloader loads the certificate and key pair from disk
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	cr := &CertReloader{
+		CertFile: certFile,
+		KeyFile:  keyFile,
+	}
+	if err := cr.Reload(); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// Reload reads the certificate and key pair again. On error the current
+// certificate stays in use.
+func (cr *CertReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(cr.CertFile, cr.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.cert = &cert
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (cr *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+	return cr.cert, nil
+}
+
+// TLSConfig returns a TLS configuration serving the reloadable certificate
+func (cr *CertReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: cr.GetCertificate,
+	}
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"backend-app/models"
+	"backend-app/store"
+)
+
+// InviteAuthor invites a user, named by username or email, to become an
+// author of a recipe with the requested role. Only its owners may invite
+// authors. The invitee must have an account; it fails with store.ErrConflict
+// if they are already an author or invited.
+func (rs *RecipeService) InviteAuthor(ctx context.Context, actorID int64, req *models.InviteAuthorRequest) (*models.Invitation, error) {
+	recipe, err := rs.ownedRecipe(ctx, actorID, req.RecipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var invitee *models.User
+	if req.Email != "" {
+		invitee, err = rs.Users.GetUserByEmail(ctx, req.Email)
+	} else {
+		invitee, err = rs.Users.GetUserByUsername(ctx, req.Username)
+	}
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return nil, fmt.Errorf("inviting author: %w", store.ErrInvalidReference)
+	case errors.Is(err, store.ErrConflict):
+		return nil, ErrAmbiguousUsername
+	case err != nil:
+		return nil, err
+	}
+	if int64(invitee.ID) == recipe.CreatorID {
+		return nil, fmt.Errorf("inviting the creator: %w", store.ErrConflict)
+	}
+
+	invitation := &models.Invitation{
+		RecipeID:  recipe.ID,
+		InviteeID: int64(invitee.ID),
+		InvitedBy: actorID,
+		Role:      req.Role,
+	}
+	if err := rs.Recipes.InviteAuthor(ctx, invitation); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// ListInvitations lists the acting user's pending invitations
+func (rs *RecipeService) ListInvitations(ctx context.Context, actorID int64) ([]*models.Invitation, error) {
+	return rs.Recipes.ListInvitations(ctx, actorID)
+}
+
+// AcceptInvitation makes the acting user an author of the recipe they were
+// invited to. Other users' invitations are not found.
+func (rs *RecipeService) AcceptInvitation(ctx context.Context, actorID, invitationID int64) (*models.RecipeAuthor, error) {
+	invitation, err := rs.Recipes.GetInvitation(store.WithPrimary(ctx), invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.InviteeID != actorID {
+		return nil, fmt.Errorf("retrieving invitation: %w", store.ErrNotFound)
+	}
+	return rs.Recipes.AcceptInvitation(ctx, invitationID)
+}
+
+// DeclineInvitation removes a pending invitation. The invitee declines it
+// this way, and the recipe's owners withdraw it; it is not found for
+// anyone else.
+func (rs *RecipeService) DeclineInvitation(ctx context.Context, actorID, invitationID int64) error {
+	invitation, err := rs.Recipes.GetInvitation(store.WithPrimary(ctx), invitationID)
+	if err != nil {
+		return err
+	}
+	if invitation.InviteeID != actorID {
+		_, err := rs.ownedRecipe(ctx, actorID, invitation.RecipeID)
+		if errors.Is(err, ErrForbidden) || errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("retrieving invitation: %w", store.ErrNotFound)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return rs.Recipes.DeleteInvitation(ctx, invitationID)
+}
+
+// ListAuthors lists the authors of a recipe the viewer may read: its
+// creator, as an owner, followed by its co-authors
+func (rs *RecipeService) ListAuthors(ctx context.Context, viewerID, recipeID int64) ([]*models.RecipeAuthor, error) {
+	recipe, err := rs.visibleRecipe(ctx, viewerID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	coAuthors, err := rs.Recipes.ListAuthors(ctx, recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The creator became an author with the first version
+	creator := &models.RecipeAuthor{
+		RecipeID: recipe.ID,
+		UserID:   recipe.CreatorID,
+		Role:     models.RoleOwner,
+	}
+	first, err := rs.Recipes.GetRevision(ctx, recipe.ID, 1)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	if first != nil {
+		creator.CreatedAt = first.CreatedAt
+	}
+	return append([]*models.RecipeAuthor{creator}, coAuthors...), nil
+}
+
+// RemoveAuthor removes a co-author from a recipe. Owners may remove any
+// co-author and co-authors may leave; the creator cannot be removed.
+func (rs *RecipeService) RemoveAuthor(ctx context.Context, actorID, recipeID, userID int64) error {
+	var recipe *models.Recipe
+	var err error
+	if userID == actorID {
+		recipe, err = rs.visibleRecipe(store.WithPrimary(ctx), actorID, recipeID)
+	} else {
+		recipe, err = rs.ownedRecipe(ctx, actorID, recipeID)
+	}
+	if err != nil {
+		return err
+	}
+	if userID == recipe.CreatorID {
+		return ErrForbidden
+	}
+	return rs.Recipes.RemoveAuthor(ctx, recipeID, userID)
+}
+
+// role returns the role of a user among a recipe's authors, or nothing if
+// they are not one
+func (rs *RecipeService) role(ctx context.Context, recipe *models.Recipe, userID int64) (string, error) {
+	if userID == 0 {
+		return "", nil
+	}
+	if recipe.CreatorID == userID {
+		return models.RoleOwner, nil
+	}
+	author, err := rs.Recipes.GetAuthor(ctx, recipe.ID, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return author.Role, nil
+}
+
+// roles returns the role of a user among the authors of each of recipes,
+// by recipe ID. It reads the user's co-authorships at most once, however
+// many recipes there are.
+func (rs *RecipeService) roles(ctx context.Context, recipes []*models.Recipe, userID int64) (map[int64]string, error) {
+	roles := make(map[int64]string)
+	if userID == 0 {
+		return roles, nil
+	}
+	coAuthored := false
+	for _, recipe := range recipes {
+		if recipe.CreatorID == userID {
+			roles[recipe.ID] = models.RoleOwner
+		} else {
+			coAuthored = true
+		}
+	}
+	if !coAuthored {
+		return roles, nil
+	}
+	authorships, err := rs.Recipes.ListAuthorships(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, author := range authorships {
+		if _, ok := roles[author.RecipeID]; !ok {
+			roles[author.RecipeID] = author.Role
+		}
+	}
+	return roles, nil
+}
+
+// collaborative reports whether anyone besides its creator may edit a
+// recipe
+func (rs *RecipeService) collaborative(ctx context.Context, recipe *models.Recipe) (bool, error) {
+	authors, err := rs.Recipes.ListAuthors(ctx, recipe.ID)
+	if err != nil || len(authors) > 0 {
+		return len(authors) > 0, err
+	}
+	shares, err := rs.Recipes.ListShares(ctx, recipe.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, share := range shares {
+		if share.Permission == models.PermissionEdit {
+			return true, nil
+		}
+	}
+	return false, nil
+}
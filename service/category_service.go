@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+
+	"backend-app/models"
+	"backend-app/store"
+)
+
+type CategoryService struct {
+	Categories store.CategoryStore
+}
+
+// NewCategoryService initializes a new CategoryService
+func NewCategoryService(categories store.CategoryStore) *CategoryService {
+	return &CategoryService{
+		Categories: categories,
+	}
+}
+
+// CreateCategory creates a new recipe category
+func (cs *CategoryService) CreateCategory(ctx context.Context, req *models.CreateCategoryRequest) (*models.Category, error) {
+	category := &models.Category{Name: req.Name}
+	if err := cs.Categories.CreateCategory(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// UpdateCategory renames a recipe category
+func (cs *CategoryService) UpdateCategory(ctx context.Context, req *models.UpdateCategoryRequest) (*models.Category, error) {
+	category := &models.Category{ID: req.ID, Name: req.Name}
+	if err := cs.Categories.UpdateCategory(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// DeleteCategory deletes a recipe category. Its recipes are moved to
+// reassignTo; without one, the category may only be deleted when no live
+// recipe belongs to it, and deleted recipes become uncategorized.
+func (cs *CategoryService) DeleteCategory(ctx context.Context, categoryID, reassignTo int64) error {
+	_, err := cs.Categories.DeleteCategory(ctx, categoryID, reassignTo)
+	return err
+}
+
+// GetAllCategories retrieves all recipe categories
+func (cs *CategoryService) GetAllCategories(ctx context.Context) ([]*models.Category, error) {
+	return cs.Categories.GetAllCategories(ctx)
+}
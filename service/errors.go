@@ -0,0 +1,27 @@
+// Package service holds the business rules of the application. Controllers
+// translate HTTP requests into service calls, and services use the stores
+// for persistence.
+package service
+
+import "errors"
+
+var (
+	// ErrForbidden is returned when the acting user may not perform an
+	// operation, e.g. editing someone else's recipe
+	ErrForbidden = errors.New("operation not allowed")
+	// ErrInvalidCredentials is returned when a login does not match a user
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrInvalidTransition is returned when a recipe cannot move from its
+	// current status to the requested one, e.g. an archived recipe back to
+	// a draft
+	ErrInvalidTransition = errors.New("invalid status transition")
+	// ErrVersionRequired is returned when a recipe that several users may
+	// edit is updated without the version the change is based on
+	ErrVersionRequired = errors.New("expected version required")
+	// ErrAmbiguousUsername is returned when a user named by username cannot
+	// be told apart from others with the same username
+	ErrAmbiguousUsername = errors.New("ambiguous username")
+	// ErrSharedWithCreator is returned when a recipe is shared with its
+	// creator, who already owns it
+	ErrSharedWithCreator = errors.New("recipe shared with its creator")
+)
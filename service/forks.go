@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"backend-app/models"
+	"backend-app/store"
+)
+
+// maxLineageDepth bounds how many ancestors Lineage follows
+const maxLineageDepth = 100
+
+// ForkRecipe copies the current content of a recipe the acting user may
+// read, images included, into a new draft owned by the acting user that is
+// attributed to the original
+func (rs *RecipeService) ForkRecipe(ctx context.Context, actorID, recipeID int64) (*models.Recipe, error) {
+	parent, err := rs.visibleRecipe(store.WithPrimary(ctx), actorID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The fork shares the parent's image blobs; they stay in use while
+	// either recipe references them
+	fork := &models.Recipe{
+		Title:       parent.Title,
+		Description: parent.Description,
+		Ingredients: parent.Ingredients,
+		Steps:       parent.Steps,
+		PrepTime:    parent.PrepTime,
+		CategoryID:  parent.CategoryID,
+		CreatorID:   actorID,
+		Images:      parent.Images,
+		Status:      models.StatusDraft,
+		Visibility:  models.VisibilityPublic,
+		ForkedFrom: &models.ForkOrigin{
+			RecipeID:  parent.ID,
+			Version:   parent.Version,
+			CreatorID: parent.CreatorID,
+		},
+	}
+	return rs.Recipes.CreateRecipe(ctx, fork)
+}
+
+// Lineage lists the recipes a recipe was forked from and the forks made of
+// it, as far as the viewer may see them. Ancestry stops at the first deleted
+// ancestor, which is listed with the attribution its fork kept, or at the
+// first ancestor the viewer may not read, which is listed the same way
+// without being marked deleted.
+func (rs *RecipeService) Lineage(ctx context.Context, viewerID, recipeID int64) (*models.Lineage, error) {
+	recipe, err := rs.visibleRecipe(ctx, viewerID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	lineage := &models.Lineage{
+		RecipeID:  recipe.ID,
+		Ancestors: []models.LineageEntry{},
+		Forks:     []models.LineageEntry{},
+	}
+	for origin := recipe.ForkedFrom; origin != nil && len(lineage.Ancestors) < maxLineageDepth; {
+		ancestor, err := rs.Recipes.GetRecipeByID(ctx, origin.RecipeID)
+		if errors.Is(err, store.ErrNotFound) {
+			lineage.Ancestors = append(lineage.Ancestors, models.LineageEntry{
+				RecipeID:  origin.RecipeID,
+				CreatorID: origin.CreatorID,
+				Deleted:   true,
+			})
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		permission, err := rs.permission(ctx, ancestor, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if permission == "" {
+			lineage.Ancestors = append(lineage.Ancestors, models.LineageEntry{
+				RecipeID:  origin.RecipeID,
+				CreatorID: origin.CreatorID,
+			})
+			break
+		}
+		lineage.Ancestors = append(lineage.Ancestors, lineageEntry(ancestor))
+		origin = ancestor.ForkedFrom
+	}
+
+	forks, err := rs.Recipes.ListForks(ctx, recipe.ID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, fork := range forks {
+		lineage.Forks = append(lineage.Forks, lineageEntry(fork))
+	}
+	lineage.ForkCount = len(forks)
+	return lineage, nil
+}
+
+func lineageEntry(recipe *models.Recipe) models.LineageEntry {
+	return models.LineageEntry{
+		RecipeID:  recipe.ID,
+		Title:     recipe.Title,
+		CreatorID: recipe.CreatorID,
+	}
+}
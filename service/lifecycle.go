@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"backend-app/models"
+	"backend-app/store"
+)
+
+// transitions lists the statuses a recipe may move to from each status.
+// Moving a draft to draft again changes or cancels its scheduled
+// publication.
+var transitions = map[string][]string{
+	models.StatusDraft:     {models.StatusDraft, models.StatusPublished},
+	models.StatusPublished: {models.StatusArchived},
+	models.StatusArchived:  {models.StatusPublished},
+}
+
+// SetRecipeStatus moves a recipe through its lifecycle. Only its owners may
+// do so. Publishing a draft with a future PublishAt schedules it instead;
+// it fails with ErrInvalidTransition if the recipe cannot move to the
+// requested status.
+func (rs *RecipeService) SetRecipeStatus(ctx context.Context, actorID int64, req *models.RecipeStatusRequest) (*models.Recipe, error) {
+	recipe, err := rs.ownedRecipe(ctx, actorID, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed(recipe.Status, req.Status) {
+		return nil, ErrInvalidTransition
+	}
+
+	status, publishAt := scheduledStatus(req.Status, req.PublishAt, time.Now())
+	if status != req.Status && recipe.Status != models.StatusDraft {
+		// Only drafts wait to be published
+		return nil, ErrInvalidTransition
+	}
+	recipe.Status = status
+	recipe.PublishAt = publishAt
+	recipe.UpdatedBy = actorID
+	if req.ExpectedVersion != 0 {
+		recipe.Version = req.ExpectedVersion
+	}
+	if err := rs.Recipes.SetRecipeStatus(ctx, recipe); err != nil {
+		return nil, err
+	}
+	return recipe, nil
+}
+
+// allowed reports whether a recipe may move from one status to another
+func allowed(from, to string) bool {
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduledStatus resolves a requested status into the status to store and
+// the scheduled publication time, if any. A publication requested for a
+// time after now leaves the recipe a draft until then; the time is ignored
+// otherwise.
+func scheduledStatus(status string, publishAt *time.Time, now time.Time) (string, *time.Time) {
+	if status == models.StatusPublished && publishAt != nil && publishAt.After(now) {
+		return models.StatusDraft, publishAt
+	}
+	return status, nil
+}
+
+// visibleRecipe loads a recipe the viewer may read. Recipes the viewer may
+// not read are hidden as if they did not exist.
+func (rs *RecipeService) visibleRecipe(ctx context.Context, viewerID, recipeID int64) (*models.Recipe, error) {
+	recipe, err := rs.Recipes.GetRecipeByID(ctx, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	permission, err := rs.permission(ctx, recipe, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if permission == "" {
+		return nil, fmt.Errorf("retrieving recipe: %w", store.ErrNotFound)
+	}
+	return recipe, nil
+}
@@ -0,0 +1,15 @@
+package service
+
+// Login attempt results reported to Observer.LoginAttempt
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure" // invalid credentials
+	LoginError   = "error"   // the attempt could not be checked
+)
+
+// Observer is told about events worth measuring. Services accept a nil
+// Observer.
+type Observer interface {
+	UploadedBytes(n int64)
+	LoginAttempt(result string)
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"mime/multipart"
+	"time"
+
+	"backend-app/media"
+	"backend-app/models"
+	"backend-app/store"
+)
+
+type RecipeService struct {
+	Recipes   store.RecipeStore
+	Users     store.UserStore // Resolves invited authors, needed for invitations
+	Media     store.MediaStore
+	Blobs     *media.Store
+	Observer  Observer      // Told about uploaded bytes, may be nil
+	Retention time.Duration // How long deleted recipes can be restored
+}
+
+// NewRecipeService initializes a new RecipeService
+func NewRecipeService(recipes store.RecipeStore, mediaStore store.MediaStore, blobs *media.Store) *RecipeService {
+	return &RecipeService{
+		Recipes:   recipes,
+		Media:     mediaStore,
+		Blobs:     blobs,
+		Retention: DefaultRetention,
+	}
+}
+
+// CreateRecipe assembles a recipe owned by the acting user from the request
+// and its uploaded images, and stores it. The recipe is a draft unless the
+// request publishes it, and public unless the request says otherwise.
+func (rs *RecipeService) CreateRecipe(ctx context.Context, actorID int64, req *models.CreateRecipeRequest) (*models.Recipe, error) {
+	recipe := req.Recipe()
+	recipe.CreatorID = actorID
+	recipe.Status = models.StatusDraft
+	if req.Status != "" {
+		recipe.Status, recipe.PublishAt = scheduledStatus(req.Status, req.PublishAt, time.Now())
+	}
+	recipe.Visibility = models.VisibilityPublic
+	if req.Visibility != "" {
+		recipe.Visibility = req.Visibility
+	}
+	if recipe.Visibility == models.VisibilityUnlisted {
+		token, err := generateShareToken()
+		if err != nil {
+			return nil, err
+		}
+		recipe.ShareToken = token
+	}
+
+	images, err := rs.saveImages(ctx, req.Images)
+	if err != nil {
+		return nil, err
+	}
+	recipe.Images = images
+
+	return rs.Recipes.CreateRecipe(ctx, recipe)
+}
+
+// UpdateRecipe replaces the content of a recipe. Only its authors and users
+// it is shared with for editing may edit it; the uploaded images replace the
+// previous ones. The update fails with store.ErrStale if the recipe changed
+// after the version the request expects.
+func (rs *RecipeService) UpdateRecipe(ctx context.Context, actorID int64, req *models.UpdateRecipeRequest) (*models.Recipe, error) {
+	existing, err := rs.editableRecipe(ctx, actorID, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipe := req.Recipe()
+	images, err := rs.saveImages(ctx, req.Images)
+	if err != nil {
+		return nil, err
+	}
+	recipe.Images = images
+
+	return rs.save(ctx, actorID, existing, recipe, req.ExpectedVersion)
+}
+
+// save stores recipe as the next version of existing, edited by actorID.
+// A non-zero expectedVersion must be the current version. Recipes that
+// others may edit too require one, so that concurrent edits are detected
+// rather than overwriting each other; it fails with ErrVersionRequired
+// otherwise.
+func (rs *RecipeService) save(ctx context.Context, actorID int64, existing, recipe *models.Recipe, expectedVersion int64) (*models.Recipe, error) {
+	recipe.ID = existing.ID
+	recipe.CreatorID = existing.CreatorID
+	recipe.ForkedFrom = existing.ForkedFrom
+	recipe.Status = existing.Status
+	recipe.PublishAt = existing.PublishAt
+	recipe.PublishedAt = existing.PublishedAt
+	recipe.Visibility = existing.Visibility
+	recipe.ShareToken = existing.ShareToken
+	recipe.UpdatedBy = actorID
+
+	// The store checks the expected version, since the recipe may have
+	// changed since it was loaded
+	if expectedVersion != 0 {
+		recipe.Version = expectedVersion
+		if err := rs.Recipes.UpdateRecipe(ctx, recipe); err != nil {
+			return nil, err
+		}
+		return rs.redacted(ctx, recipe, actorID)
+	}
+
+	collaborative, err := rs.collaborative(store.WithPrimary(ctx), existing)
+	if err != nil {
+		return nil, err
+	}
+	if collaborative {
+		return nil, ErrVersionRequired
+	}
+
+	// An unconditional update applies to whatever version is current, so
+	// it is retried once if the loaded one turns out to be stale
+	recipe.Version = existing.Version
+	err = rs.Recipes.UpdateRecipe(ctx, recipe)
+	if errors.Is(err, store.ErrStale) {
+		if existing, err = rs.editableRecipe(ctx, actorID, recipe.ID); err != nil {
+			return nil, err
+		}
+		recipe.Version = existing.Version
+		err = rs.Recipes.UpdateRecipe(ctx, recipe)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rs.redacted(ctx, recipe, actorID)
+}
+
+// DeleteRecipe moves a recipe to its creator's trash. Only its owners may
+// delete it.
+func (rs *RecipeService) DeleteRecipe(ctx context.Context, actorID, recipeID int64) error {
+	if _, err := rs.ownedRecipe(ctx, actorID, recipeID); err != nil {
+		return err
+	}
+	return rs.Recipes.DeleteRecipe(ctx, recipeID, actorID)
+}
+
+// GetRecipe retrieves a recipe the viewer may read by ID
+func (rs *RecipeService) GetRecipe(ctx context.Context, viewerID, recipeID int64) (*models.Recipe, error) {
+	recipe, err := rs.visibleRecipe(ctx, viewerID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	return rs.redacted(ctx, recipe, viewerID)
+}
+
+// GetAllRecipes retrieves the published public recipes, the published
+// recipes shared with the viewer and the recipes the viewer authors
+func (rs *RecipeService) GetAllRecipes(ctx context.Context, viewerID int64) ([]*models.Recipe, error) {
+	recipes, err := rs.Recipes.GetAllRecipes(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	// Only share links depend on the viewer's role
+	var shared []*models.Recipe
+	for _, recipe := range recipes {
+		if recipe.ShareToken != "" {
+			shared = append(shared, recipe)
+		}
+	}
+	roles, err := rs.roles(ctx, shared, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	for i, recipe := range recipes {
+		recipes[i] = redact(recipe, roles[recipe.ID])
+	}
+	return recipes, nil
+}
+
+// ownedRecipe loads a recipe and checks that the acting user is one of its
+// owners. Recipes the acting user may not read are not found rather than
+// forbidden. It reads from the primary like editableRecipe.
+func (rs *RecipeService) ownedRecipe(ctx context.Context, actorID, recipeID int64) (*models.Recipe, error) {
+	ctx = store.WithPrimary(ctx)
+	recipe, err := rs.visibleRecipe(ctx, actorID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := rs.role(ctx, recipe, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if role != models.RoleOwner {
+		return nil, ErrForbidden
+	}
+	return recipe, nil
+}
+
+// saveImages stores uploaded images and tracks them for garbage collection
+func (rs *RecipeService) saveImages(ctx context.Context, files []*multipart.FileHeader) ([]string, error) {
+	var images []string
+
+	for _, file := range files {
+		src, err := file.Open()
+		if err != nil {
+			return images, err
+		}
+
+		path, err := rs.Blobs.Save(file.Filename, src)
+		src.Close()
+		if err != nil {
+			return images, err
+		}
+
+		// Track the blob so it is collected if no recipe ends up using it
+		if err := rs.Media.TrackMedia(ctx, path); err != nil {
+			return images, err
+		}
+		images = append(images, path)
+		if rs.Observer != nil {
+			rs.Observer.UploadedBytes(file.Size)
+		}
+	}
+
+	return images, nil
+}
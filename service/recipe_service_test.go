@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"backend-app/media"
+	"backend-app/models"
+	"backend-app/store"
+	"backend-app/store/memory"
+)
+
+func newRecipeService(t *testing.T) (*RecipeService, *memory.UserStore) {
+	t.Helper()
+
+	db := memory.NewDB()
+	recipes := NewRecipeService(memory.NewRecipeStore(db), memory.NewMediaStore(db), media.NewStore(t.TempDir()))
+	return recipes, memory.NewUserStore(db)
+}
+
+func createUser(t *testing.T, users *memory.UserStore, email string) int64 {
+	t.Helper()
+
+	user := &models.User{Username: email, Email: email, PasswordHash: "hash"}
+	if err := users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return int64(user.ID)
+}
+
+func TestCreateRecipeIsOwnedByActor(t *testing.T) {
+	ctx := context.Background()
+	recipes, users := newRecipeService(t)
+	owner := createUser(t, users, "abebe@example.com")
+
+	var req models.CreateRecipeRequest
+	req.Title = "Shiro"
+	recipe, err := recipes.CreateRecipe(ctx, owner, &req)
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+	if recipe.CreatorID != owner {
+		t.Errorf("CreatorID = %d, want %d", recipe.CreatorID, owner)
+	}
+}
+
+func TestOnlyCreatorChangesRecipe(t *testing.T) {
+	ctx := context.Background()
+	recipes, users := newRecipeService(t)
+	owner := createUser(t, users, "abebe@example.com")
+	other := createUser(t, users, "kebede@example.com")
+
+	var create models.CreateRecipeRequest
+	create.Title = "Shiro"
+	create.Status = models.StatusPublished
+	recipe, err := recipes.CreateRecipe(ctx, owner, &create)
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+
+	update := models.UpdateRecipeRequest{ID: recipe.ID}
+	update.Title = "Shiro Wat"
+	if _, err := recipes.UpdateRecipe(ctx, other, &update); !errors.Is(err, ErrForbidden) {
+		t.Errorf("UpdateRecipe by other user = %v, want ErrForbidden", err)
+	}
+	if err := recipes.DeleteRecipe(ctx, other, recipe.ID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("DeleteRecipe by other user = %v, want ErrForbidden", err)
+	}
+
+	updated, err := recipes.UpdateRecipe(ctx, owner, &update)
+	if err != nil {
+		t.Fatalf("UpdateRecipe by owner: %v", err)
+	}
+	if updated.Title != "Shiro Wat" || updated.CreatorID != owner {
+		t.Errorf("updated recipe = %+v", updated)
+	}
+	if err := recipes.DeleteRecipe(ctx, owner, recipe.ID); err != nil {
+		t.Errorf("DeleteRecipe by owner: %v", err)
+	}
+}
+
+func TestDraftsAreHiddenFromOtherUsers(t *testing.T) {
+	ctx := context.Background()
+	recipes, users := newRecipeService(t)
+	owner := createUser(t, users, "abebe@example.com")
+	other := createUser(t, users, "kebede@example.com")
+
+	var create models.CreateRecipeRequest
+	create.Title = "Shiro"
+	draft, err := recipes.CreateRecipe(ctx, owner, &create)
+	if err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+	if draft.Status != models.StatusDraft {
+		t.Fatalf("status = %q, want a draft", draft.Status)
+	}
+
+	if _, err := recipes.GetRecipe(ctx, other, draft.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetRecipe by other user = %v, want ErrNotFound", err)
+	}
+	if _, err := recipes.ForkRecipe(ctx, other, draft.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ForkRecipe by other user = %v, want ErrNotFound", err)
+	}
+	if err := recipes.DeleteRecipe(ctx, other, draft.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("DeleteRecipe by other user = %v, want ErrNotFound", err)
+	}
+	if _, err := recipes.GetRecipe(ctx, owner, draft.ID); err != nil {
+		t.Errorf("GetRecipe by owner: %v", err)
+	}
+
+	// An update keeps the recipe a draft
+	update := models.UpdateRecipeRequest{ID: draft.ID}
+	update.Title = "Shiro Wat"
+	updated, err := recipes.UpdateRecipe(ctx, owner, &update)
+	if err != nil {
+		t.Fatalf("UpdateRecipe: %v", err)
+	}
+	if updated.Status != models.StatusDraft {
+		t.Errorf("status after update = %q, want a draft", updated.Status)
+	}
+}
+
+// queryCounter counts store calls by method
+type queryCounter map[string]int
+
+func (c queryCounter) ObserveQuery(store, method string, duration time.Duration, err error) {
+	c[method]++
+}
+
+func TestGetAllRecipesReadsRolesOnce(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewDB()
+	users := memory.NewUserStore(db)
+	queries := queryCounter{}
+	recipeStore := &store.InstrumentedRecipeStore{Next: memory.NewRecipeStore(db), Observer: queries}
+	recipes := NewRecipeService(recipeStore, memory.NewMediaStore(db), media.NewStore(t.TempDir()))
+	owner := createUser(t, users, "abebe@example.com")
+	coOwner := createUser(t, users, "kebede@example.com")
+
+	for i, token := range []string{"first", "second", "third"} {
+		recipe, err := recipeStore.CreateRecipe(ctx, &models.Recipe{Title: "Shiro", CreatorID: owner, Status: models.StatusPublished,
+			Visibility: models.VisibilityUnlisted, ShareToken: token})
+		if err != nil {
+			t.Fatalf("CreateRecipe: %v", err)
+		}
+		if i == 2 {
+			share := &models.RecipeShare{RecipeID: recipe.ID, UserID: coOwner, Permission: models.PermissionEdit}
+			if err := recipeStore.ShareRecipe(ctx, share, owner); err != nil {
+				t.Fatalf("ShareRecipe: %v", err)
+			}
+			break
+		}
+		invitation := &models.Invitation{RecipeID: recipe.ID, InviteeID: coOwner, InvitedBy: owner, Role: models.RoleOwner}
+		if err := recipeStore.InviteAuthor(ctx, invitation); err != nil {
+			t.Fatalf("InviteAuthor: %v", err)
+		}
+		if _, err := recipeStore.AcceptInvitation(ctx, invitation.ID); err != nil {
+			t.Fatalf("AcceptInvitation: %v", err)
+		}
+	}
+
+	clear(queries)
+	listed, err := recipes.GetAllRecipes(ctx, coOwner)
+	if err != nil {
+		t.Fatalf("GetAllRecipes: %v", err)
+	}
+	var tokens []string
+	for _, recipe := range listed {
+		tokens = append(tokens, recipe.ShareToken)
+	}
+	if len(tokens) != 3 || tokens[0] != "first" || tokens[1] != "second" || tokens[2] != "" {
+		t.Errorf("share links listed = %q, want those of the co-owned recipes only", tokens)
+	}
+	if queries["GetAuthor"] != 0 || queries["ListAuthorships"] != 1 {
+		t.Errorf("queries = %v, want one ListAuthorships", queries)
+	}
+}
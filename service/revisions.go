@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"reflect"
+
+	"backend-app/models"
+)
+
+// ListRevisions retrieves every revision of a recipe the viewer may read,
+// newest first
+func (rs *RecipeService) ListRevisions(ctx context.Context, viewerID, recipeID int64) ([]*models.Revision, error) {
+	if _, err := rs.visibleRecipe(ctx, viewerID, recipeID); err != nil {
+		return nil, err
+	}
+	return rs.Recipes.ListRevisions(ctx, recipeID)
+}
+
+// GetRevision retrieves one revision of a recipe the viewer may read
+func (rs *RecipeService) GetRevision(ctx context.Context, viewerID, recipeID, version int64) (*models.Revision, error) {
+	if _, err := rs.visibleRecipe(ctx, viewerID, recipeID); err != nil {
+		return nil, err
+	}
+	return rs.Recipes.GetRevision(ctx, recipeID, version)
+}
+
+// DiffRevisions compares two revisions of a recipe the viewer may read field
+// by field
+func (rs *RecipeService) DiffRevisions(ctx context.Context, viewerID, recipeID, from, to int64) (*models.RevisionDiff, error) {
+	if _, err := rs.visibleRecipe(ctx, viewerID, recipeID); err != nil {
+		return nil, err
+	}
+	older, err := rs.Recipes.GetRevision(ctx, recipeID, from)
+	if err != nil {
+		return nil, err
+	}
+	newer, err := rs.Recipes.GetRevision(ctx, recipeID, to)
+	if err != nil {
+		return nil, err
+	}
+	return &models.RevisionDiff{
+		RecipeID: recipeID,
+		From:     from,
+		To:       to,
+		Changes:  diffRevisions(older, newer),
+	}, nil
+}
+
+// RestoreRevision makes the content of a previous revision the recipe's
+// next version, so the restore itself can be undone. Only users who may
+// edit the recipe may restore it. A non-zero expectedVersion must be the current
+// version.
+func (rs *RecipeService) RestoreRevision(ctx context.Context, actorID, recipeID, version, expectedVersion int64) (*models.Recipe, error) {
+	existing, err := rs.editableRecipe(ctx, actorID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := rs.Recipes.GetRevision(ctx, recipeID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	recipe := &models.Recipe{
+		Title:       revision.Title,
+		Description: revision.Description,
+		Ingredients: revision.Ingredients,
+		Steps:       revision.Steps,
+		PrepTime:    revision.PrepTime,
+		CategoryID:  revision.CategoryID,
+		Images:      revision.Images,
+	}
+	return rs.save(ctx, actorID, existing, recipe, expectedVersion)
+}
+
+// diffRevisions lists the content fields that differ between two revisions
+func diffRevisions(older, newer *models.Revision) []models.FieldChange {
+	fields := []struct {
+		name     string
+		from, to interface{}
+	}{
+		{"title", older.Title, newer.Title},
+		{"description", older.Description, newer.Description},
+		{"ingredients", older.Ingredients, newer.Ingredients},
+		{"steps", older.Steps, newer.Steps},
+		{"time", older.PrepTime, newer.PrepTime},
+		{"category_id", older.CategoryID, newer.CategoryID},
+		{"images", older.Images, newer.Images},
+	}
+
+	changes := []models.FieldChange{}
+	for _, f := range fields {
+		if !sameValue(f.from, f.to) {
+			changes = append(changes, models.FieldChange{Field: f.name, From: f.from, To: f.to})
+		}
+	}
+	return changes
+}
+
+// sameValue compares field values, treating nil and empty lists as equal
+func sameValue(a, b interface{}) bool {
+	if x, ok := a.([]string); ok {
+		y := b.([]string)
+		return len(x) == len(y) && (len(x) == 0 || reflect.DeepEqual(x, y))
+	}
+	return a == b
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"backend-app/models"
+	"backend-app/store"
+)
+
+// shareTokenBytes is how much randomness a share link token carries
+const shareTokenBytes = 32
+
+// SetRecipeVisibility changes who can read a recipe. Only its owners may do
+// so. An unlisted recipe keeps its share link while it stays unlisted and
+// gets a new one otherwise; the link is revoked when the recipe stops being
+// unlisted.
+func (rs *RecipeService) SetRecipeVisibility(ctx context.Context, actorID int64, req *models.RecipeVisibilityRequest) (*models.Recipe, error) {
+	recipe, err := rs.ownedRecipe(ctx, actorID, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case req.Visibility != models.VisibilityUnlisted:
+		recipe.ShareToken = ""
+	case recipe.Visibility != models.VisibilityUnlisted || recipe.ShareToken == "":
+		if recipe.ShareToken, err = generateShareToken(); err != nil {
+			return nil, err
+		}
+	}
+	recipe.Visibility = req.Visibility
+	recipe.UpdatedBy = actorID
+	if req.ExpectedVersion != 0 {
+		recipe.Version = req.ExpectedVersion
+	}
+	if err := rs.Recipes.SetRecipeVisibility(ctx, recipe); err != nil {
+		return nil, err
+	}
+	return recipe, nil
+}
+
+// GetSharedRecipe retrieves an unlisted recipe by its share link token.
+// Anyone holding the link may read it.
+func (rs *RecipeService) GetSharedRecipe(ctx context.Context, token string) (*models.Recipe, error) {
+	recipe, err := rs.Recipes.GetRecipeByShareToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return rs.redacted(ctx, recipe, 0)
+}
+
+// ShareRecipe shares a recipe with another user, or changes the permission
+// it is shared with. Only its owners may share it.
+func (rs *RecipeService) ShareRecipe(ctx context.Context, actorID int64, req *models.ShareRecipeRequest) (*models.RecipeShare, error) {
+	recipe, err := rs.ownedRecipe(ctx, actorID, req.RecipeID)
+	if err != nil {
+		return nil, err
+	}
+	if req.UserID == recipe.CreatorID {
+		return nil, ErrSharedWithCreator
+	}
+
+	share := &models.RecipeShare{
+		RecipeID:   recipe.ID,
+		UserID:     req.UserID,
+		Permission: req.Permission,
+	}
+	if err := rs.Recipes.ShareRecipe(ctx, share, actorID); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// UnshareRecipe stops sharing a recipe with a user. Only its owners may do
+// so.
+func (rs *RecipeService) UnshareRecipe(ctx context.Context, actorID, recipeID, userID int64) error {
+	if _, err := rs.ownedRecipe(ctx, actorID, recipeID); err != nil {
+		return err
+	}
+	return rs.Recipes.UnshareRecipe(ctx, recipeID, userID)
+}
+
+// ListShares lists the users a recipe is shared with. Only its owners may
+// list them.
+func (rs *RecipeService) ListShares(ctx context.Context, actorID, recipeID int64) ([]*models.RecipeShare, error) {
+	if _, err := rs.ownedRecipe(ctx, actorID, recipeID); err != nil {
+		return nil, err
+	}
+	return rs.Recipes.ListShares(ctx, recipeID)
+}
+
+// permission resolves what a user may do with a recipe: PermissionEdit for
+// its authors, and for anyone else nothing while it is a draft. Once it is
+// not, users it is shared with get the permission it is shared with, and
+// anyone PermissionView if it is public. A zero userID stands for an
+// anonymous viewer.
+func (rs *RecipeService) permission(ctx context.Context, recipe *models.Recipe, userID int64) (string, error) {
+	role, err := rs.role(ctx, recipe, userID)
+	if err != nil {
+		return "", err
+	}
+	if role != "" {
+		return models.PermissionEdit, nil
+	}
+	if recipe.Status == models.StatusDraft {
+		return "", nil
+	}
+	if userID != 0 {
+		share, err := rs.Recipes.GetShare(ctx, recipe.ID, userID)
+		if err == nil {
+			return share.Permission, nil
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return "", err
+		}
+	}
+	if recipe.Visibility == models.VisibilityPublic {
+		return models.PermissionView, nil
+	}
+	return "", nil
+}
+
+// editableRecipe loads a recipe the acting user may edit: one they author
+// or that is shared with them for editing. Recipes they may not read are
+// not found rather than forbidden. Like every check authorizing a write, it
+// reads from the primary, so that a share or authorship revoked moments
+// ago is not honoured from a lagging replica.
+func (rs *RecipeService) editableRecipe(ctx context.Context, actorID, recipeID int64) (*models.Recipe, error) {
+	ctx = store.WithPrimary(ctx)
+	recipe, err := rs.Recipes.GetRecipeByID(ctx, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	permission, err := rs.permission(ctx, recipe, actorID)
+	if err != nil {
+		return nil, err
+	}
+	switch permission {
+	case models.PermissionEdit:
+		return recipe, nil
+	case "":
+		return nil, fmt.Errorf("retrieving recipe: %w", store.ErrNotFound)
+	default:
+		return nil, ErrForbidden
+	}
+}
+
+// redacted returns a recipe as the viewer may see it. Only its owners see
+// its share link token; others get a copy without it, so recipes shared
+// from a cache are left alone.
+func (rs *RecipeService) redacted(ctx context.Context, recipe *models.Recipe, viewerID int64) (*models.Recipe, error) {
+	if recipe.ShareToken == "" {
+		return recipe, nil
+	}
+	role, err := rs.role(ctx, recipe, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return redact(recipe, role), nil
+}
+
+// redact returns a recipe as a viewer with the given role may see it, like
+// redacted
+func redact(recipe *models.Recipe, role string) *models.Recipe {
+	if recipe.ShareToken == "" || role == models.RoleOwner {
+		return recipe
+	}
+	redacted := *recipe
+	redacted.ShareToken = ""
+	return &redacted
+}
+
+// generateShareToken returns a new unguessable share link token
+func generateShareToken() (string, error) {
+	b := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating share token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
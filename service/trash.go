@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"backend-app/models"
+)
+
+// DefaultRetention is how long deleted recipes and accounts can be restored
+// before they are purged
+const DefaultRetention = 30 * 24 * time.Hour
+
+// Trash lists the acting user's deleted recipes that can still be restored,
+// most recently deleted first
+func (rs *RecipeService) Trash(ctx context.Context, actorID int64) ([]*models.Recipe, error) {
+	deleted, err := rs.Recipes.ListDeletedRecipes(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Recipes past the window are left for the purger
+	cutoff := time.Now().Add(-rs.Retention)
+	recipes := make([]*models.Recipe, 0, len(deleted))
+	for _, recipe := range deleted {
+		if !recipe.DeletedAt.Before(cutoff) {
+			recipes = append(recipes, recipe)
+		}
+	}
+	return recipes, nil
+}
+
+// RestoreRecipe takes one of the acting user's recipes out of the trash. It
+// fails with store.ErrNotFound once the retention window has passed.
+func (rs *RecipeService) RestoreRecipe(ctx context.Context, actorID, recipeID int64) (*models.Recipe, error) {
+	if err := rs.Recipes.RestoreRecipe(ctx, recipeID, actorID, time.Now().Add(-rs.Retention)); err != nil {
+		return nil, err
+	}
+	return rs.Recipes.GetRecipeByID(ctx, recipeID)
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend-app/models"
+	"backend-app/store"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type UserService struct {
+	Users     store.UserStore
+	Observer  Observer      // Told about login attempts, may be nil
+	Retention time.Duration // How long deleted accounts can be restored
+}
+
+// NewUserService initializes a new UserService
+func NewUserService(users store.UserStore) *UserService {
+	return &UserService{
+		Users:     users,
+		Retention: DefaultRetention,
+	}
+}
+
+// SignUp registers a new user with a hashed password
+func (us *UserService) SignUp(ctx context.Context, req *models.SignUpRequest) (*models.User, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username:     req.Username,
+		Email:        req.Email,
+		PasswordHash: string(hashedPassword),
+	}
+	if err := us.Users.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Authenticate returns the user matching the credentials, or
+// ErrInvalidCredentials
+func (us *UserService) Authenticate(ctx context.Context, creds *models.Credentials) (*models.User, error) {
+	user, err := us.authenticate(ctx, creds)
+	if us.Observer != nil {
+		switch {
+		case err == nil:
+			us.Observer.LoginAttempt(LoginSuccess)
+		case errors.Is(err, ErrInvalidCredentials):
+			us.Observer.LoginAttempt(LoginFailure)
+		default:
+			us.Observer.LoginAttempt(LoginError)
+		}
+	}
+	return user, err
+}
+
+func (us *UserService) authenticate(ctx context.Context, creds *models.Credentials) (*models.User, error) {
+	user, err := us.Users.GetUserByEmail(ctx, creds.Email)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(creds.Password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// GetUser retrieves a user by ID
+func (us *UserService) GetUser(ctx context.Context, userID int64) (*models.User, error) {
+	return us.Users.GetUserByID(ctx, userID)
+}
+
+// UpdateUser changes the profile of the acting user. Users may only edit
+// their own profile; the password hash is kept.
+func (us *UserService) UpdateUser(ctx context.Context, actorID int64, req *models.UpdateUserRequest) (*models.User, error) {
+	if int64(req.ID) != actorID {
+		return nil, ErrForbidden
+	}
+
+	user, err := us.Users.GetUserByID(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	user.Username = req.Username
+	user.Email = req.Email
+
+	if err := us.Users.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// DeleteUser deletes the account of the acting user and their recipes.
+// Users may only delete their own account, and may restore it with
+// RestoreUser until the retention window ends.
+func (us *UserService) DeleteUser(ctx context.Context, actorID, userID int64) error {
+	if userID != actorID {
+		return ErrForbidden
+	}
+	return us.Users.DeleteUser(ctx, userID)
+}
+
+// RestoreUser restores a deleted account, and the recipes deleted with it,
+// for a user who signs in with its credentials within the retention window
+func (us *UserService) RestoreUser(ctx context.Context, creds *models.Credentials) (*models.User, error) {
+	user, err := us.Users.GetDeletedUserByEmail(ctx, creds.Email, time.Now().Add(-us.Retention))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(creds.Password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := us.Users.RestoreUser(ctx, int64(user.ID), time.Now().Add(-us.Retention)); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend-app/models"
+	"backend-app/store/memory"
+)
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	users := NewUserService(memory.NewUserStore(memory.NewDB()))
+
+	_, err := users.SignUp(ctx, &models.SignUpRequest{Username: "abebe", Email: "abebe@example.com", Password: "injera-1234"})
+	if err != nil {
+		t.Fatalf("SignUp: %v", err)
+	}
+
+	if _, err := users.Authenticate(ctx, &models.Credentials{Email: "abebe@example.com", Password: "injera-1234"}); err != nil {
+		t.Errorf("Authenticate with valid password: %v", err)
+	}
+	if _, err := users.Authenticate(ctx, &models.Credentials{Email: "abebe@example.com", Password: "wrong"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Authenticate with wrong password = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := users.Authenticate(ctx, &models.Credentials{Email: "nobody@example.com", Password: "injera-1234"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Authenticate unknown email = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestUpdateUserKeepsPasswordHash(t *testing.T) {
+	ctx := context.Background()
+	users := NewUserService(memory.NewUserStore(memory.NewDB()))
+
+	user, err := users.SignUp(ctx, &models.SignUpRequest{Username: "abebe", Email: "abebe@example.com", Password: "injera-1234"})
+	if err != nil {
+		t.Fatalf("SignUp: %v", err)
+	}
+
+	if _, err := users.UpdateUser(ctx, int64(user.ID)+1, &models.UpdateUserRequest{ID: user.ID, Username: "x", Email: "x@example.com"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("UpdateUser by other user = %v, want ErrForbidden", err)
+	}
+
+	if _, err := users.UpdateUser(ctx, int64(user.ID), &models.UpdateUserRequest{ID: user.ID, Username: "abebe2", Email: "abebe2@example.com"}); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if _, err := users.Authenticate(ctx, &models.Credentials{Email: "abebe2@example.com", Password: "injera-1234"}); err != nil {
+		t.Errorf("Authenticate after update: %v", err)
+	}
+}
+
+// countingObserver records the events services report
+type countingObserver struct {
+	logins   map[string]int
+	uploaded int64
+}
+
+func (o *countingObserver) UploadedBytes(n int64) { o.uploaded += n }
+
+func (o *countingObserver) LoginAttempt(result string) {
+	if o.logins == nil {
+		o.logins = make(map[string]int)
+	}
+	o.logins[result]++
+}
+
+func TestAuthenticateReportsAttempts(t *testing.T) {
+	ctx := context.Background()
+	obs := &countingObserver{}
+	users := NewUserService(memory.NewUserStore(memory.NewDB()))
+	users.Observer = obs
+
+	users.SignUp(ctx, &models.SignUpRequest{Username: "abebe", Email: "abebe@example.com", Password: "injera-1234"})
+	users.Authenticate(ctx, &models.Credentials{Email: "abebe@example.com", Password: "injera-1234"})
+	users.Authenticate(ctx, &models.Credentials{Email: "abebe@example.com", Password: "wrong"})
+	users.Authenticate(ctx, &models.Credentials{Email: "nobody@example.com", Password: "wrong"})
+
+	if obs.logins[LoginSuccess] != 1 || obs.logins[LoginFailure] != 2 {
+		t.Errorf("login attempts = %v, want 1 success and 2 failures", obs.logins)
+	}
+}
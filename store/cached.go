@@ -0,0 +1,267 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"backend-app/cache"
+	"backend-app/models"
+)
+
+const categoriesKey = "categories"
+
+func recipeKey(recipeID int64) string {
+	return "recipe:" + strconv.FormatInt(recipeID, 10)
+}
+
+// CachedRecipeStore serves GetRecipeByID from a read-through cache. Updates
+// and deletions invalidate the recipe's entry, whether they succeed or not,
+// since a failed update may have found the cached copy stale. Misses are
+// loaded from the primary: a lagging replica could still return the
+// version a write just invalidated, which would then stay cached. Reads
+// with WithPrimary bypass the cache.
+//
+// A miss that started loading before an invalidation is not cached by this
+// instance, but with the redis backend another instance may still store
+// what it loaded, until the entry expires after TTL.
+type CachedRecipeStore struct {
+	Next  RecipeStore
+	Cache *cache.Cache
+	TTL   time.Duration
+}
+
+func (s *CachedRecipeStore) CreateRecipe(ctx context.Context, recipe *models.Recipe) (*models.Recipe, error) {
+	return s.Next.CreateRecipe(ctx, recipe)
+}
+
+func (s *CachedRecipeStore) UpdateRecipe(ctx context.Context, recipe *models.Recipe) error {
+	defer s.Cache.Invalidate(ctx, recipeKey(recipe.ID))
+	return s.Next.UpdateRecipe(ctx, recipe)
+}
+
+func (s *CachedRecipeStore) DeleteRecipe(ctx context.Context, recipeID, deletedBy int64) error {
+	defer s.Cache.Invalidate(ctx, recipeKey(recipeID))
+	return s.Next.DeleteRecipe(ctx, recipeID, deletedBy)
+}
+
+func (s *CachedRecipeStore) GetRecipeByID(ctx context.Context, recipeID int64) (*models.Recipe, error) {
+	if primaryRequested(ctx) {
+		return s.Next.GetRecipeByID(ctx, recipeID)
+	}
+	return cache.Fetch(ctx, s.Cache, recipeKey(recipeID), s.TTL, func(ctx context.Context) (*models.Recipe, error) {
+		return s.Next.GetRecipeByID(WithPrimary(ctx), recipeID)
+	})
+}
+
+func (s *CachedRecipeStore) GetAllRecipes(ctx context.Context, viewerID int64) ([]*models.Recipe, error) {
+	return s.Next.GetAllRecipes(ctx, viewerID)
+}
+
+func (s *CachedRecipeStore) ListForks(ctx context.Context, recipeID, viewerID int64) ([]*models.Recipe, error) {
+	return s.Next.ListForks(ctx, recipeID, viewerID)
+}
+
+func (s *CachedRecipeStore) SetRecipeStatus(ctx context.Context, recipe *models.Recipe) error {
+	defer s.Cache.Invalidate(ctx, recipeKey(recipe.ID))
+	return s.Next.SetRecipeStatus(ctx, recipe)
+}
+
+func (s *CachedRecipeStore) PublishDueRecipes(ctx context.Context, now time.Time) ([]int64, error) {
+	published, err := s.Next.PublishDueRecipes(ctx, now)
+	for _, recipeID := range published {
+		s.Cache.Invalidate(ctx, recipeKey(recipeID))
+	}
+	return published, err
+}
+
+func (s *CachedRecipeStore) ListDeletedRecipes(ctx context.Context, creatorID int64) ([]*models.Recipe, error) {
+	return s.Next.ListDeletedRecipes(ctx, creatorID)
+}
+
+func (s *CachedRecipeStore) RestoreRecipe(ctx context.Context, recipeID, creatorID int64, deletedAfter time.Time) error {
+	defer s.Cache.Invalidate(ctx, recipeKey(recipeID))
+	return s.Next.RestoreRecipe(ctx, recipeID, creatorID, deletedAfter)
+}
+
+// PurgeRecipes needs no invalidation, since the recipes were invalidated
+// when they were deleted
+func (s *CachedRecipeStore) PurgeRecipes(ctx context.Context, before time.Time) (int64, error) {
+	return s.Next.PurgeRecipes(ctx, before)
+}
+
+func (s *CachedRecipeStore) SetRecipeVisibility(ctx context.Context, recipe *models.Recipe) error {
+	defer s.Cache.Invalidate(ctx, recipeKey(recipe.ID))
+	return s.Next.SetRecipeVisibility(ctx, recipe)
+}
+
+func (s *CachedRecipeStore) GetRecipeByShareToken(ctx context.Context, token string) (*models.Recipe, error) {
+	return s.Next.GetRecipeByShareToken(ctx, token)
+}
+
+// Shares are not cached, so that revoking one takes effect at once
+
+func (s *CachedRecipeStore) ShareRecipe(ctx context.Context, share *models.RecipeShare, sharedBy int64) error {
+	return s.Next.ShareRecipe(ctx, share, sharedBy)
+}
+
+func (s *CachedRecipeStore) UnshareRecipe(ctx context.Context, recipeID, userID int64) error {
+	return s.Next.UnshareRecipe(ctx, recipeID, userID)
+}
+
+func (s *CachedRecipeStore) GetShare(ctx context.Context, recipeID, userID int64) (*models.RecipeShare, error) {
+	return s.Next.GetShare(ctx, recipeID, userID)
+}
+
+func (s *CachedRecipeStore) ListShares(ctx context.Context, recipeID int64) ([]*models.RecipeShare, error) {
+	return s.Next.ListShares(ctx, recipeID)
+}
+
+// Authors and invitations are not cached either
+
+func (s *CachedRecipeStore) InviteAuthor(ctx context.Context, invitation *models.Invitation) error {
+	return s.Next.InviteAuthor(ctx, invitation)
+}
+
+func (s *CachedRecipeStore) GetInvitation(ctx context.Context, invitationID int64) (*models.Invitation, error) {
+	return s.Next.GetInvitation(ctx, invitationID)
+}
+
+func (s *CachedRecipeStore) ListInvitations(ctx context.Context, inviteeID int64) ([]*models.Invitation, error) {
+	return s.Next.ListInvitations(ctx, inviteeID)
+}
+
+func (s *CachedRecipeStore) AcceptInvitation(ctx context.Context, invitationID int64) (*models.RecipeAuthor, error) {
+	return s.Next.AcceptInvitation(ctx, invitationID)
+}
+
+func (s *CachedRecipeStore) DeleteInvitation(ctx context.Context, invitationID int64) error {
+	return s.Next.DeleteInvitation(ctx, invitationID)
+}
+
+func (s *CachedRecipeStore) GetAuthor(ctx context.Context, recipeID, userID int64) (*models.RecipeAuthor, error) {
+	return s.Next.GetAuthor(ctx, recipeID, userID)
+}
+
+func (s *CachedRecipeStore) ListAuthors(ctx context.Context, recipeID int64) ([]*models.RecipeAuthor, error) {
+	return s.Next.ListAuthors(ctx, recipeID)
+}
+
+func (s *CachedRecipeStore) ListAuthorships(ctx context.Context, userID int64) ([]*models.RecipeAuthor, error) {
+	return s.Next.ListAuthorships(ctx, userID)
+}
+
+func (s *CachedRecipeStore) RemoveAuthor(ctx context.Context, recipeID, userID int64) error {
+	return s.Next.RemoveAuthor(ctx, recipeID, userID)
+}
+
+func (s *CachedRecipeStore) ListRevisions(ctx context.Context, recipeID int64) ([]*models.Revision, error) {
+	return s.Next.ListRevisions(ctx, recipeID)
+}
+
+func (s *CachedRecipeStore) GetRevision(ctx context.Context, recipeID, version int64) (*models.Revision, error) {
+	return s.Next.GetRevision(ctx, recipeID, version)
+}
+
+// CachedCategoryStore serves GetAllCategories from a read-through cache,
+// loading misses from the primary like CachedRecipeStore. Every change to a
+// category invalidates the list, and deleting one also invalidates the
+// recipes it moved to another category.
+type CachedCategoryStore struct {
+	Next  CategoryStore
+	Cache *cache.Cache
+	TTL   time.Duration
+}
+
+func (s *CachedCategoryStore) CreateCategory(ctx context.Context, category *models.Category) error {
+	defer s.Cache.Invalidate(ctx, categoriesKey)
+	return s.Next.CreateCategory(ctx, category)
+}
+
+func (s *CachedCategoryStore) UpdateCategory(ctx context.Context, category *models.Category) error {
+	defer s.Cache.Invalidate(ctx, categoriesKey)
+	return s.Next.UpdateCategory(ctx, category)
+}
+
+func (s *CachedCategoryStore) DeleteCategory(ctx context.Context, categoryID, reassignTo int64) ([]int64, error) {
+	moved, err := s.Next.DeleteCategory(ctx, categoryID, reassignTo)
+	keys := []string{categoriesKey}
+	for _, recipeID := range moved {
+		keys = append(keys, recipeKey(recipeID))
+	}
+	s.Cache.Invalidate(ctx, keys...)
+	return moved, err
+}
+
+func (s *CachedCategoryStore) GetCategoryByID(ctx context.Context, categoryID int64) (*models.Category, error) {
+	return s.Next.GetCategoryByID(ctx, categoryID)
+}
+
+func (s *CachedCategoryStore) GetAllCategories(ctx context.Context) ([]*models.Category, error) {
+	return cache.Fetch(ctx, s.Cache, categoriesKey, s.TTL, func(ctx context.Context) ([]*models.Category, error) {
+		return s.Next.GetAllCategories(WithPrimary(ctx))
+	})
+}
+
+func (s *CachedCategoryStore) PurgeCategories(ctx context.Context, before time.Time) (int64, error) {
+	return s.Next.PurgeCategories(ctx, before)
+}
+
+// CachedUserStore invalidates the cached recipes of users who delete their
+// account, which deletes the recipes too. Users themselves are not cached.
+type CachedUserStore struct {
+	Next    UserStore
+	Recipes RecipeStore
+	Cache   *cache.Cache
+}
+
+func (s *CachedUserStore) CreateUser(ctx context.Context, user *models.User) error {
+	return s.Next.CreateUser(ctx, user)
+}
+
+func (s *CachedUserStore) UpdateUser(ctx context.Context, user *models.User) error {
+	return s.Next.UpdateUser(ctx, user)
+}
+
+func (s *CachedUserStore) DeleteUser(ctx context.Context, userID int64) error {
+	if err := s.Next.DeleteUser(ctx, userID); err != nil {
+		return err
+	}
+
+	// The deleted recipes are now in the user's trash
+	deleted, err := s.Recipes.ListDeletedRecipes(ctx, userID)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(deleted))
+	for _, recipe := range deleted {
+		keys = append(keys, recipeKey(recipe.ID))
+	}
+	s.Cache.Invalidate(ctx, keys...)
+	return nil
+}
+
+func (s *CachedUserStore) GetUserByID(ctx context.Context, userID int64) (*models.User, error) {
+	return s.Next.GetUserByID(ctx, userID)
+}
+
+func (s *CachedUserStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return s.Next.GetUserByEmail(ctx, email)
+}
+
+func (s *CachedUserStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	return s.Next.GetUserByUsername(ctx, username)
+}
+
+func (s *CachedUserStore) GetDeletedUserByEmail(ctx context.Context, email string, deletedAfter time.Time) (*models.User, error) {
+	return s.Next.GetDeletedUserByEmail(ctx, email, deletedAfter)
+}
+
+// RestoreUser needs no invalidation, since missing recipes are not cached
+func (s *CachedUserStore) RestoreUser(ctx context.Context, userID int64, deletedAfter time.Time) error {
+	return s.Next.RestoreUser(ctx, userID, deletedAfter)
+}
+
+func (s *CachedUserStore) PurgeUsers(ctx context.Context, before time.Time) (int64, error) {
+	return s.Next.PurgeUsers(ctx, before)
+}
@@ -0,0 +1,75 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend-app/cache"
+	"backend-app/models"
+	"backend-app/store"
+	"backend-app/store/memory"
+	"backend-app/store/storetest"
+)
+
+func TestCachedContract(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		db := memory.NewDB()
+		c := cache.New(cache.NewLRU(100))
+		return storetest.Stores{
+			Users:      memory.NewUserStore(db),
+			Categories: &store.CachedCategoryStore{Next: memory.NewCategoryStore(db), Cache: c, TTL: time.Minute},
+			Recipes:    &store.CachedRecipeStore{Next: memory.NewRecipeStore(db), Cache: c, TTL: time.Minute},
+			Media:      memory.NewMediaStore(db),
+		}
+	})
+}
+
+func TestCachedStoresServeReadsFromCache(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewDB()
+	users := memory.NewUserStore(db)
+	backing := memory.NewRecipeStore(db)
+	c := cache.New(cache.NewLRU(100))
+	recipes := &store.CachedRecipeStore{Next: backing, Cache: c, TTL: time.Minute}
+	categories := &store.CachedCategoryStore{Next: memory.NewCategoryStore(db), Cache: c, TTL: time.Minute}
+
+	user := &models.User{Username: "abebe", Email: "abebe@example.com"}
+	if err := users.CreateUser(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	recipe, err := recipes.CreateRecipe(ctx, &models.Recipe{Title: "Shiro", CreatorID: int64(user.ID)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipes.GetRecipeByID(ctx, recipe.ID)
+
+	// A change the cache is not told about stays hidden until the entry
+	// expires or is invalidated
+	changed := *recipe
+	changed.Title = "Spicy Shiro"
+	if err := backing.UpdateRecipe(ctx, &changed); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := recipes.GetRecipeByID(ctx, recipe.ID); got.Title != "Shiro" {
+		t.Errorf("title = %q, want the cached one", got.Title)
+	}
+
+	// A failed update still invalidates, since it found the cache stale
+	if err := recipes.UpdateRecipe(ctx, recipe); err == nil {
+		t.Fatal("update of a stale version succeeded")
+	}
+	if got, _ := recipes.GetRecipeByID(ctx, recipe.ID); got.Title != "Spicy Shiro" || got.Version != changed.Version {
+		t.Errorf("recipe = %+v, want the current version", got)
+	}
+
+	if list, _ := categories.GetAllCategories(ctx); len(list) != 0 {
+		t.Fatalf("categories = %v, want none", list)
+	}
+	if err := categories.CreateCategory(ctx, &models.Category{Name: "Stews"}); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := categories.GetAllCategories(ctx); len(list) != 1 {
+		t.Errorf("categories = %v, want the new one", list)
+	}
+}
@@ -0,0 +1,228 @@
+package store
+
+import (
+	"backend-app/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type PostgresCategoryStore struct {
+	DB      *Cluster      // Reads are served by replicas when there are any
+	Timeout time.Duration // Per-query timeout, DefaultQueryTimeout if zero
+}
+
+// NewPostgresCategoryStore initializes a new PostgresCategoryStore
+func NewPostgresCategoryStore(db *Cluster) *PostgresCategoryStore {
+	return &PostgresCategoryStore{
+		DB:      db,
+		Timeout: DefaultQueryTimeout,
+	}
+}
+
+// CreateCategory creates a new category in the database
+func (cr *PostgresCategoryStore) CreateCategory(ctx context.Context, category *models.Category) error {
+	ctx, cancel := withTimeout(ctx, cr.Timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO categories (name)
+		VALUES ($1)
+		RETURNING id
+	`
+	err := cr.DB.Primary.QueryRowContext(ctx, query, category.Name).Scan(&category.ID)
+	if err != nil {
+		return translateError("creating category", err)
+	}
+	cr.DB.MarkWrite(ctx)
+	return nil
+}
+
+// UpdateCategory updates an existing category in the database
+func (cr *PostgresCategoryStore) UpdateCategory(ctx context.Context, category *models.Category) error {
+	ctx, cancel := withTimeout(ctx, cr.Timeout)
+	defer cancel()
+
+	query := `
+		UPDATE categories
+		SET name = $1
+		WHERE id = $2 AND deleted_at IS NULL
+	`
+	result, err := cr.DB.Primary.ExecContext(ctx, query, category.Name, category.ID)
+	if err != nil {
+		return translateError("updating category", err)
+	}
+	if err := requireAffected(result); err != nil {
+		return translateError("updating category", err)
+	}
+	cr.DB.MarkWrite(ctx)
+	return nil
+}
+
+// DeleteCategory deletes a category and moves its recipes to reassignTo,
+// saving the move as a new version of each. A zero reassignTo blocks the
+// deletion while live recipes belong to the category.
+func (cr *PostgresCategoryStore) DeleteCategory(ctx context.Context, categoryID, reassignTo int64) ([]int64, error) {
+	ctx, cancel := withTimeout(ctx, cr.Timeout)
+	defer cancel()
+
+	// Deleting first locks the row, so recipes created in the category
+	// concurrently either commit before the move or see it deleted
+	query := `
+		UPDATE categories
+		SET deleted_at = current_timestamp
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	tx, err := cr.DB.Primary.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, translateError("starting transaction", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, categoryID)
+	if err != nil {
+		return nil, translateError("deleting category", err)
+	}
+	if err := requireAffected(result); err != nil {
+		return nil, translateError("deleting category", err)
+	}
+
+	if reassignTo == 0 {
+		var inUse bool
+		check := `SELECT EXISTS (SELECT 1 FROM recipes WHERE category_id = $1 AND deleted_at IS NULL)`
+		if err := tx.QueryRowContext(ctx, check, categoryID).Scan(&inUse); err != nil {
+			return nil, translateError("deleting category", err)
+		}
+		if inUse {
+			return nil, fmt.Errorf("deleting category: %w", ErrInvalidReference)
+		}
+	} else if err := lockCategory(ctx, tx, reassignTo); err != nil {
+		return nil, translateError("deleting category", err)
+	}
+
+	move := `
+		WITH recipe AS (
+			UPDATE recipes
+			SET category_id = $2, updated_by = NULL, version = version + 1, updated_at = current_timestamp
+			WHERE category_id = $1
+			RETURNING *
+		)
+	` + saveRevision + `
+		RETURNING recipe_id
+	`
+	rows, err := tx.QueryContext(ctx, move, categoryID, nullableID(reassignTo))
+	if err != nil {
+		return nil, translateError("moving category recipes", err)
+	}
+	defer rows.Close()
+
+	var moved []int64
+	for rows.Next() {
+		var recipeID int64
+		if err := rows.Scan(&recipeID); err != nil {
+			return nil, translateError("scanning moved recipe", err)
+		}
+		moved = append(moved, recipeID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError("moving category recipes", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, translateError("deleting category", err)
+	}
+	cr.DB.MarkWrite(ctx)
+	return moved, nil
+}
+
+// PurgeCategories permanently deletes categories deleted before the given
+// time. Their recipes were moved when they were deleted.
+func (cr *PostgresCategoryStore) PurgeCategories(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx, cr.Timeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM categories
+		WHERE deleted_at < $1
+		AND NOT EXISTS (SELECT 1 FROM recipes WHERE category_id = categories.id)
+	`
+	result, err := cr.DB.Primary.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, translateError("purging categories", err)
+	}
+	purged, err := result.RowsAffected()
+	return purged, translateError("purging categories", err)
+}
+
+// lockCategory checks that a live category exists and keeps it from being
+// deleted until tx ends. It returns ErrInvalidReference if there is none.
+func lockCategory(ctx context.Context, tx *sql.Tx, categoryID int64) error {
+	var id int64
+	query := `SELECT id FROM categories WHERE id = $1 AND deleted_at IS NULL FOR SHARE`
+	err := tx.QueryRowContext(ctx, query, categoryID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInvalidReference
+	}
+	return err
+}
+
+// GetCategoryByID retrieves a category from the database by ID
+func (cr *PostgresCategoryStore) GetCategoryByID(ctx context.Context, categoryID int64) (*models.Category, error) {
+	ctx, cancel := withTimeout(ctx, cr.Timeout)
+	defer cancel()
+
+	var category models.Category
+	query := `
+		SELECT id, name
+		FROM categories
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	err := cr.DB.Primary.QueryRowContext(ctx, query, categoryID).Scan(&category.ID, &category.Name)
+	if err != nil {
+		return nil, translateError("retrieving category", err)
+	}
+	return &category, nil
+}
+
+// GetAllCategories retrieves all categories from the database
+func (cr *PostgresCategoryStore) GetAllCategories(ctx context.Context) ([]*models.Category, error) {
+	ctx, cancel := withTimeout(ctx, cr.Timeout)
+	defer cancel()
+
+	var categories []*models.Category
+	query := `
+		SELECT id, name
+		FROM categories
+		WHERE deleted_at IS NULL
+		ORDER BY id
+	`
+	err := cr.DB.Read(ctx, func(db *sql.DB) error {
+		// Start over if a replica fails and the read is retried
+		categories = nil
+
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			return translateError("retrieving categories", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var category models.Category
+			err := rows.Scan(&category.ID, &category.Name)
+			if err != nil {
+				return translateError("scanning category row", err)
+			}
+			categories = append(categories, &category)
+		}
+		if err := rows.Err(); err != nil {
+			return translateError("iterating over category rows", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
@@ -0,0 +1,236 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DefaultReadYourWritesWindow is how long a user's reads stay on the
+// primary after they write, unless the cluster is configured otherwise
+const DefaultReadYourWritesWindow = 5 * time.Second
+
+// Cluster routes queries between a primary database and its read replicas.
+// Writes always go to the primary. Reads go to a healthy replica, except
+// for users who wrote recently, whose reads go to the primary so they see
+// their own changes despite replication lag. Without replicas every query
+// goes to the primary.
+//
+// Recent writes are only known to the instance that made them, so
+// read-your-writes holds per instance: behind a load balancer without
+// sticky sessions, a user's next request may still be read from a lagging
+// replica. Reads that must see every write, such as the checks authorizing
+// a write, use WithPrimary instead.
+type Cluster struct {
+	Primary *sql.DB
+	// ReadYourWritesWindow is how long a user's reads stay on the primary
+	// after a write
+	ReadYourWritesWindow time.Duration
+	// Actor returns the user a request acts for. Read-your-writes is only
+	// provided when it is set.
+	Actor func(ctx context.Context) (int64, bool)
+
+	replicas []*replica
+	next     atomic.Uint64
+
+	mu         sync.Mutex
+	lastWrites map[int64]time.Time
+	now        func() time.Time
+}
+
+type replica struct {
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// NewCluster creates a Cluster. Replicas start out healthy.
+func NewCluster(primary *sql.DB, replicas ...*sql.DB) *Cluster {
+	c := &Cluster{
+		Primary:              primary,
+		ReadYourWritesWindow: DefaultReadYourWritesWindow,
+		lastWrites:           make(map[int64]time.Time),
+		now:                  time.Now,
+	}
+	for _, db := range replicas {
+		r := &replica{db: db}
+		r.healthy.Store(true)
+		c.replicas = append(c.replicas, r)
+	}
+	return c
+}
+
+// Read runs fn against a replica, or against the primary if no replica is
+// healthy, the acting user wrote recently or ctx comes from WithPrimary. If
+// a replica fails with a connection error it is marked unhealthy and fn is
+// retried on the primary.
+func (c *Cluster) Read(ctx context.Context, fn func(db *sql.DB) error) error {
+	r := c.pickReplica(ctx)
+	if r == nil {
+		return fn(c.Primary)
+	}
+
+	err := fn(r.db)
+	if err == nil || !isConnectionError(err) || ctx.Err() != nil {
+		return err
+	}
+	slog.WarnContext(ctx, "read replica failed, falling back to primary", "error", err)
+	r.healthy.Store(false)
+	return fn(c.Primary)
+}
+
+type primaryKey struct{}
+
+// WithPrimary returns a context whose reads go to the primary, for reads
+// that must not miss a recent write, whoever made it
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// primaryRequested reports whether ctx comes from WithPrimary
+func primaryRequested(ctx context.Context) bool {
+	requested, _ := ctx.Value(primaryKey{}).(bool)
+	return requested
+}
+
+// MarkWrite records that the acting user just wrote, so their reads go to
+// the primary for ReadYourWritesWindow
+func (c *Cluster) MarkWrite(ctx context.Context) {
+	if len(c.replicas) == 0 || c.Actor == nil {
+		return
+	}
+	userID, ok := c.Actor(ctx)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.lastWrites[userID] = now
+	// Forget users whose window has passed so the map stays small
+	for id, at := range c.lastWrites {
+		if now.Sub(at) > c.ReadYourWritesWindow {
+			delete(c.lastWrites, id)
+		}
+	}
+}
+
+// pickReplica returns the replica to read from, or nil to use the primary
+func (c *Cluster) pickReplica(ctx context.Context) *replica {
+	if len(c.replicas) == 0 || primaryRequested(ctx) || c.wroteRecently(ctx) {
+		return nil
+	}
+
+	// Round-robin over the healthy replicas
+	start := c.next.Add(1)
+	for i := range c.replicas {
+		r := c.replicas[(start+uint64(i))%uint64(len(c.replicas))]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) wroteRecently(ctx context.Context) bool {
+	if c.Actor == nil {
+		return false
+	}
+	userID, ok := c.Actor(ctx)
+	if !ok {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	at, ok := c.lastWrites[userID]
+	return ok && c.now().Sub(at) <= c.ReadYourWritesWindow
+}
+
+// CheckReplicas pings every replica and records whether it is healthy
+func (c *Cluster) CheckReplicas(ctx context.Context, timeout time.Duration) {
+	for _, r := range c.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := r.db.PingContext(pingCtx)
+		cancel()
+
+		healthy := err == nil
+		if was := r.healthy.Swap(healthy); was != healthy {
+			if healthy {
+				slog.InfoContext(ctx, "read replica recovered")
+			} else {
+				slog.WarnContext(ctx, "read replica unhealthy", "error", err)
+			}
+		}
+	}
+}
+
+// MonitorReplicas checks the replicas every interval until ctx is cancelled
+func (c *Cluster) MonitorReplicas(ctx context.Context, interval, timeout time.Duration) {
+	if len(c.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.CheckReplicas(ctx, timeout)
+		}
+	}
+}
+
+// HealthyReplicas returns the number of replicas currently used for reads
+func (c *Cluster) HealthyReplicas() int {
+	n := 0
+	for _, r := range c.replicas {
+		if r.healthy.Load() {
+			n++
+		}
+	}
+	return n
+}
+
+// Close closes the primary and every replica
+func (c *Cluster) Close() error {
+	err := c.Primary.Close()
+	for _, r := range c.replicas {
+		err = errors.Join(err, r.db.Close())
+	}
+	return err
+}
+
+// isConnectionError reports whether err means the database could not be
+// reached, as opposed to the query itself failing
+func isConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// Class 08 is connection exceptions, 57P is the server shutting down
+		// or in recovery
+		code := string(pqErr.Code)
+		return strings.HasPrefix(code, "08") || strings.HasPrefix(code, "57P")
+	}
+	return false
+}
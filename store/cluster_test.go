@@ -0,0 +1,212 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"backend-app/cache"
+	"backend-app/models"
+)
+
+// actorKey carries the acting user in cluster tests
+type actorKey struct{}
+
+func asUser(userID int64) context.Context {
+	return context.WithValue(context.Background(), actorKey{}, userID)
+}
+
+func testCluster(t *testing.T, replicas int) (*Cluster, []*sql.DB) {
+	t.Helper()
+
+	open := func() *sql.DB {
+		db := sql.OpenDB(&flakyConnector{})
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+	var dbs []*sql.DB
+	for i := 0; i < replicas; i++ {
+		dbs = append(dbs, open())
+	}
+	c := NewCluster(open(), dbs...)
+	c.Actor = func(ctx context.Context) (int64, bool) {
+		userID, ok := ctx.Value(actorKey{}).(int64)
+		return userID, ok
+	}
+	return c, dbs
+}
+
+// readFrom returns the database a read was routed to
+func readFrom(c *Cluster, ctx context.Context) *sql.DB {
+	var used *sql.DB
+	c.Read(ctx, func(db *sql.DB) error {
+		used = db
+		return nil
+	})
+	return used
+}
+
+func TestReadsGoToReplicas(t *testing.T) {
+	c, replicas := testCluster(t, 2)
+
+	seen := make(map[*sql.DB]bool)
+	for i := 0; i < 4; i++ {
+		seen[readFrom(c, context.Background())] = true
+	}
+	if len(seen) != 2 || !seen[replicas[0]] || !seen[replicas[1]] {
+		t.Errorf("reads were not spread over both replicas")
+	}
+}
+
+func TestReadsWithoutReplicasGoToPrimary(t *testing.T) {
+	c, _ := testCluster(t, 0)
+
+	if readFrom(c, context.Background()) != c.Primary {
+		t.Error("read did not go to the primary")
+	}
+}
+
+func TestReadYourWrites(t *testing.T) {
+	c, _ := testCluster(t, 1)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	c.MarkWrite(asUser(1))
+
+	if readFrom(c, asUser(1)) != c.Primary {
+		t.Error("writer's read did not go to the primary")
+	}
+	if readFrom(c, asUser(2)) == c.Primary {
+		t.Error("another user's read went to the primary")
+	}
+
+	now = now.Add(c.ReadYourWritesWindow + time.Second)
+	if readFrom(c, asUser(1)) == c.Primary {
+		t.Error("writer's read still went to the primary after the window")
+	}
+}
+
+func TestReadsWithPrimary(t *testing.T) {
+	c, _ := testCluster(t, 2)
+
+	for i := 0; i < 2; i++ {
+		if readFrom(c, WithPrimary(context.Background())) != c.Primary {
+			t.Fatal("read with WithPrimary did not go to the primary")
+		}
+	}
+}
+
+// laggingRecipes serves GetRecipeByID through a cluster whose replica has
+// not yet replicated the last update
+type laggingRecipes struct {
+	RecipeStore
+	cluster          *Cluster
+	replica          *sql.DB
+	current, lagging models.Recipe
+}
+
+func (s *laggingRecipes) GetRecipeByID(ctx context.Context, recipeID int64) (*models.Recipe, error) {
+	var recipe models.Recipe
+	err := s.cluster.Read(ctx, func(db *sql.DB) error {
+		recipe = s.current
+		if db == s.replica {
+			recipe = s.lagging
+		}
+		return nil
+	})
+	return &recipe, err
+}
+
+func (s *laggingRecipes) UpdateRecipe(ctx context.Context, recipe *models.Recipe) error {
+	s.lagging = s.current
+	s.current = *recipe
+	s.current.Version++
+	return nil
+}
+
+func TestCacheMissesReadPrimary(t *testing.T) {
+	c, replicas := testCluster(t, 1)
+	original := models.Recipe{ID: 1, Title: "Shiro", Version: 1}
+	backing := &laggingRecipes{cluster: c, replica: replicas[0], current: original, lagging: original}
+	recipes := &CachedRecipeStore{Next: backing, Cache: cache.New(cache.NewLRU(10)), TTL: time.Minute}
+	ctx := context.Background()
+
+	if _, err := recipes.GetRecipeByID(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	edited := original
+	edited.Title = "Spicy shiro"
+	if err := recipes.UpdateRecipe(ctx, &edited); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := backing.GetRecipeByID(ctx, 1); got.Title != "Shiro" {
+		t.Fatalf("replica has %q, want it lagging behind", got.Title)
+	}
+
+	// The miss after the invalidation must not cache the replica's copy
+	for i := 0; i < 2; i++ {
+		if got, _ := recipes.GetRecipeByID(ctx, 1); got.Title != "Spicy shiro" || got.Version != 2 {
+			t.Errorf("recipe = %+v, want the update", got)
+		}
+	}
+
+	// Reads that must see every write skip the cache
+	backing.current.Title = "Shiro wot"
+	if got, _ := recipes.GetRecipeByID(WithPrimary(ctx), 1); got.Title != "Shiro wot" {
+		t.Errorf("title = %q with WithPrimary, want the primary's", got.Title)
+	}
+}
+
+func TestReplicaConnectionErrorFailsOver(t *testing.T) {
+	c, replicas := testCluster(t, 1)
+
+	var used []*sql.DB
+	err := c.Read(context.Background(), func(db *sql.DB) error {
+		used = append(used, db)
+		if db == replicas[0] {
+			return translateError("retrieving recipes", driver.ErrBadConn)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(used) != 2 || used[1] != c.Primary {
+		t.Errorf("read was not retried on the primary")
+	}
+	if c.HealthyReplicas() != 0 {
+		t.Error("failed replica is still considered healthy")
+	}
+
+	// Query errors are the caller's problem and are not retried
+	used = nil
+	c.CheckReplicas(context.Background(), time.Second)
+	err = c.Read(context.Background(), func(db *sql.DB) error {
+		used = append(used, db)
+		return sql.ErrNoRows
+	})
+	if err != sql.ErrNoRows || len(used) != 1 {
+		t.Errorf("Read = %v after %d attempts, want sql.ErrNoRows after 1", err, len(used))
+	}
+}
+
+func TestCheckReplicas(t *testing.T) {
+	down := sql.OpenDB(&flakyConnector{failures: 1 << 30})
+	defer down.Close()
+	up := sql.OpenDB(&flakyConnector{})
+	defer up.Close()
+	c := NewCluster(up, down, up)
+
+	c.CheckReplicas(context.Background(), time.Second)
+
+	if got := c.HealthyReplicas(); got != 1 {
+		t.Errorf("healthy replicas = %d, want 1", got)
+	}
+	for i := 0; i < 3; i++ {
+		if readFrom(c, context.Background()) == down {
+			t.Fatal("read went to an unhealthy replica")
+		}
+	}
+}
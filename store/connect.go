@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Backoff bounds between connection attempts in Ping
+const (
+	minPingBackoff = 250 * time.Millisecond
+	maxPingBackoff = 5 * time.Second
+)
+
+// Ping checks that db is reachable, retrying with exponential backoff until
+// it answers or timeout has elapsed. It is meant for startup, when the
+// database may still be coming up.
+func Ping(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := minPingBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("database unreachable after %d attempts: %w", attempt, err)
+		}
+
+		slog.WarnContext(ctx, "database not reachable, retrying", "attempt", attempt, "backoff", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("database unreachable after %d attempts: %w", attempt, err)
+		}
+		backoff = min(2*backoff, maxPingBackoff)
+	}
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyConnector fails the first failures connection attempts
+type flakyConnector struct {
+	failures int32
+	attempts atomic.Int32
+}
+
+func (c *flakyConnector) Connect(context.Context) (driver.Conn, error) {
+	if c.attempts.Add(1) <= c.failures {
+		return nil, errors.New("connection refused")
+	}
+	return fakeConn{}, nil
+}
+
+func (c *flakyConnector) Driver() driver.Driver { return nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func TestPingRetriesUntilReachable(t *testing.T) {
+	connector := &flakyConnector{failures: 2}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	if err := Ping(context.Background(), db, 10*time.Second); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if got := connector.attempts.Load(); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+}
+
+func TestPingGivesUpAfterTimeout(t *testing.T) {
+	db := sql.OpenDB(&flakyConnector{failures: 1 << 30})
+	defer db.Close()
+
+	start := time.Now()
+	err := Ping(context.Background(), db, 600*time.Millisecond)
+	if err == nil {
+		t.Fatal("Ping succeeded against an unreachable database")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Ping took %s, want it to stop at the timeout", elapsed)
+	}
+}
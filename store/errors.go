@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Sentinel errors returned by every store. Callers branch on them with
+// errors.Is; the underlying driver error stays wrapped for logging.
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a write violates a uniqueness constraint
+	ErrConflict = errors.New("record already exists")
+	// ErrInvalidReference is returned when a write references a record that
+	// does not exist, or a delete would leave references dangling
+	ErrInvalidReference = errors.New("referenced record does not exist")
+	// ErrStale is returned when an update names a version of the record
+	// that is no longer current
+	ErrStale = errors.New("record has been modified")
+)
+
+// DefaultQueryTimeout bounds each store call unless the store is configured
+// otherwise
+const DefaultQueryTimeout = 5 * time.Second
+
+// PostgreSQL error codes translated to sentinel errors
+const (
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+)
+
+// translateError wraps err with the sentinel error it corresponds to and
+// with a description of the failed operation
+func translateError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case pqUniqueViolation:
+			return fmt.Errorf("%s: %w: %w", op, ErrConflict, err)
+		case pqForeignKeyViolation:
+			return fmt.Errorf("%s: %w: %w", op, ErrInvalidReference, err)
+		}
+	}
+
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+// withTimeout derives a context that is cancelled after timeout, or after
+// DefaultQueryTimeout when timeout is not set
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = DefaultQueryTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,347 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"backend-app/models"
+)
+
+// Observer is told how long each store method took and how it ended
+type Observer interface {
+	ObserveQuery(store, method string, duration time.Duration, err error)
+}
+
+// begin starts a span named after method, e.g. "CreateRecipe", so that the
+// spans of the statements it runs are grouped under it. The returned
+// function ends the span and reports the call to obs, if set.
+func begin(ctx context.Context, obs Observer, store, method string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := otel.Tracer("backend-app/store").Start(ctx, method,
+		trace.WithAttributes(attribute.String("store", store)))
+
+	return ctx, func(err error) {
+		// Missing records and conflicts are answers, not failures
+		if err != nil && !isSentinel(err) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		if obs != nil {
+			obs.ObserveQuery(store, method, time.Since(start), err)
+		}
+	}
+}
+
+// isSentinel reports whether err is one of the sentinel errors every store
+// returns
+func isSentinel(err error) bool {
+	return errors.Is(err, ErrNotFound) || errors.Is(err, ErrConflict) || errors.Is(err, ErrInvalidReference) ||
+		errors.Is(err, ErrStale)
+}
+
+// InstrumentedUserStore times and traces every UserStore call
+type InstrumentedUserStore struct {
+	Next     UserStore
+	Observer Observer
+}
+
+func (s *InstrumentedUserStore) CreateUser(ctx context.Context, user *models.User) (err error) {
+	ctx, end := begin(ctx, s.Observer, "user", "CreateUser")
+	defer func() { end(err) }()
+	return s.Next.CreateUser(ctx, user)
+}
+
+func (s *InstrumentedUserStore) UpdateUser(ctx context.Context, user *models.User) (err error) {
+	ctx, end := begin(ctx, s.Observer, "user", "UpdateUser")
+	defer func() { end(err) }()
+	return s.Next.UpdateUser(ctx, user)
+}
+
+func (s *InstrumentedUserStore) DeleteUser(ctx context.Context, userID int64) (err error) {
+	ctx, end := begin(ctx, s.Observer, "user", "DeleteUser")
+	defer func() { end(err) }()
+	return s.Next.DeleteUser(ctx, userID)
+}
+
+func (s *InstrumentedUserStore) GetUserByID(ctx context.Context, userID int64) (user *models.User, err error) {
+	ctx, end := begin(ctx, s.Observer, "user", "GetUserByID")
+	defer func() { end(err) }()
+	return s.Next.GetUserByID(ctx, userID)
+}
+
+func (s *InstrumentedUserStore) GetUserByEmail(ctx context.Context, email string) (user *models.User, err error) {
+	ctx, end := begin(ctx, s.Observer, "user", "GetUserByEmail")
+	defer func() { end(err) }()
+	return s.Next.GetUserByEmail(ctx, email)
+}
+
+func (s *InstrumentedUserStore) GetUserByUsername(ctx context.Context, username string) (user *models.User, err error) {
+	ctx, end := begin(ctx, s.Observer, "user", "GetUserByUsername")
+	defer func() { end(err) }()
+	return s.Next.GetUserByUsername(ctx, username)
+}
+
+func (s *InstrumentedUserStore) GetDeletedUserByEmail(ctx context.Context, email string, deletedAfter time.Time) (user *models.User, err error) {
+	ctx, end := begin(ctx, s.Observer, "user", "GetDeletedUserByEmail")
+	defer func() { end(err) }()
+	return s.Next.GetDeletedUserByEmail(ctx, email, deletedAfter)
+}
+
+func (s *InstrumentedUserStore) RestoreUser(ctx context.Context, userID int64, deletedAfter time.Time) (err error) {
+	ctx, end := begin(ctx, s.Observer, "user", "RestoreUser")
+	defer func() { end(err) }()
+	return s.Next.RestoreUser(ctx, userID, deletedAfter)
+}
+
+func (s *InstrumentedUserStore) PurgeUsers(ctx context.Context, before time.Time) (purged int64, err error) {
+	ctx, end := begin(ctx, s.Observer, "user", "PurgeUsers")
+	defer func() { end(err) }()
+	return s.Next.PurgeUsers(ctx, before)
+}
+
+// InstrumentedCategoryStore times and traces every CategoryStore call
+type InstrumentedCategoryStore struct {
+	Next     CategoryStore
+	Observer Observer
+}
+
+func (s *InstrumentedCategoryStore) CreateCategory(ctx context.Context, category *models.Category) (err error) {
+	ctx, end := begin(ctx, s.Observer, "category", "CreateCategory")
+	defer func() { end(err) }()
+	return s.Next.CreateCategory(ctx, category)
+}
+
+func (s *InstrumentedCategoryStore) UpdateCategory(ctx context.Context, category *models.Category) (err error) {
+	ctx, end := begin(ctx, s.Observer, "category", "UpdateCategory")
+	defer func() { end(err) }()
+	return s.Next.UpdateCategory(ctx, category)
+}
+
+func (s *InstrumentedCategoryStore) DeleteCategory(ctx context.Context, categoryID, reassignTo int64) (moved []int64, err error) {
+	ctx, end := begin(ctx, s.Observer, "category", "DeleteCategory")
+	defer func() { end(err) }()
+	return s.Next.DeleteCategory(ctx, categoryID, reassignTo)
+}
+
+func (s *InstrumentedCategoryStore) GetCategoryByID(ctx context.Context, categoryID int64) (category *models.Category, err error) {
+	ctx, end := begin(ctx, s.Observer, "category", "GetCategoryByID")
+	defer func() { end(err) }()
+	return s.Next.GetCategoryByID(ctx, categoryID)
+}
+
+func (s *InstrumentedCategoryStore) GetAllCategories(ctx context.Context) (categories []*models.Category, err error) {
+	ctx, end := begin(ctx, s.Observer, "category", "GetAllCategories")
+	defer func() { end(err) }()
+	return s.Next.GetAllCategories(ctx)
+}
+
+func (s *InstrumentedCategoryStore) PurgeCategories(ctx context.Context, before time.Time) (purged int64, err error) {
+	ctx, end := begin(ctx, s.Observer, "category", "PurgeCategories")
+	defer func() { end(err) }()
+	return s.Next.PurgeCategories(ctx, before)
+}
+
+// InstrumentedRecipeStore times and traces every RecipeStore call
+type InstrumentedRecipeStore struct {
+	Next     RecipeStore
+	Observer Observer
+}
+
+func (s *InstrumentedRecipeStore) CreateRecipe(ctx context.Context, recipe *models.Recipe) (created *models.Recipe, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "CreateRecipe")
+	defer func() { end(err) }()
+	return s.Next.CreateRecipe(ctx, recipe)
+}
+
+func (s *InstrumentedRecipeStore) UpdateRecipe(ctx context.Context, recipe *models.Recipe) (err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "UpdateRecipe")
+	defer func() { end(err) }()
+	return s.Next.UpdateRecipe(ctx, recipe)
+}
+
+func (s *InstrumentedRecipeStore) DeleteRecipe(ctx context.Context, recipeID, deletedBy int64) (err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "DeleteRecipe")
+	defer func() { end(err) }()
+	return s.Next.DeleteRecipe(ctx, recipeID, deletedBy)
+}
+
+func (s *InstrumentedRecipeStore) GetRecipeByID(ctx context.Context, recipeID int64) (recipe *models.Recipe, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "GetRecipeByID")
+	defer func() { end(err) }()
+	return s.Next.GetRecipeByID(ctx, recipeID)
+}
+
+func (s *InstrumentedRecipeStore) GetAllRecipes(ctx context.Context, viewerID int64) (recipes []*models.Recipe, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "GetAllRecipes")
+	defer func() { end(err) }()
+	return s.Next.GetAllRecipes(ctx, viewerID)
+}
+
+func (s *InstrumentedRecipeStore) ListForks(ctx context.Context, recipeID, viewerID int64) (forks []*models.Recipe, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "ListForks")
+	defer func() { end(err) }()
+	return s.Next.ListForks(ctx, recipeID, viewerID)
+}
+
+func (s *InstrumentedRecipeStore) SetRecipeStatus(ctx context.Context, recipe *models.Recipe) (err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "SetRecipeStatus")
+	defer func() { end(err) }()
+	return s.Next.SetRecipeStatus(ctx, recipe)
+}
+
+func (s *InstrumentedRecipeStore) PublishDueRecipes(ctx context.Context, now time.Time) (published []int64, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "PublishDueRecipes")
+	defer func() { end(err) }()
+	return s.Next.PublishDueRecipes(ctx, now)
+}
+
+func (s *InstrumentedRecipeStore) SetRecipeVisibility(ctx context.Context, recipe *models.Recipe) (err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "SetRecipeVisibility")
+	defer func() { end(err) }()
+	return s.Next.SetRecipeVisibility(ctx, recipe)
+}
+
+func (s *InstrumentedRecipeStore) GetRecipeByShareToken(ctx context.Context, token string) (recipe *models.Recipe, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "GetRecipeByShareToken")
+	defer func() { end(err) }()
+	return s.Next.GetRecipeByShareToken(ctx, token)
+}
+
+func (s *InstrumentedRecipeStore) ShareRecipe(ctx context.Context, share *models.RecipeShare, sharedBy int64) (err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "ShareRecipe")
+	defer func() { end(err) }()
+	return s.Next.ShareRecipe(ctx, share, sharedBy)
+}
+
+func (s *InstrumentedRecipeStore) UnshareRecipe(ctx context.Context, recipeID, userID int64) (err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "UnshareRecipe")
+	defer func() { end(err) }()
+	return s.Next.UnshareRecipe(ctx, recipeID, userID)
+}
+
+func (s *InstrumentedRecipeStore) GetShare(ctx context.Context, recipeID, userID int64) (share *models.RecipeShare, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "GetShare")
+	defer func() { end(err) }()
+	return s.Next.GetShare(ctx, recipeID, userID)
+}
+
+func (s *InstrumentedRecipeStore) ListShares(ctx context.Context, recipeID int64) (shares []*models.RecipeShare, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "ListShares")
+	defer func() { end(err) }()
+	return s.Next.ListShares(ctx, recipeID)
+}
+
+func (s *InstrumentedRecipeStore) InviteAuthor(ctx context.Context, invitation *models.Invitation) (err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "InviteAuthor")
+	defer func() { end(err) }()
+	return s.Next.InviteAuthor(ctx, invitation)
+}
+
+func (s *InstrumentedRecipeStore) GetInvitation(ctx context.Context, invitationID int64) (invitation *models.Invitation, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "GetInvitation")
+	defer func() { end(err) }()
+	return s.Next.GetInvitation(ctx, invitationID)
+}
+
+func (s *InstrumentedRecipeStore) ListInvitations(ctx context.Context, inviteeID int64) (invitations []*models.Invitation, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "ListInvitations")
+	defer func() { end(err) }()
+	return s.Next.ListInvitations(ctx, inviteeID)
+}
+
+func (s *InstrumentedRecipeStore) AcceptInvitation(ctx context.Context, invitationID int64) (author *models.RecipeAuthor, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "AcceptInvitation")
+	defer func() { end(err) }()
+	return s.Next.AcceptInvitation(ctx, invitationID)
+}
+
+func (s *InstrumentedRecipeStore) DeleteInvitation(ctx context.Context, invitationID int64) (err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "DeleteInvitation")
+	defer func() { end(err) }()
+	return s.Next.DeleteInvitation(ctx, invitationID)
+}
+
+func (s *InstrumentedRecipeStore) GetAuthor(ctx context.Context, recipeID, userID int64) (author *models.RecipeAuthor, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "GetAuthor")
+	defer func() { end(err) }()
+	return s.Next.GetAuthor(ctx, recipeID, userID)
+}
+
+func (s *InstrumentedRecipeStore) ListAuthors(ctx context.Context, recipeID int64) (authors []*models.RecipeAuthor, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "ListAuthors")
+	defer func() { end(err) }()
+	return s.Next.ListAuthors(ctx, recipeID)
+}
+
+func (s *InstrumentedRecipeStore) ListAuthorships(ctx context.Context, userID int64) (authorships []*models.RecipeAuthor, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "ListAuthorships")
+	defer func() { end(err) }()
+	return s.Next.ListAuthorships(ctx, userID)
+}
+
+func (s *InstrumentedRecipeStore) RemoveAuthor(ctx context.Context, recipeID, userID int64) (err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "RemoveAuthor")
+	defer func() { end(err) }()
+	return s.Next.RemoveAuthor(ctx, recipeID, userID)
+}
+
+func (s *InstrumentedRecipeStore) ListRevisions(ctx context.Context, recipeID int64) (revisions []*models.Revision, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "ListRevisions")
+	defer func() { end(err) }()
+	return s.Next.ListRevisions(ctx, recipeID)
+}
+
+func (s *InstrumentedRecipeStore) GetRevision(ctx context.Context, recipeID, version int64) (revision *models.Revision, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "GetRevision")
+	defer func() { end(err) }()
+	return s.Next.GetRevision(ctx, recipeID, version)
+}
+
+func (s *InstrumentedRecipeStore) ListDeletedRecipes(ctx context.Context, creatorID int64) (recipes []*models.Recipe, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "ListDeletedRecipes")
+	defer func() { end(err) }()
+	return s.Next.ListDeletedRecipes(ctx, creatorID)
+}
+
+func (s *InstrumentedRecipeStore) RestoreRecipe(ctx context.Context, recipeID, creatorID int64, deletedAfter time.Time) (err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "RestoreRecipe")
+	defer func() { end(err) }()
+	return s.Next.RestoreRecipe(ctx, recipeID, creatorID, deletedAfter)
+}
+
+func (s *InstrumentedRecipeStore) PurgeRecipes(ctx context.Context, before time.Time) (purged int64, err error) {
+	ctx, end := begin(ctx, s.Observer, "recipe", "PurgeRecipes")
+	defer func() { end(err) }()
+	return s.Next.PurgeRecipes(ctx, before)
+}
+
+// InstrumentedMediaStore times and traces every MediaStore call
+type InstrumentedMediaStore struct {
+	Next     MediaStore
+	Observer Observer
+}
+
+func (s *InstrumentedMediaStore) TrackMedia(ctx context.Context, path string) (err error) {
+	ctx, end := begin(ctx, s.Observer, "media", "TrackMedia")
+	defer func() { end(err) }()
+	return s.Next.TrackMedia(ctx, path)
+}
+
+func (s *InstrumentedMediaStore) OrphanedMedia(ctx context.Context, before time.Time) (paths []string, err error) {
+	ctx, end := begin(ctx, s.Observer, "media", "OrphanedMedia")
+	defer func() { end(err) }()
+	return s.Next.OrphanedMedia(ctx, before)
+}
+
+func (s *InstrumentedMediaStore) ForgetMedia(ctx context.Context, path string) (err error) {
+	ctx, end := begin(ctx, s.Observer, "media", "ForgetMedia")
+	defer func() { end(err) }()
+	return s.Next.ForgetMedia(ctx, path)
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type PostgresMediaStore struct {
+	DB      *sql.DB
+	Timeout time.Duration // Per-query timeout, DefaultQueryTimeout if zero
+}
+
+// NewPostgresMediaStore initializes a new PostgresMediaStore
+func NewPostgresMediaStore(db *sql.DB) *PostgresMediaStore {
+	return &PostgresMediaStore{
+		DB:      db,
+		Timeout: DefaultQueryTimeout,
+	}
+}
+
+// TrackMedia records a newly stored blob
+func (mr *PostgresMediaStore) TrackMedia(ctx context.Context, path string) error {
+	ctx, cancel := withTimeout(ctx, mr.Timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO media (path)
+		VALUES ($1)
+		ON CONFLICT (path) DO UPDATE SET released_at = NULL
+	`
+	_, err := mr.DB.ExecContext(ctx, query, path)
+	return translateError("tracking media", err)
+}
+
+// OrphanedMedia returns tracked blobs that no recipe or revision references
+// and that were released (or, if never attached, uploaded) before the given
+// time
+func (mr *PostgresMediaStore) OrphanedMedia(ctx context.Context, before time.Time) ([]string, error) {
+	ctx, cancel := withTimeout(ctx, mr.Timeout)
+	defer cancel()
+
+	var paths []string
+	query := `
+		SELECT m.path
+		FROM media m
+		WHERE COALESCE(m.released_at, m.created_at) < $1
+		AND NOT EXISTS (SELECT 1 FROM recipes r WHERE m.path = ANY(r.images))
+		AND NOT EXISTS (SELECT 1 FROM recipe_revisions v WHERE m.path = ANY(v.images))
+	`
+	rows, err := mr.DB.QueryContext(ctx, query, before)
+	if err != nil {
+		return nil, translateError("retrieving orphaned media", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, translateError("scanning media row", err)
+		}
+		paths = append(paths, path)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError("iterating over media rows", err)
+	}
+
+	return paths, nil
+}
+
+// ForgetMedia deletes the tracking record of a blob
+func (mr *PostgresMediaStore) ForgetMedia(ctx context.Context, path string) error {
+	ctx, cancel := withTimeout(ctx, mr.Timeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM media
+		WHERE path = $1
+	`
+	_, err := mr.DB.ExecContext(ctx, query, path)
+	return translateError("forgetting media", err)
+}
@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"backend-app/models"
+	"backend-app/store"
+)
+
+type CategoryStore struct {
+	DB *DB
+}
+
+var _ store.CategoryStore = (*CategoryStore)(nil)
+
+// NewCategoryStore initializes a new CategoryStore
+func NewCategoryStore(db *DB) *CategoryStore {
+	return &CategoryStore{
+		DB: db,
+	}
+}
+
+// CreateCategory creates a new category
+func (cr *CategoryStore) CreateCategory(ctx context.Context, category *models.Category) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	cr.DB.mu.Lock()
+	defer cr.DB.mu.Unlock()
+
+	if cr.nameTaken(category.Name, 0) {
+		return fmt.Errorf("creating category: %w", store.ErrConflict)
+	}
+
+	cr.DB.lastCategoryID++
+	category.ID = int(cr.DB.lastCategoryID)
+	cr.DB.categories[cr.DB.lastCategoryID] = *category
+	return nil
+}
+
+// UpdateCategory updates an existing category
+func (cr *CategoryStore) UpdateCategory(ctx context.Context, category *models.Category) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	cr.DB.mu.Lock()
+	defer cr.DB.mu.Unlock()
+
+	if !cr.DB.categoryLive(int64(category.ID)) {
+		return fmt.Errorf("updating category: %w", store.ErrNotFound)
+	}
+	if cr.nameTaken(category.Name, int64(category.ID)) {
+		return fmt.Errorf("updating category: %w", store.ErrConflict)
+	}
+
+	cr.DB.categories[int64(category.ID)] = *category
+	return nil
+}
+
+// DeleteCategory deletes a category and moves its recipes to reassignTo,
+// saving the move as a new version of each, and returns the moved recipes'
+// IDs. A zero reassignTo blocks the deletion while live recipes belong to
+// the category.
+func (cr *CategoryStore) DeleteCategory(ctx context.Context, categoryID, reassignTo int64) ([]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cr.DB.mu.Lock()
+	defer cr.DB.mu.Unlock()
+
+	if !cr.DB.categoryLive(categoryID) {
+		return nil, fmt.Errorf("deleting category: %w", store.ErrNotFound)
+	}
+	if reassignTo == 0 {
+		inUse := cr.DB.recipeReferences(func(recipe models.Recipe) bool {
+			return recipe.CategoryID == categoryID && recipe.DeletedAt == nil
+		})
+		if inUse {
+			return nil, fmt.Errorf("deleting category: %w", store.ErrInvalidReference)
+		}
+	} else if reassignTo == categoryID || !cr.DB.categoryLive(reassignTo) {
+		return nil, fmt.Errorf("deleting category: %w", store.ErrInvalidReference)
+	}
+
+	now := cr.DB.Now()
+	cr.DB.deletedCategories[categoryID] = now
+	var moved []int64
+	for id, recipe := range cr.DB.recipes {
+		if recipe.CategoryID != categoryID {
+			continue
+		}
+		recipe.CategoryID = reassignTo
+		recipe.UpdatedBy = 0
+		recipe.Version++
+		recipe.UpdatedAt = now
+		cr.DB.recipes[id] = recipe
+		cr.DB.saveRevision(recipe)
+		moved = append(moved, id)
+	}
+	return moved, nil
+}
+
+// PurgeCategories permanently deletes categories deleted before the given
+// time. Their recipes were moved when they were deleted.
+func (cr *CategoryStore) PurgeCategories(ctx context.Context, before time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	cr.DB.mu.Lock()
+	defer cr.DB.mu.Unlock()
+
+	var purged int64
+	for id, deletedAt := range cr.DB.deletedCategories {
+		if !deletedAt.Before(before) {
+			continue
+		}
+		delete(cr.DB.categories, id)
+		delete(cr.DB.deletedCategories, id)
+		purged++
+	}
+	return purged, nil
+}
+
+// GetCategoryByID retrieves a category by ID
+func (cr *CategoryStore) GetCategoryByID(ctx context.Context, categoryID int64) (*models.Category, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cr.DB.mu.RLock()
+	defer cr.DB.mu.RUnlock()
+
+	category, ok := cr.DB.categories[categoryID]
+	if !ok || !cr.DB.categoryLive(categoryID) {
+		return nil, fmt.Errorf("retrieving category: %w", store.ErrNotFound)
+	}
+	return &category, nil
+}
+
+// GetAllCategories retrieves all categories ordered by ID
+func (cr *CategoryStore) GetAllCategories(ctx context.Context) ([]*models.Category, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cr.DB.mu.RLock()
+	defer cr.DB.mu.RUnlock()
+
+	var categories []*models.Category
+	for id, category := range cr.DB.categories {
+		if !cr.DB.categoryLive(id) {
+			continue
+		}
+		category := category
+		categories = append(categories, &category)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+	return categories, nil
+}
+
+// nameTaken reports whether another live category than exceptID uses the
+// name. The caller must hold the DB lock.
+func (cr *CategoryStore) nameTaken(name string, exceptID int64) bool {
+	for id, category := range cr.DB.categories {
+		if id != exceptID && category.Name == name && cr.DB.categoryLive(id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+// Package memory implements the store interfaces in memory. It honours
+// the same constraints as the PostgreSQL schema (unique emails and category
+// names, foreign keys between recipes, users and categories) so it can stand
+// in for the database in tests.
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"backend-app/models"
+)
+
+// DB holds the tables shared by the in-memory stores. Stores created from the
+// same DB see each other's data, like stores sharing a *sql.DB.
+type DB struct {
+	mu sync.RWMutex
+
+	users       map[int64]models.User
+	categories  map[int64]models.Category
+	recipes     map[int64]models.Recipe
+	revisions   map[int64][]models.Revision             // By recipe, oldest first
+	shares      map[int64]map[int64]models.RecipeShare  // By recipe, then user
+	authors     map[int64]map[int64]models.RecipeAuthor // By recipe, then user
+	invitations map[int64]models.Invitation             // By ID
+	media       map[string]mediaRecord
+
+	// Deletion times of users and categories that have not been purged
+	deletedUsers      map[int64]time.Time
+	deletedCategories map[int64]time.Time
+
+	lastUserID       int64
+	lastCategoryID   int64
+	lastRecipeID     int64
+	lastInvitationID int64
+
+	// Now returns the current time; tests may replace it to control the
+	// timestamps recorded for media and recipes
+	Now func() time.Time
+}
+
+type mediaRecord struct {
+	createdAt  time.Time
+	releasedAt time.Time
+}
+
+// NewDB initializes an empty DB
+func NewDB() *DB {
+	return &DB{
+		users:       make(map[int64]models.User),
+		categories:  make(map[int64]models.Category),
+		recipes:     make(map[int64]models.Recipe),
+		revisions:   make(map[int64][]models.Revision),
+		shares:      make(map[int64]map[int64]models.RecipeShare),
+		authors:     make(map[int64]map[int64]models.RecipeAuthor),
+		invitations: make(map[int64]models.Invitation),
+		media:       make(map[string]mediaRecord),
+		Now:         time.Now,
+
+		deletedUsers:      make(map[int64]time.Time),
+		deletedCategories: make(map[int64]time.Time),
+	}
+}
+
+// recipeReferences reports whether any recipe matches the predicate. The
+// caller must hold db.mu.
+func (db *DB) recipeReferences(match func(models.Recipe) bool) bool {
+	for _, recipe := range db.recipes {
+		if match(recipe) {
+			return true
+		}
+	}
+	return false
+}
+
+// saveRevision records the current version of a recipe. The caller must
+// hold db.mu for writing.
+func (db *DB) saveRevision(recipe models.Recipe) {
+	db.revisions[recipe.ID] = append(db.revisions[recipe.ID], models.Revision{
+		RecipeID:    recipe.ID,
+		Version:     recipe.Version,
+		Title:       recipe.Title,
+		Description: recipe.Description,
+		Ingredients: cloneStrings(recipe.Ingredients),
+		Steps:       cloneStrings(recipe.Steps),
+		PrepTime:    recipe.PrepTime,
+		CategoryID:  recipe.CategoryID,
+		Images:      cloneStrings(recipe.Images),
+		EditorID:    recipe.UpdatedBy,
+		CreatedAt:   recipe.UpdatedAt,
+	})
+}
+
+// categoryLive reports whether a category exists and is not deleted. The
+// caller must hold db.mu.
+func (db *DB) categoryLive(categoryID int64) bool {
+	_, deleted := db.deletedCategories[categoryID]
+	_, ok := db.categories[categoryID]
+	return ok && !deleted
+}
+
+// userLive reports whether a user exists and is not deleted. The caller
+// must hold db.mu.
+func (db *DB) userLive(userID int64) bool {
+	_, deleted := db.deletedUsers[userID]
+	_, ok := db.users[userID]
+	return ok && !deleted
+}
+
+// imageReferenced reports whether any recipe or revision references the
+// image. The caller must hold db.mu.
+func (db *DB) imageReferenced(path string) bool {
+	if db.recipeReferences(func(recipe models.Recipe) bool { return contains(recipe.Images, path) }) {
+		return true
+	}
+	for _, revisions := range db.revisions {
+		for _, revision := range revisions {
+			if contains(revision.Images, path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// releaseImages marks tracked images that are not in keep as released. The
+// caller must hold db.mu for writing.
+func (db *DB) releaseImages(images, keep []string) {
+	now := db.Now()
+	for _, path := range images {
+		record, ok := db.media[path]
+		if !ok || contains(keep, path) {
+			continue
+		}
+		record.releasedAt = now
+		db.media[path] = record
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append([]string(nil), values...)
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"backend-app/store"
+)
+
+type MediaStore struct {
+	DB *DB
+}
+
+var _ store.MediaStore = (*MediaStore)(nil)
+
+// NewMediaStore initializes a new MediaStore
+func NewMediaStore(db *DB) *MediaStore {
+	return &MediaStore{
+		DB: db,
+	}
+}
+
+// TrackMedia records a newly stored blob
+func (mr *MediaStore) TrackMedia(ctx context.Context, path string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	mr.DB.mu.Lock()
+	defer mr.DB.mu.Unlock()
+
+	record, ok := mr.DB.media[path]
+	if !ok {
+		record.createdAt = mr.DB.Now()
+	}
+	record.releasedAt = time.Time{}
+	mr.DB.media[path] = record
+	return nil
+}
+
+// OrphanedMedia returns tracked blobs that no recipe references and that were
+// released (or, if never attached, uploaded) before the given time
+func (mr *MediaStore) OrphanedMedia(ctx context.Context, before time.Time) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	mr.DB.mu.RLock()
+	defer mr.DB.mu.RUnlock()
+
+	var paths []string
+	for path, record := range mr.DB.media {
+		since := record.releasedAt
+		if since.IsZero() {
+			since = record.createdAt
+		}
+		if since.Before(before) && !mr.DB.imageReferenced(path) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// ForgetMedia deletes the tracking record of a blob
+func (mr *MediaStore) ForgetMedia(ctx context.Context, path string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	mr.DB.mu.Lock()
+	defer mr.DB.mu.Unlock()
+
+	delete(mr.DB.media, path)
+	return nil
+}
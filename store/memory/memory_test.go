@@ -0,0 +1,20 @@
+package memory_test
+
+import (
+	"testing"
+
+	"backend-app/store/memory"
+	"backend-app/store/storetest"
+)
+
+func TestContract(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		db := memory.NewDB()
+		return storetest.Stores{
+			Users:      memory.NewUserStore(db),
+			Categories: memory.NewCategoryStore(db),
+			Recipes:    memory.NewRecipeStore(db),
+			Media:      memory.NewMediaStore(db),
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"backend-app/models"
+	"backend-app/store"
+)
+
+// InviteAuthor stores a pending invitation to become an author of a recipe
+// and sets its ID and creation time
+func (rr *RecipeStore) InviteAuthor(ctx context.Context, invitation *models.Invitation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rr.DB.mu.Lock()
+	defer rr.DB.mu.Unlock()
+
+	if !rr.live(invitation.RecipeID) {
+		return fmt.Errorf("inviting author: %w", store.ErrNotFound)
+	}
+	if !rr.DB.userLive(invitation.InvitedBy) || !rr.DB.userLive(invitation.InviteeID) {
+		return fmt.Errorf("inviting author: %w", store.ErrInvalidReference)
+	}
+	if _, ok := rr.DB.authors[invitation.RecipeID][invitation.InviteeID]; ok {
+		return fmt.Errorf("inviting author: %w", store.ErrConflict)
+	}
+	for _, pending := range rr.DB.invitations {
+		if pending.RecipeID == invitation.RecipeID && pending.InviteeID == invitation.InviteeID {
+			return fmt.Errorf("inviting author: %w", store.ErrConflict)
+		}
+	}
+
+	rr.DB.lastInvitationID++
+	invitation.ID = rr.DB.lastInvitationID
+	invitation.CreatedAt = rr.DB.Now()
+	rr.DB.invitations[invitation.ID] = *invitation
+	return nil
+}
+
+// GetInvitation retrieves a pending invitation by ID
+func (rr *RecipeStore) GetInvitation(ctx context.Context, invitationID int64) (*models.Invitation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.RLock()
+	defer rr.DB.mu.RUnlock()
+
+	invitation, ok := rr.DB.invitations[invitationID]
+	if !ok {
+		return nil, fmt.Errorf("retrieving invitation: %w", store.ErrNotFound)
+	}
+	return &invitation, nil
+}
+
+// ListInvitations retrieves a user's pending invitations to recipes that are
+// not deleted, oldest first
+func (rr *RecipeStore) ListInvitations(ctx context.Context, inviteeID int64) ([]*models.Invitation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.RLock()
+	defer rr.DB.mu.RUnlock()
+
+	var invitations []*models.Invitation
+	for _, invitation := range rr.DB.invitations {
+		if invitation.InviteeID != inviteeID || !rr.live(invitation.RecipeID) {
+			continue
+		}
+		invitation := invitation
+		invitations = append(invitations, &invitation)
+	}
+	sort.Slice(invitations, func(i, j int) bool {
+		if !invitations[i].CreatedAt.Equal(invitations[j].CreatedAt) {
+			return invitations[i].CreatedAt.Before(invitations[j].CreatedAt)
+		}
+		return invitations[i].ID < invitations[j].ID
+	})
+	return invitations, nil
+}
+
+// AcceptInvitation makes the invitee a co-author with the role they were
+// invited with and removes the invitation. Invitations to deleted accounts
+// are not found.
+func (rr *RecipeStore) AcceptInvitation(ctx context.Context, invitationID int64) (*models.RecipeAuthor, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.Lock()
+	defer rr.DB.mu.Unlock()
+
+	invitation, ok := rr.DB.invitations[invitationID]
+	if !ok || !rr.live(invitation.RecipeID) || !rr.DB.userLive(invitation.InviteeID) {
+		return nil, fmt.Errorf("accepting invitation: %w", store.ErrNotFound)
+	}
+
+	authors := rr.DB.authors[invitation.RecipeID]
+	if authors == nil {
+		authors = make(map[int64]models.RecipeAuthor)
+		rr.DB.authors[invitation.RecipeID] = authors
+	}
+	author := models.RecipeAuthor{
+		RecipeID:  invitation.RecipeID,
+		UserID:    invitation.InviteeID,
+		Role:      invitation.Role,
+		CreatedAt: rr.DB.Now(),
+	}
+	authors[author.UserID] = author
+	delete(rr.DB.invitations, invitationID)
+	return &author, nil
+}
+
+// DeleteInvitation removes a pending invitation
+func (rr *RecipeStore) DeleteInvitation(ctx context.Context, invitationID int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rr.DB.mu.Lock()
+	defer rr.DB.mu.Unlock()
+
+	if _, ok := rr.DB.invitations[invitationID]; !ok {
+		return fmt.Errorf("deleting invitation: %w", store.ErrNotFound)
+	}
+	delete(rr.DB.invitations, invitationID)
+	return nil
+}
+
+// GetAuthor retrieves a co-author of a recipe unless their account is
+// deleted
+func (rr *RecipeStore) GetAuthor(ctx context.Context, recipeID, userID int64) (*models.RecipeAuthor, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.RLock()
+	defer rr.DB.mu.RUnlock()
+
+	author, ok := rr.DB.authors[recipeID][userID]
+	if !ok || !rr.DB.userLive(userID) {
+		return nil, fmt.Errorf("retrieving author: %w", store.ErrNotFound)
+	}
+	return &author, nil
+}
+
+// ListAuthors retrieves the co-authors of a recipe, oldest first, leaving
+// out deleted accounts
+func (rr *RecipeStore) ListAuthors(ctx context.Context, recipeID int64) ([]*models.RecipeAuthor, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.RLock()
+	defer rr.DB.mu.RUnlock()
+
+	var authors []*models.RecipeAuthor
+	for userID, author := range rr.DB.authors[recipeID] {
+		if !rr.DB.userLive(userID) {
+			continue
+		}
+		author := author
+		authors = append(authors, &author)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		if !authors[i].CreatedAt.Equal(authors[j].CreatedAt) {
+			return authors[i].CreatedAt.Before(authors[j].CreatedAt)
+		}
+		return authors[i].UserID < authors[j].UserID
+	})
+	return authors, nil
+}
+
+// ListAuthorships retrieves the recipes a user co-authors, by recipe ID,
+// and nothing for a deleted account
+func (rr *RecipeStore) ListAuthorships(ctx context.Context, userID int64) ([]*models.RecipeAuthor, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.RLock()
+	defer rr.DB.mu.RUnlock()
+
+	if !rr.DB.userLive(userID) {
+		return nil, nil
+	}
+	var authorships []*models.RecipeAuthor
+	for _, authors := range rr.DB.authors {
+		if author, ok := authors[userID]; ok {
+			authorships = append(authorships, &author)
+		}
+	}
+	sort.Slice(authorships, func(i, j int) bool { return authorships[i].RecipeID < authorships[j].RecipeID })
+	return authorships, nil
+}
+
+// RemoveAuthor removes a co-author from a recipe
+func (rr *RecipeStore) RemoveAuthor(ctx context.Context, recipeID, userID int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rr.DB.mu.Lock()
+	defer rr.DB.mu.Unlock()
+
+	if _, ok := rr.DB.authors[recipeID][userID]; !ok {
+		return fmt.Errorf("removing author: %w", store.ErrNotFound)
+	}
+	delete(rr.DB.authors[recipeID], userID)
+	return nil
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"backend-app/models"
+	"backend-app/store"
+)
+
+// SetRecipeVisibility stores recipe.Visibility and recipe.ShareToken if
+// recipe.Version is still the current version, saves the change as a
+// revision and sets the new version on recipe
+func (rr *RecipeStore) SetRecipeVisibility(ctx context.Context, recipe *models.Recipe) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rr.DB.mu.Lock()
+	defer rr.DB.mu.Unlock()
+
+	stored, ok := rr.DB.recipes[recipe.ID]
+	if !ok || stored.DeletedAt != nil {
+		return fmt.Errorf("changing recipe visibility: %w", store.ErrNotFound)
+	}
+	if stored.Version != recipe.Version {
+		return fmt.Errorf("changing recipe visibility: %w", store.ErrStale)
+	}
+	if !rr.editorLive(recipe.UpdatedBy) {
+		return fmt.Errorf("changing recipe visibility: %w", store.ErrInvalidReference)
+	}
+	if recipe.ShareToken != "" && recipe.ShareToken != stored.ShareToken && rr.tokenTaken(recipe.ShareToken) {
+		return fmt.Errorf("changing recipe visibility: %w", store.ErrConflict)
+	}
+
+	stored.Visibility = recipe.Visibility
+	stored.ShareToken = recipe.ShareToken
+	stored.UpdatedBy = recipe.UpdatedBy
+	stored.Version++
+	stored.UpdatedAt = rr.DB.Now()
+	rr.DB.recipes[recipe.ID] = stored
+	rr.DB.saveRevision(stored)
+
+	recipe.Version = stored.Version
+	recipe.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+// GetRecipeByShareToken retrieves the unlisted recipe with the share link
+// token unless it is a draft
+func (rr *RecipeStore) GetRecipeByShareToken(ctx context.Context, token string) (*models.Recipe, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.RLock()
+	defer rr.DB.mu.RUnlock()
+
+	for _, recipe := range rr.DB.recipes {
+		if token == "" || recipe.ShareToken != token {
+			continue
+		}
+		if recipe.Visibility != models.VisibilityUnlisted || recipe.Status == models.StatusDraft || recipe.DeletedAt != nil {
+			break
+		}
+		recipe = cloneRecipe(recipe)
+		return &recipe, nil
+	}
+	return nil, fmt.Errorf("retrieving shared recipe: %w", store.ErrNotFound)
+}
+
+// ShareRecipe shares a recipe with a user on behalf of sharedBy, or changes
+// the permission it is shared with, and sets when it was first shared on
+// share
+func (rr *RecipeStore) ShareRecipe(ctx context.Context, share *models.RecipeShare, sharedBy int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rr.DB.mu.Lock()
+	defer rr.DB.mu.Unlock()
+
+	if !rr.live(share.RecipeID) {
+		return fmt.Errorf("sharing recipe: %w", store.ErrNotFound)
+	}
+	if !rr.DB.userLive(share.UserID) || !rr.editorLive(sharedBy) {
+		return fmt.Errorf("sharing recipe: %w", store.ErrInvalidReference)
+	}
+
+	shares := rr.DB.shares[share.RecipeID]
+	if shares == nil {
+		shares = make(map[int64]models.RecipeShare)
+		rr.DB.shares[share.RecipeID] = shares
+	}
+	if existing, ok := shares[share.UserID]; ok {
+		share.CreatedAt = existing.CreatedAt
+	} else {
+		share.CreatedAt = rr.DB.Now()
+	}
+	shares[share.UserID] = *share
+	return nil
+}
+
+// UnshareRecipe stops sharing a recipe with a user
+func (rr *RecipeStore) UnshareRecipe(ctx context.Context, recipeID, userID int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rr.DB.mu.Lock()
+	defer rr.DB.mu.Unlock()
+
+	if _, ok := rr.DB.shares[recipeID][userID]; !ok {
+		return fmt.Errorf("unsharing recipe: %w", store.ErrNotFound)
+	}
+	delete(rr.DB.shares[recipeID], userID)
+	return nil
+}
+
+// GetShare retrieves the share of a recipe with a user unless their
+// account is deleted
+func (rr *RecipeStore) GetShare(ctx context.Context, recipeID, userID int64) (*models.RecipeShare, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.RLock()
+	defer rr.DB.mu.RUnlock()
+
+	share, ok := rr.DB.shares[recipeID][userID]
+	if !ok || !rr.DB.userLive(userID) {
+		return nil, fmt.Errorf("retrieving share: %w", store.ErrNotFound)
+	}
+	return &share, nil
+}
+
+// ListShares retrieves the users a recipe is shared with, oldest share
+// first, leaving out deleted accounts
+func (rr *RecipeStore) ListShares(ctx context.Context, recipeID int64) ([]*models.RecipeShare, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.RLock()
+	defer rr.DB.mu.RUnlock()
+
+	var shares []*models.RecipeShare
+	for userID, share := range rr.DB.shares[recipeID] {
+		if !rr.DB.userLive(userID) {
+			continue
+		}
+		share := share
+		shares = append(shares, &share)
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if !shares[i].CreatedAt.Equal(shares[j].CreatedAt) {
+			return shares[i].CreatedAt.Before(shares[j].CreatedAt)
+		}
+		return shares[i].UserID < shares[j].UserID
+	})
+	return shares, nil
+}
+
+// tokenTaken reports whether any recipe has the share link token. The
+// caller must hold the DB lock.
+func (rr *RecipeStore) tokenTaken(token string) bool {
+	return rr.DB.recipeReferences(func(recipe models.Recipe) bool { return recipe.ShareToken == token })
+}
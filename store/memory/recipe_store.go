@@ -0,0 +1,439 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"backend-app/models"
+	"backend-app/store"
+)
+
+type RecipeStore struct {
+	DB *DB
+}
+
+var _ store.RecipeStore = (*RecipeStore)(nil)
+
+// NewRecipeStore initializes a new RecipeStore
+func NewRecipeStore(db *DB) *RecipeStore {
+	return &RecipeStore{
+		DB: db,
+	}
+}
+
+// CreateRecipe creates a new recipe
+func (rr *RecipeStore) CreateRecipe(ctx context.Context, recipe *models.Recipe) (*models.Recipe, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.Lock()
+	defer rr.DB.mu.Unlock()
+
+	if !rr.DB.userLive(recipe.CreatorID) || !rr.editorLive(recipe.UpdatedBy) {
+		return nil, fmt.Errorf("creating recipe: %w", store.ErrInvalidReference)
+	}
+	if !rr.categoryExists(recipe.CategoryID) {
+		return nil, fmt.Errorf("creating recipe: %w", store.ErrInvalidReference)
+	}
+
+	rr.DB.lastRecipeID++
+	recipe.ID = rr.DB.lastRecipeID
+	recipe.Version = 1
+	recipe.UpdatedAt = rr.DB.Now()
+	if recipe.UpdatedBy == 0 {
+		recipe.UpdatedBy = recipe.CreatorID
+	}
+	if recipe.Status == "" {
+		recipe.Status = models.StatusDraft
+	}
+	if recipe.Visibility == "" {
+		recipe.Visibility = models.VisibilityPublic
+	}
+	if recipe.ShareToken != "" && rr.tokenTaken(recipe.ShareToken) {
+		return nil, fmt.Errorf("creating recipe: %w", store.ErrConflict)
+	}
+	if recipe.Status == models.StatusPublished {
+		publishedAt := recipe.UpdatedAt
+		recipe.PublishedAt = &publishedAt
+	}
+	rr.DB.recipes[recipe.ID] = cloneRecipe(*recipe)
+	rr.DB.saveRevision(*recipe)
+	return recipe, nil
+}
+
+// UpdateRecipe updates an existing recipe if recipe.Version is still the
+// current version, saves the new version as a revision, sets it on recipe
+// and releases the images it no longer references
+func (rr *RecipeStore) UpdateRecipe(ctx context.Context, recipe *models.Recipe) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rr.DB.mu.Lock()
+	defer rr.DB.mu.Unlock()
+
+	stored, ok := rr.DB.recipes[recipe.ID]
+	if !ok || stored.DeletedAt != nil {
+		return fmt.Errorf("updating recipe: %w", store.ErrNotFound)
+	}
+	if stored.Version != recipe.Version {
+		return fmt.Errorf("updating recipe: %w", store.ErrStale)
+	}
+	if !rr.categoryExists(recipe.CategoryID) || !rr.editorLive(recipe.UpdatedBy) {
+		return fmt.Errorf("updating recipe: %w", store.ErrInvalidReference)
+	}
+
+	rr.DB.releaseImages(stored.Images, recipe.Images)
+
+	recipe.Version++
+	recipe.UpdatedAt = rr.DB.Now()
+
+	// The creator and origin of a recipe never change, and updates leave
+	// its status and visibility alone
+	updated := cloneRecipe(*recipe)
+	updated.CreatorID = stored.CreatorID
+	updated.ForkedFrom = stored.ForkedFrom
+	updated.Status, updated.PublishAt, updated.PublishedAt = stored.Status, stored.PublishAt, stored.PublishedAt
+	updated.Visibility, updated.ShareToken = stored.Visibility, stored.ShareToken
+	recipe.ForkedFrom = cloneOrigin(stored.ForkedFrom)
+	recipe.Status, recipe.PublishAt, recipe.PublishedAt = stored.Status, cloneTime(stored.PublishAt), cloneTime(stored.PublishedAt)
+	recipe.Visibility, recipe.ShareToken = stored.Visibility, stored.ShareToken
+	rr.DB.recipes[recipe.ID] = updated
+	rr.DB.saveRevision(updated)
+	return nil
+}
+
+// SetRecipeStatus stores recipe.Status and recipe.PublishAt if
+// recipe.Version is still the current version, saves the change as a
+// revision and sets the new version on recipe
+func (rr *RecipeStore) SetRecipeStatus(ctx context.Context, recipe *models.Recipe) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rr.DB.mu.Lock()
+	defer rr.DB.mu.Unlock()
+
+	stored, ok := rr.DB.recipes[recipe.ID]
+	if !ok || stored.DeletedAt != nil {
+		return fmt.Errorf("changing recipe status: %w", store.ErrNotFound)
+	}
+	if stored.Version != recipe.Version {
+		return fmt.Errorf("changing recipe status: %w", store.ErrStale)
+	}
+	if !rr.editorLive(recipe.UpdatedBy) {
+		return fmt.Errorf("changing recipe status: %w", store.ErrInvalidReference)
+	}
+
+	stored.Status = recipe.Status
+	stored.PublishAt = cloneTime(recipe.PublishAt)
+	stored.UpdatedBy = recipe.UpdatedBy
+	stored.Version++
+	stored.UpdatedAt = rr.DB.Now()
+	if stored.Status == models.StatusPublished && stored.PublishedAt == nil {
+		publishedAt := stored.UpdatedAt
+		stored.PublishedAt = &publishedAt
+	}
+	rr.DB.recipes[recipe.ID] = stored
+	rr.DB.saveRevision(stored)
+
+	recipe.Version = stored.Version
+	recipe.UpdatedAt = stored.UpdatedAt
+	recipe.PublishedAt = cloneTime(stored.PublishedAt)
+	return nil
+}
+
+// PublishDueRecipes publishes the drafts scheduled at or before now, saves
+// each as a revision and returns their IDs. The revision is attributed to
+// whoever scheduled the publication.
+func (rr *RecipeStore) PublishDueRecipes(ctx context.Context, now time.Time) ([]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.Lock()
+	defer rr.DB.mu.Unlock()
+
+	var published []int64
+	for id, recipe := range rr.DB.recipes {
+		if recipe.DeletedAt != nil || recipe.Status != models.StatusDraft || recipe.PublishAt == nil || recipe.PublishAt.After(now) {
+			continue
+		}
+		recipe.Status = models.StatusPublished
+		recipe.PublishedAt = recipe.PublishAt
+		recipe.PublishAt = nil
+		recipe.Version++
+		recipe.UpdatedAt = rr.DB.Now()
+		rr.DB.recipes[id] = recipe
+		rr.DB.saveRevision(recipe)
+		published = append(published, id)
+	}
+	sort.Slice(published, func(i, j int) bool { return published[i] < published[j] })
+	return published, nil
+}
+
+// DeleteRecipe moves a recipe to its creator's trash on behalf of
+// deletedBy. Its images stay in use until it is purged.
+func (rr *RecipeStore) DeleteRecipe(ctx context.Context, recipeID, deletedBy int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rr.DB.mu.Lock()
+	defer rr.DB.mu.Unlock()
+
+	stored, ok := rr.DB.recipes[recipeID]
+	if !ok || stored.DeletedAt != nil {
+		return fmt.Errorf("deleting recipe: %w", store.ErrNotFound)
+	}
+	if !rr.editorLive(deletedBy) {
+		return fmt.Errorf("deleting recipe: %w", store.ErrInvalidReference)
+	}
+
+	deletedAt := rr.DB.Now()
+	stored.DeletedAt = &deletedAt
+	rr.DB.recipes[recipeID] = stored
+	return nil
+}
+
+// ListDeletedRecipes retrieves the recipes in a user's trash, most recently
+// deleted first
+func (rr *RecipeStore) ListDeletedRecipes(ctx context.Context, creatorID int64) ([]*models.Recipe, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.RLock()
+	defer rr.DB.mu.RUnlock()
+
+	var recipes []*models.Recipe
+	for _, recipe := range rr.DB.recipes {
+		if recipe.CreatorID != creatorID || recipe.DeletedAt == nil {
+			continue
+		}
+		recipe = cloneRecipe(recipe)
+		recipes = append(recipes, &recipe)
+	}
+	sort.Slice(recipes, func(i, j int) bool {
+		if !recipes[i].DeletedAt.Equal(*recipes[j].DeletedAt) {
+			return recipes[i].DeletedAt.After(*recipes[j].DeletedAt)
+		}
+		return recipes[i].ID < recipes[j].ID
+	})
+	return recipes, nil
+}
+
+// RestoreRecipe takes a recipe out of its creator's trash if it was deleted
+// at or after deletedAfter
+func (rr *RecipeStore) RestoreRecipe(ctx context.Context, recipeID, creatorID int64, deletedAfter time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rr.DB.mu.Lock()
+	defer rr.DB.mu.Unlock()
+
+	stored, ok := rr.DB.recipes[recipeID]
+	if !ok || stored.CreatorID != creatorID || stored.DeletedAt == nil || stored.DeletedAt.Before(deletedAfter) {
+		return fmt.Errorf("restoring recipe: %w", store.ErrNotFound)
+	}
+	stored.DeletedAt = nil
+	rr.DB.recipes[recipeID] = stored
+	return nil
+}
+
+// PurgeRecipes permanently deletes recipes deleted before the given time
+// with their revisions and releases the images they referenced
+func (rr *RecipeStore) PurgeRecipes(ctx context.Context, before time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	rr.DB.mu.Lock()
+	defer rr.DB.mu.Unlock()
+
+	var purged int64
+	for id, recipe := range rr.DB.recipes {
+		if recipe.DeletedAt == nil || !recipe.DeletedAt.Before(before) {
+			continue
+		}
+		rr.DB.releaseImages(recipe.Images, nil)
+		for _, revision := range rr.DB.revisions[id] {
+			rr.DB.releaseImages(revision.Images, nil)
+		}
+		delete(rr.DB.recipes, id)
+		delete(rr.DB.revisions, id)
+		delete(rr.DB.shares, id)
+		delete(rr.DB.authors, id)
+		for invitationID, invitation := range rr.DB.invitations {
+			if invitation.RecipeID == id {
+				delete(rr.DB.invitations, invitationID)
+			}
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// GetRecipeByID retrieves a recipe by ID
+func (rr *RecipeStore) GetRecipeByID(ctx context.Context, recipeID int64) (*models.Recipe, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.RLock()
+	defer rr.DB.mu.RUnlock()
+
+	recipe, ok := rr.DB.recipes[recipeID]
+	if !ok || recipe.DeletedAt != nil {
+		return nil, fmt.Errorf("retrieving recipe: %w", store.ErrNotFound)
+	}
+	recipe = cloneRecipe(recipe)
+	return &recipe, nil
+}
+
+// GetAllRecipes retrieves the published recipes and the viewer's own
+// drafts and archived recipes, ordered by ID
+func (rr *RecipeStore) GetAllRecipes(ctx context.Context, viewerID int64) ([]*models.Recipe, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.RLock()
+	defer rr.DB.mu.RUnlock()
+
+	var recipes []*models.Recipe
+	for _, recipe := range rr.DB.recipes {
+		if recipe.DeletedAt != nil || !rr.listable(recipe, viewerID) {
+			continue
+		}
+		recipe = cloneRecipe(recipe)
+		recipes = append(recipes, &recipe)
+	}
+	sort.Slice(recipes, func(i, j int) bool { return recipes[i].ID < recipes[j].ID })
+	return recipes, nil
+}
+
+// ListForks retrieves the recipes forked directly from a recipe that the
+// viewer can list, oldest first. The recipe itself need not exist any more.
+func (rr *RecipeStore) ListForks(ctx context.Context, recipeID, viewerID int64) ([]*models.Recipe, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.RLock()
+	defer rr.DB.mu.RUnlock()
+
+	var forks []*models.Recipe
+	for _, recipe := range rr.DB.recipes {
+		if recipe.DeletedAt != nil || recipe.ForkedFrom == nil || recipe.ForkedFrom.RecipeID != recipeID || !rr.listable(recipe, viewerID) {
+			continue
+		}
+		recipe = cloneRecipe(recipe)
+		forks = append(forks, &recipe)
+	}
+	sort.Slice(forks, func(i, j int) bool { return forks[i].ID < forks[j].ID })
+	return forks, nil
+}
+
+// ListRevisions retrieves every revision of a recipe, newest first
+func (rr *RecipeStore) ListRevisions(ctx context.Context, recipeID int64) ([]*models.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.RLock()
+	defer rr.DB.mu.RUnlock()
+
+	stored, ok := rr.DB.revisions[recipeID]
+	if !ok || !rr.live(recipeID) {
+		return nil, fmt.Errorf("retrieving revisions: %w", store.ErrNotFound)
+	}
+	revisions := make([]*models.Revision, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		revision := cloneRevision(stored[i])
+		revisions = append(revisions, &revision)
+	}
+	return revisions, nil
+}
+
+// GetRevision retrieves one revision of a recipe
+func (rr *RecipeStore) GetRevision(ctx context.Context, recipeID, version int64) (*models.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rr.DB.mu.RLock()
+	defer rr.DB.mu.RUnlock()
+
+	if !rr.live(recipeID) {
+		return nil, fmt.Errorf("retrieving revision: %w", store.ErrNotFound)
+	}
+	for _, revision := range rr.DB.revisions[recipeID] {
+		if revision.Version == version {
+			revision = cloneRevision(revision)
+			return &revision, nil
+		}
+	}
+	return nil, fmt.Errorf("retrieving revision: %w", store.ErrNotFound)
+}
+
+// live reports whether a recipe exists and is not in the trash. The caller
+// must hold the DB lock.
+func (rr *RecipeStore) live(recipeID int64) bool {
+	recipe, ok := rr.DB.recipes[recipeID]
+	return ok && recipe.DeletedAt == nil
+}
+
+// listable reports whether a recipe appears in the viewer's lists: the
+// viewer created it or is one of its authors, or it is published and either
+// public or shared with the viewer. The caller must hold the DB lock.
+func (rr *RecipeStore) listable(recipe models.Recipe, viewerID int64) bool {
+	if _, author := rr.DB.authors[recipe.ID][viewerID]; author || recipe.CreatorID == viewerID {
+		return true
+	}
+	if recipe.Status != models.StatusPublished {
+		return false
+	}
+	_, shared := rr.DB.shares[recipe.ID][viewerID]
+	return recipe.Visibility == models.VisibilityPublic || shared
+}
+
+// editorLive reports whether a change may be attributed to a user: a live
+// account, or nobody when userID is zero. The caller must hold the DB lock.
+func (rr *RecipeStore) editorLive(userID int64) bool {
+	return userID == 0 || rr.DB.userLive(userID)
+}
+
+// categoryExists reports whether a recipe may reference the category. Zero
+// means uncategorized. The caller must hold the DB lock.
+func (rr *RecipeStore) categoryExists(categoryID int64) bool {
+	if categoryID == 0 {
+		return true
+	}
+	return rr.DB.categoryLive(categoryID)
+}
+
+func cloneRecipe(recipe models.Recipe) models.Recipe {
+	recipe.Ingredients = cloneStrings(recipe.Ingredients)
+	recipe.Steps = cloneStrings(recipe.Steps)
+	recipe.Images = cloneStrings(recipe.Images)
+	recipe.ForkedFrom = cloneOrigin(recipe.ForkedFrom)
+	recipe.DeletedAt = cloneTime(recipe.DeletedAt)
+	recipe.PublishAt = cloneTime(recipe.PublishAt)
+	recipe.PublishedAt = cloneTime(recipe.PublishedAt)
+	return recipe
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	clone := *t
+	return &clone
+}
+
+func cloneOrigin(origin *models.ForkOrigin) *models.ForkOrigin {
+	if origin == nil {
+		return nil
+	}
+	clone := *origin
+	return &clone
+}
+
+func cloneRevision(revision models.Revision) models.Revision {
+	revision.Ingredients = cloneStrings(revision.Ingredients)
+	revision.Steps = cloneStrings(revision.Steps)
+	revision.Images = cloneStrings(revision.Images)
+	return revision
+}
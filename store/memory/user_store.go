@@ -0,0 +1,275 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"backend-app/models"
+	"backend-app/store"
+)
+
+type UserStore struct {
+	DB *DB
+}
+
+var _ store.UserStore = (*UserStore)(nil)
+
+// NewUserStore initializes a new UserStore
+func NewUserStore(db *DB) *UserStore {
+	return &UserStore{
+		DB: db,
+	}
+}
+
+// CreateUser creates a new user
+func (ur *UserStore) CreateUser(ctx context.Context, user *models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ur.DB.mu.Lock()
+	defer ur.DB.mu.Unlock()
+
+	if ur.emailTaken(user.Email, 0) {
+		return fmt.Errorf("creating user: %w", store.ErrConflict)
+	}
+
+	ur.DB.lastUserID++
+	user.ID = int(ur.DB.lastUserID)
+	ur.DB.users[ur.DB.lastUserID] = *user
+	return nil
+}
+
+// UpdateUser updates an existing user
+func (ur *UserStore) UpdateUser(ctx context.Context, user *models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ur.DB.mu.Lock()
+	defer ur.DB.mu.Unlock()
+
+	stored, ok := ur.DB.users[int64(user.ID)]
+	if !ok || !ur.DB.userLive(int64(user.ID)) {
+		return fmt.Errorf("updating user: %w", store.ErrNotFound)
+	}
+	if ur.emailTaken(user.Email, int64(user.ID)) {
+		return fmt.Errorf("updating user: %w", store.ErrConflict)
+	}
+
+	stored.Username = user.Username
+	stored.Email = user.Email
+	stored.PasswordHash = user.PasswordHash
+	ur.DB.users[int64(user.ID)] = stored
+	return nil
+}
+
+// DeleteUser deletes a user and the recipes they created. Both are kept
+// until they are restored or purged.
+func (ur *UserStore) DeleteUser(ctx context.Context, userID int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ur.DB.mu.Lock()
+	defer ur.DB.mu.Unlock()
+
+	if !ur.DB.userLive(userID) {
+		return fmt.Errorf("deleting user: %w", store.ErrNotFound)
+	}
+
+	// The recipes share the account's deletion time, which is how
+	// RestoreUser tells them from recipes that were already in the trash
+	now := ur.DB.Now()
+	ur.DB.deletedUsers[userID] = now
+	for id, recipe := range ur.DB.recipes {
+		if recipe.CreatorID == userID && recipe.DeletedAt == nil {
+			deletedAt := now
+			recipe.DeletedAt = &deletedAt
+			ur.DB.recipes[id] = recipe
+		}
+	}
+	return nil
+}
+
+// RestoreUser restores a user deleted at or after deletedAfter with the
+// recipes that were deleted with them
+func (ur *UserStore) RestoreUser(ctx context.Context, userID int64, deletedAfter time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ur.DB.mu.Lock()
+	defer ur.DB.mu.Unlock()
+
+	deletedAt, ok := ur.DB.deletedUsers[userID]
+	if !ok || deletedAt.Before(deletedAfter) {
+		return fmt.Errorf("restoring user: %w", store.ErrNotFound)
+	}
+	if ur.emailTaken(ur.DB.users[userID].Email, userID) {
+		return fmt.Errorf("restoring user: %w", store.ErrConflict)
+	}
+
+	delete(ur.DB.deletedUsers, userID)
+	for id, recipe := range ur.DB.recipes {
+		if recipe.CreatorID == userID && recipe.DeletedAt != nil && recipe.DeletedAt.Equal(deletedAt) {
+			recipe.DeletedAt = nil
+			ur.DB.recipes[id] = recipe
+		}
+	}
+	return nil
+}
+
+// PurgeUsers permanently deletes users deleted before the given time. Users
+// whose recipes have not been purged yet are left for a later purge.
+func (ur *UserStore) PurgeUsers(ctx context.Context, before time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	ur.DB.mu.Lock()
+	defer ur.DB.mu.Unlock()
+
+	var purged int64
+	for userID, deletedAt := range ur.DB.deletedUsers {
+		if !deletedAt.Before(before) {
+			continue
+		}
+		if ur.DB.recipeReferences(func(recipe models.Recipe) bool { return recipe.CreatorID == userID }) {
+			continue
+		}
+		ur.purge(userID)
+		purged++
+	}
+	return purged, nil
+}
+
+// purge deletes a user the way the foreign keys to users do. The caller must
+// hold the DB lock for writing.
+func (ur *UserStore) purge(userID int64) {
+	delete(ur.DB.users, userID)
+	delete(ur.DB.deletedUsers, userID)
+
+	// The user's edits stay in the history without an editor
+	for id, recipe := range ur.DB.recipes {
+		if recipe.UpdatedBy == userID {
+			recipe.UpdatedBy = 0
+			ur.DB.recipes[id] = recipe
+		}
+	}
+	// Forks keep the recipe they came from but lose its author
+	for id, recipe := range ur.DB.recipes {
+		if recipe.ForkedFrom != nil && recipe.ForkedFrom.CreatorID == userID {
+			origin := *recipe.ForkedFrom
+			origin.CreatorID = 0
+			recipe.ForkedFrom = &origin
+			ur.DB.recipes[id] = recipe
+		}
+	}
+	for _, revisions := range ur.DB.revisions {
+		for i := range revisions {
+			if revisions[i].EditorID == userID {
+				revisions[i].EditorID = 0
+			}
+		}
+	}
+	for _, shares := range ur.DB.shares {
+		delete(shares, userID)
+	}
+	for _, authors := range ur.DB.authors {
+		delete(authors, userID)
+	}
+	for id, invitation := range ur.DB.invitations {
+		if invitation.InviteeID == userID || invitation.InvitedBy == userID {
+			delete(ur.DB.invitations, id)
+		}
+	}
+}
+
+// GetUserByID retrieves a user by ID
+func (ur *UserStore) GetUserByID(ctx context.Context, userID int64) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ur.DB.mu.RLock()
+	defer ur.DB.mu.RUnlock()
+
+	user, ok := ur.DB.users[userID]
+	if !ok || !ur.DB.userLive(userID) {
+		return nil, fmt.Errorf("retrieving user: %w", store.ErrNotFound)
+	}
+	return &user, nil
+}
+
+// GetUserByEmail retrieves a user by email
+func (ur *UserStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ur.DB.mu.RLock()
+	defer ur.DB.mu.RUnlock()
+
+	for id, user := range ur.DB.users {
+		if user.Email == email && ur.DB.userLive(id) {
+			return &user, nil
+		}
+	}
+	return nil, fmt.Errorf("retrieving user by email: %w", store.ErrNotFound)
+}
+
+// GetUserByUsername retrieves the user with the username, failing with
+// ErrConflict if several users share it
+func (ur *UserStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ur.DB.mu.RLock()
+	defer ur.DB.mu.RUnlock()
+
+	var found *models.User
+	for id, user := range ur.DB.users {
+		if user.Username != username || !ur.DB.userLive(id) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("retrieving user by username: %w", store.ErrConflict)
+		}
+		user := user
+		found = &user
+	}
+	if found == nil {
+		return nil, fmt.Errorf("retrieving user by username: %w", store.ErrNotFound)
+	}
+	return found, nil
+}
+
+// GetDeletedUserByEmail retrieves the most recently deleted user with the
+// email that was deleted at or after deletedAfter
+func (ur *UserStore) GetDeletedUserByEmail(ctx context.Context, email string, deletedAfter time.Time) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ur.DB.mu.RLock()
+	defer ur.DB.mu.RUnlock()
+
+	var found *models.User
+	var latest time.Time
+	for id, deletedAt := range ur.DB.deletedUsers {
+		user := ur.DB.users[id]
+		if user.Email != email || deletedAt.Before(deletedAfter) || (found != nil && !deletedAt.After(latest)) {
+			continue
+		}
+		found, latest = &user, deletedAt
+	}
+	if found == nil {
+		return nil, fmt.Errorf("retrieving deleted user by email: %w", store.ErrNotFound)
+	}
+	return found, nil
+}
+
+// emailTaken reports whether another live user than exceptID uses the
+// email. The caller must hold the DB lock.
+func (ur *UserStore) emailTaken(email string, exceptID int64) bool {
+	for id, user := range ur.DB.users {
+		if id != exceptID && user.Email == email && ur.DB.userLive(id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+// Package pgtest provides throwaway PostgreSQL databases for integration
+// tests.
+//
+// By default it starts a private cluster with the local initdb and pg_ctl
+// binaries (found on PATH, in $PG_BIN or in /usr/lib/postgresql/*/bin),
+// listening only on a Unix socket in a temporary directory. Setting
+// TEST_DATABASE_URL uses an existing server instead. Each test gets its own
+// freshly migrated database.
+//
+// Tests are skipped when no PostgreSQL is available, unless PGTEST_REQUIRED
+// is set, in which case they fail.
+package pgtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"backend-app/migrations"
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+var (
+	startOnce sync.Once
+	server    *cluster
+	startErr  error
+	databases atomic.Int64
+)
+
+// cluster is a running PostgreSQL server tests can create databases on
+type cluster struct {
+	adminDSN string
+	// dir and pgCtl are set for clusters started by this package
+	dir   string
+	pgCtl string
+}
+
+// Main runs the tests of a package and stops the cluster afterwards. Call it
+// from TestMain in every package that uses NewDB.
+func Main(m *testing.M) {
+	code := m.Run()
+	if server != nil {
+		server.stop()
+	}
+	os.Exit(code)
+}
+
+// NewDB creates an empty database with all migrations applied and returns a
+// connection to it. The database is dropped when the test finishes.
+func NewDB(t testing.TB) *sql.DB {
+	t.Helper()
+
+	startOnce.Do(func() {
+		server, startErr = start()
+	})
+	if startErr != nil {
+		if os.Getenv("PGTEST_REQUIRED") != "" {
+			t.Fatalf("pgtest: %v", startErr)
+		}
+		t.Skipf("pgtest: %v", startErr)
+	}
+
+	ctx := context.Background()
+	admin, err := sql.Open("postgres", server.adminDSN)
+	if err != nil {
+		t.Fatalf("pgtest: %v", err)
+	}
+	defer admin.Close()
+
+	name := fmt.Sprintf("pgtest_%d_%d", os.Getpid(), databases.Add(1))
+	if _, err := admin.ExecContext(ctx, "CREATE DATABASE "+name); err != nil {
+		t.Fatalf("pgtest: creating database: %v", err)
+	}
+
+	dsn, err := withDatabase(server.adminDSN, name)
+	if err != nil {
+		t.Fatalf("pgtest: %v", err)
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("pgtest: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		admin, err := sql.Open("postgres", server.adminDSN)
+		if err != nil {
+			return
+		}
+		defer admin.Close()
+		admin.Exec("DROP DATABASE IF EXISTS " + name)
+	})
+
+	if _, err := migrations.Apply(ctx, db); err != nil {
+		t.Fatalf("pgtest: applying migrations: %v", err)
+	}
+	return db
+}
+
+// start connects to TEST_DATABASE_URL or starts a private cluster
+func start() (*cluster, error) {
+	if dsn := os.Getenv("TEST_DATABASE_URL"); dsn != "" {
+		return &cluster{adminDSN: dsn}, nil
+	}
+
+	initdb, pgCtl, err := findBinaries()
+	if err != nil {
+		return nil, err
+	}
+	if os.Geteuid() == 0 {
+		return nil, errors.New("initdb refuses to run as root; run tests as another user or set TEST_DATABASE_URL")
+	}
+
+	dir, err := os.MkdirTemp("", "pgtest")
+	if err != nil {
+		return nil, err
+	}
+	c := &cluster{dir: dir, pgCtl: pgCtl}
+	data := filepath.Join(dir, "data")
+
+	out, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync").CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("initdb: %v: %s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	options := fmt.Sprintf("-p %d -k %s -c listen_addresses='' -F", port, dir)
+	out, err = exec.Command(pgCtl, "-D", data, "-o", options, "-l", filepath.Join(dir, "postgres.log"), "-w", "start").CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("pg_ctl start: %v: %s", err, out)
+	}
+
+	c.adminDSN = fmt.Sprintf("host=%s port=%d user=postgres dbname=postgres sslmode=disable", dir, port)
+	return c, nil
+}
+
+// stop shuts down a cluster started by this package and removes its files
+func (c *cluster) stop() {
+	if c.dir == "" {
+		return
+	}
+	exec.Command(c.pgCtl, "-D", filepath.Join(c.dir, "data"), "-m", "immediate", "-w", "stop").Run()
+	os.RemoveAll(c.dir)
+}
+
+// findBinaries locates initdb and pg_ctl
+func findBinaries() (initdb, pgCtl string, err error) {
+	var dirs []string
+	if bin := os.Getenv("PG_BIN"); bin != "" {
+		dirs = append(dirs, bin)
+	}
+	installed, _ := filepath.Glob("/usr/lib/postgresql/*/bin")
+	for i := len(installed) - 1; i >= 0; i-- { // newest version first
+		dirs = append(dirs, installed[i])
+	}
+
+	for _, dir := range dirs {
+		initdb, pgCtl = filepath.Join(dir, "initdb"), filepath.Join(dir, "pg_ctl")
+		if isExecutable(initdb) && isExecutable(pgCtl) {
+			return initdb, pgCtl, nil
+		}
+	}
+
+	initdb, err = exec.LookPath("initdb")
+	if err == nil {
+		pgCtl, err = exec.LookPath("pg_ctl")
+	}
+	if err != nil {
+		return "", "", errors.New("no PostgreSQL binaries found; install PostgreSQL, set PG_BIN or set TEST_DATABASE_URL")
+	}
+	return initdb, pgCtl, nil
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0o111 != 0
+}
+
+// freePort asks the kernel for an unused TCP port number
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// withDatabase returns dsn pointing at another database. Both URL and
+// key=value connection strings are supported.
+func withDatabase(dsn, name string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+		u.Path = "/" + name
+		return u.String(), nil
+	}
+	return dsn + " dbname=" + name, nil
+}
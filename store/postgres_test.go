@@ -0,0 +1,25 @@
+package store_test
+
+import (
+	"testing"
+
+	"backend-app/store"
+	"backend-app/store/pgtest"
+	"backend-app/store/storetest"
+)
+
+func TestMain(m *testing.M) {
+	pgtest.Main(m)
+}
+
+func TestPostgresContract(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		db := pgtest.NewDB(t)
+		return storetest.Stores{
+			Users:      store.NewPostgresUserStore(db),
+			Categories: store.NewPostgresCategoryStore(store.NewCluster(db)),
+			Recipes:    store.NewPostgresRecipeStore(store.NewCluster(db)),
+			Media:      store.NewPostgresMediaStore(db),
+		}
+	})
+}
@@ -0,0 +1,312 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"backend-app/models"
+)
+
+// invitationColumns are the columns scanInvitation expects
+const invitationColumns = `id, recipe_id, invitee_id, invited_by, role, created_at`
+
+// InviteAuthor stores a pending invitation to become an author of a recipe
+// and sets its ID and creation time
+func (rr *PostgresRecipeStore) InviteAuthor(ctx context.Context, invitation *models.Invitation) error {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO recipe_invitations (recipe_id, invitee_id, invited_by, role)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	tx, err := rr.DB.Primary.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError("starting transaction", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the recipe and the invitee so neither is deleted meanwhile
+	var id int64
+	err = tx.QueryRowContext(ctx, `SELECT id FROM recipes WHERE id = $1 AND deleted_at IS NULL FOR SHARE`, invitation.RecipeID).Scan(&id)
+	if err != nil {
+		return translateError("inviting author", err)
+	}
+	err = tx.QueryRowContext(ctx, `SELECT id FROM users WHERE id = $1 AND deleted_at IS NULL FOR SHARE`, invitation.InviteeID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = ErrInvalidReference
+	}
+	if err != nil {
+		return translateError("inviting author", err)
+	}
+	if err := lockUser(ctx, tx, invitation.InvitedBy); err != nil {
+		return translateError("inviting author", err)
+	}
+	var author bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM recipe_authors WHERE recipe_id = $1 AND user_id = $2)`,
+		invitation.RecipeID, invitation.InviteeID).Scan(&author)
+	if err != nil {
+		return translateError("inviting author", err)
+	}
+	if author {
+		return fmt.Errorf("inviting author: %w", ErrConflict)
+	}
+
+	err = tx.QueryRowContext(ctx, query, invitation.RecipeID, invitation.InviteeID, invitation.InvitedBy, invitation.Role).
+		Scan(&invitation.ID, &invitation.CreatedAt)
+	if err != nil {
+		return translateError("inviting author", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return translateError("inviting author", err)
+	}
+	rr.DB.MarkWrite(ctx)
+	return nil
+}
+
+// GetInvitation retrieves a pending invitation by ID
+func (rr *PostgresRecipeStore) GetInvitation(ctx context.Context, invitationID int64) (*models.Invitation, error) {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	var invitation *models.Invitation
+	query := `
+		SELECT ` + invitationColumns + `
+		FROM recipe_invitations
+		WHERE id = $1
+	`
+	err := rr.DB.Read(ctx, func(db *sql.DB) error {
+		var err error
+		invitation, err = scanInvitation(db.QueryRowContext(ctx, query, invitationID))
+		return err
+	})
+	if err != nil {
+		return nil, translateError("retrieving invitation", err)
+	}
+	return invitation, nil
+}
+
+// ListInvitations retrieves a user's pending invitations to recipes that are
+// not deleted, oldest first
+func (rr *PostgresRecipeStore) ListInvitations(ctx context.Context, inviteeID int64) ([]*models.Invitation, error) {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	var invitations []*models.Invitation
+	query := `
+		SELECT ` + invitationColumns + `
+		FROM recipe_invitations
+		WHERE invitee_id = $1
+		AND EXISTS (SELECT 1 FROM recipes WHERE id = recipe_id AND deleted_at IS NULL)
+		ORDER BY created_at, id
+	`
+	err := rr.DB.Read(ctx, func(db *sql.DB) error {
+		// Start over if a replica fails and the read is retried
+		invitations = nil
+
+		rows, err := db.QueryContext(ctx, query, inviteeID)
+		if err != nil {
+			return translateError("retrieving invitations", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			invitation, err := scanInvitation(rows)
+			if err != nil {
+				return translateError("scanning invitation row", err)
+			}
+			invitations = append(invitations, invitation)
+		}
+		if err := rows.Err(); err != nil {
+			return translateError("iterating over invitation rows", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// AcceptInvitation makes the invitee a co-author with the role they were
+// invited with and removes the invitation. Invitations to deleted accounts
+// are not found.
+func (rr *PostgresRecipeStore) AcceptInvitation(ctx context.Context, invitationID int64) (*models.RecipeAuthor, error) {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	query := `
+		WITH invitation AS (
+			DELETE FROM recipe_invitations
+			WHERE id = $1
+			AND EXISTS (SELECT 1 FROM recipes WHERE id = recipe_id AND deleted_at IS NULL)
+			RETURNING recipe_id, invitee_id, role
+		)
+		INSERT INTO recipe_authors (recipe_id, user_id, role)
+		SELECT recipe_id, invitee_id, role FROM invitation
+		RETURNING recipe_id, user_id, role, created_at
+	`
+	tx, err := rr.DB.Primary.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, translateError("starting transaction", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the invitee so the account is not deleted meanwhile
+	var id int64
+	lock := `
+		SELECT u.id
+		FROM recipe_invitations i
+		JOIN users u ON u.id = i.invitee_id
+		WHERE i.id = $1 AND u.deleted_at IS NULL
+		FOR SHARE OF u
+	`
+	if err := tx.QueryRowContext(ctx, lock, invitationID).Scan(&id); err != nil {
+		return nil, translateError("accepting invitation", err)
+	}
+
+	var author models.RecipeAuthor
+	err = tx.QueryRowContext(ctx, query, invitationID).
+		Scan(&author.RecipeID, &author.UserID, &author.Role, &author.CreatedAt)
+	if err != nil {
+		return nil, translateError("accepting invitation", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, translateError("accepting invitation", err)
+	}
+	rr.DB.MarkWrite(ctx)
+	return &author, nil
+}
+
+// DeleteInvitation removes a pending invitation
+func (rr *PostgresRecipeStore) DeleteInvitation(ctx context.Context, invitationID int64) error {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	result, err := rr.DB.Primary.ExecContext(ctx, `DELETE FROM recipe_invitations WHERE id = $1`, invitationID)
+	if err != nil {
+		return translateError("deleting invitation", err)
+	}
+	if err := requireAffected(result); err != nil {
+		return translateError("deleting invitation", err)
+	}
+	rr.DB.MarkWrite(ctx)
+	return nil
+}
+
+// GetAuthor retrieves a co-author of a recipe unless their account is
+// deleted
+func (rr *PostgresRecipeStore) GetAuthor(ctx context.Context, recipeID, userID int64) (*models.RecipeAuthor, error) {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	var author models.RecipeAuthor
+	query := `
+		SELECT a.recipe_id, a.user_id, a.role, a.created_at
+		FROM recipe_authors a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.recipe_id = $1 AND a.user_id = $2 AND u.deleted_at IS NULL
+	`
+	err := rr.DB.Read(ctx, func(db *sql.DB) error {
+		return db.QueryRowContext(ctx, query, recipeID, userID).
+			Scan(&author.RecipeID, &author.UserID, &author.Role, &author.CreatedAt)
+	})
+	if err != nil {
+		return nil, translateError("retrieving author", err)
+	}
+	return &author, nil
+}
+
+// ListAuthors retrieves the co-authors of a recipe, oldest first, leaving
+// out deleted accounts
+func (rr *PostgresRecipeStore) ListAuthors(ctx context.Context, recipeID int64) ([]*models.RecipeAuthor, error) {
+	query := `
+		SELECT a.recipe_id, a.user_id, a.role, a.created_at
+		FROM recipe_authors a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.recipe_id = $1 AND u.deleted_at IS NULL
+		ORDER BY a.created_at, a.user_id
+	`
+	return rr.listAuthors(ctx, query, recipeID)
+}
+
+// ListAuthorships retrieves the recipes a user co-authors, by recipe ID,
+// and nothing for a deleted account
+func (rr *PostgresRecipeStore) ListAuthorships(ctx context.Context, userID int64) ([]*models.RecipeAuthor, error) {
+	query := `
+		SELECT a.recipe_id, a.user_id, a.role, a.created_at
+		FROM recipe_authors a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.user_id = $1 AND u.deleted_at IS NULL
+		ORDER BY a.recipe_id
+	`
+	return rr.listAuthors(ctx, query, userID)
+}
+
+// listAuthors runs a query selecting recipe_authors rows
+func (rr *PostgresRecipeStore) listAuthors(ctx context.Context, query string, args ...interface{}) ([]*models.RecipeAuthor, error) {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	var authors []*models.RecipeAuthor
+	err := rr.DB.Read(ctx, func(db *sql.DB) error {
+		// Start over if a replica fails and the read is retried
+		authors = nil
+
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return translateError("retrieving authors", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var author models.RecipeAuthor
+			if err := rows.Scan(&author.RecipeID, &author.UserID, &author.Role, &author.CreatedAt); err != nil {
+				return translateError("scanning author row", err)
+			}
+			authors = append(authors, &author)
+		}
+		if err := rows.Err(); err != nil {
+			return translateError("iterating over author rows", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return authors, nil
+}
+
+// RemoveAuthor removes a co-author from a recipe
+func (rr *PostgresRecipeStore) RemoveAuthor(ctx context.Context, recipeID, userID int64) error {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM recipe_authors
+		WHERE recipe_id = $1 AND user_id = $2
+	`
+	result, err := rr.DB.Primary.ExecContext(ctx, query, recipeID, userID)
+	if err != nil {
+		return translateError("removing author", err)
+	}
+	if err := requireAffected(result); err != nil {
+		return translateError("removing author", err)
+	}
+	rr.DB.MarkWrite(ctx)
+	return nil
+}
+
+// scanInvitation scans a row of invitationColumns
+func scanInvitation(row interface{ Scan(...interface{}) error }) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := row.Scan(&invitation.ID, &invitation.RecipeID, &invitation.InviteeID, &invitation.InvitedBy,
+		&invitation.Role, &invitation.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
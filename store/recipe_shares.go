@@ -0,0 +1,205 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"backend-app/models"
+)
+
+// SetRecipeVisibility stores recipe.Visibility and recipe.ShareToken if
+// recipe.Version is still the current version, saves the change as a
+// revision and sets the new version on recipe
+func (rr *PostgresRecipeStore) SetRecipeVisibility(ctx context.Context, recipe *models.Recipe) error {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	query := `
+		WITH recipe AS (
+			UPDATE recipes
+			SET visibility = $1, share_token = $2,
+				updated_by = $5, version = version + 1, updated_at = current_timestamp
+			WHERE id = $3 AND version = $4 AND deleted_at IS NULL
+			RETURNING *
+		)
+	` + saveRevision + `
+		RETURNING version, created_at
+	`
+	tx, err := rr.DB.Primary.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError("starting transaction", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockEditors(ctx, tx, recipe.UpdatedBy); err != nil {
+		return translateError("changing recipe visibility", err)
+	}
+	err = tx.QueryRowContext(ctx, query, recipe.Visibility, nullableString(recipe.ShareToken), recipe.ID, recipe.Version,
+		nullableID(recipe.UpdatedBy)).Scan(&recipe.Version, &recipe.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return staleOrMissing(ctx, tx, recipe.ID, "changing recipe visibility")
+	}
+	if err != nil {
+		return translateError("changing recipe visibility", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return translateError("changing recipe visibility", err)
+	}
+	rr.DB.MarkWrite(ctx)
+	return nil
+}
+
+// GetRecipeByShareToken retrieves the unlisted recipe with the share link
+// token unless it is a draft
+func (rr *PostgresRecipeStore) GetRecipeByShareToken(ctx context.Context, token string) (*models.Recipe, error) {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	var recipe *models.Recipe
+	query := `
+		SELECT ` + recipeColumns + `
+		FROM recipes
+		WHERE share_token = $1 AND visibility = 'unlisted' AND status <> 'draft' AND deleted_at IS NULL
+	`
+	err := rr.DB.Read(ctx, func(db *sql.DB) error {
+		var err error
+		recipe, err = scanRecipe(db.QueryRowContext(ctx, query, token))
+		return err
+	})
+	if err != nil {
+		return nil, translateError("retrieving shared recipe", err)
+	}
+	return recipe, nil
+}
+
+// ShareRecipe shares a recipe with a user on behalf of sharedBy, or changes
+// the permission it is shared with, and sets when it was first shared on
+// share
+func (rr *PostgresRecipeStore) ShareRecipe(ctx context.Context, share *models.RecipeShare, sharedBy int64) error {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO recipe_shares (recipe_id, user_id, permission)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (recipe_id, user_id) DO UPDATE SET permission = EXCLUDED.permission
+		RETURNING created_at
+	`
+	tx, err := rr.DB.Primary.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError("starting transaction", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the recipe and the user so neither is deleted meanwhile
+	var id int64
+	err = tx.QueryRowContext(ctx, `SELECT id FROM recipes WHERE id = $1 AND deleted_at IS NULL FOR SHARE`, share.RecipeID).Scan(&id)
+	if err != nil {
+		return translateError("sharing recipe", err)
+	}
+	err = tx.QueryRowContext(ctx, `SELECT id FROM users WHERE id = $1 AND deleted_at IS NULL FOR SHARE`, share.UserID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = ErrInvalidReference
+	}
+	if err != nil {
+		return translateError("sharing recipe", err)
+	}
+	if err := lockEditors(ctx, tx, sharedBy); err != nil {
+		return translateError("sharing recipe", err)
+	}
+
+	if err := tx.QueryRowContext(ctx, query, share.RecipeID, share.UserID, share.Permission).Scan(&share.CreatedAt); err != nil {
+		return translateError("sharing recipe", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return translateError("sharing recipe", err)
+	}
+	rr.DB.MarkWrite(ctx)
+	return nil
+}
+
+// UnshareRecipe stops sharing a recipe with a user
+func (rr *PostgresRecipeStore) UnshareRecipe(ctx context.Context, recipeID, userID int64) error {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM recipe_shares
+		WHERE recipe_id = $1 AND user_id = $2
+	`
+	result, err := rr.DB.Primary.ExecContext(ctx, query, recipeID, userID)
+	if err != nil {
+		return translateError("unsharing recipe", err)
+	}
+	if err := requireAffected(result); err != nil {
+		return translateError("unsharing recipe", err)
+	}
+	rr.DB.MarkWrite(ctx)
+	return nil
+}
+
+// GetShare retrieves the share of a recipe with a user unless their
+// account is deleted
+func (rr *PostgresRecipeStore) GetShare(ctx context.Context, recipeID, userID int64) (*models.RecipeShare, error) {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	var share models.RecipeShare
+	query := `
+		SELECT s.recipe_id, s.user_id, s.permission, s.created_at
+		FROM recipe_shares s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.recipe_id = $1 AND s.user_id = $2 AND u.deleted_at IS NULL
+	`
+	err := rr.DB.Read(ctx, func(db *sql.DB) error {
+		return db.QueryRowContext(ctx, query, recipeID, userID).
+			Scan(&share.RecipeID, &share.UserID, &share.Permission, &share.CreatedAt)
+	})
+	if err != nil {
+		return nil, translateError("retrieving share", err)
+	}
+	return &share, nil
+}
+
+// ListShares retrieves the users a recipe is shared with, oldest share
+// first, leaving out deleted accounts
+func (rr *PostgresRecipeStore) ListShares(ctx context.Context, recipeID int64) ([]*models.RecipeShare, error) {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	var shares []*models.RecipeShare
+	query := `
+		SELECT s.recipe_id, s.user_id, s.permission, s.created_at
+		FROM recipe_shares s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.recipe_id = $1 AND u.deleted_at IS NULL
+		ORDER BY s.created_at, s.user_id
+	`
+	err := rr.DB.Read(ctx, func(db *sql.DB) error {
+		// Start over if a replica fails and the read is retried
+		shares = nil
+
+		rows, err := db.QueryContext(ctx, query, recipeID)
+		if err != nil {
+			return translateError("retrieving shares", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var share models.RecipeShare
+			if err := rows.Scan(&share.RecipeID, &share.UserID, &share.Permission, &share.CreatedAt); err != nil {
+				return translateError("scanning share row", err)
+			}
+			shares = append(shares, &share)
+		}
+		if err := rows.Err(); err != nil {
+			return translateError("iterating over share rows", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
@@ -0,0 +1,682 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"backend-app/models"
+	"github.com/lib/pq"
+)
+
+type PostgresRecipeStore struct {
+	DB      *Cluster      // Reads are served by replicas when there are any
+	Timeout time.Duration // Per-query timeout, DefaultQueryTimeout if zero
+}
+
+// NewPostgresRecipeStore initializes a new PostgresRecipeStore
+func NewPostgresRecipeStore(db *Cluster) *PostgresRecipeStore {
+	return &PostgresRecipeStore{
+		DB:      db,
+		Timeout: DefaultQueryTimeout,
+	}
+}
+
+// CreateRecipe creates a new recipe in the database
+func (rr *PostgresRecipeStore) CreateRecipe(ctx context.Context, recipe *models.Recipe) (*models.Recipe, error) {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	if recipe.UpdatedBy == 0 {
+		recipe.UpdatedBy = recipe.CreatorID
+	}
+	if recipe.Status == "" {
+		recipe.Status = models.StatusDraft
+	}
+	if recipe.Visibility == "" {
+		recipe.Visibility = models.VisibilityPublic
+	}
+	var origin models.ForkOrigin
+	if recipe.ForkedFrom != nil {
+		origin = *recipe.ForkedFrom
+	}
+	query := `
+			WITH recipe AS (
+				INSERT INTO recipes (title, description, ingredients, steps, prep_time, category_id, creator_id, images, updated_by,
+					forked_from, forked_from_version, forked_from_creator, status, publish_at, published_at, visibility, share_token)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
+					CASE WHEN $13 = 'published' THEN current_timestamp END, $15, $16)
+				RETURNING *
+			)
+		` + saveRevision + `
+			RETURNING recipe_id, version, created_at
+		`
+	tx, err := rr.DB.Primary.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, translateError("starting transaction", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockEditors(ctx, tx, recipe.CreatorID, recipe.UpdatedBy); err != nil {
+		return nil, translateError("creating recipe", err)
+	}
+	if recipe.CategoryID != 0 {
+		if err := lockCategory(ctx, tx, recipe.CategoryID); err != nil {
+			return nil, translateError("creating recipe", err)
+		}
+	}
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		recipe.Title,
+		recipe.Description,
+		textArray(recipe.Ingredients),
+		textArray(recipe.Steps),
+		recipe.PrepTime,
+		nullableID(recipe.CategoryID),
+		recipe.CreatorID,
+		textArray(recipe.Images),
+		recipe.UpdatedBy,
+		nullableID(origin.RecipeID),
+		nullableID(origin.Version),
+		nullableID(origin.CreatorID),
+		recipe.Status,
+		recipe.PublishAt,
+		recipe.Visibility,
+		nullableString(recipe.ShareToken),
+	).Scan(&recipe.ID, &recipe.Version, &recipe.UpdatedAt)
+	if err != nil {
+		return nil, translateError("creating recipe", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, translateError("creating recipe", err)
+	}
+	rr.DB.MarkWrite(ctx)
+	if recipe.Status == models.StatusPublished {
+		// Published in the same transaction, so at the same timestamp
+		publishedAt := recipe.UpdatedAt
+		recipe.PublishedAt = &publishedAt
+	}
+	return recipe, nil
+}
+
+// UpdateRecipe updates an existing recipe in the database if recipe.Version
+// is still the current version, saves the new version as a revision and
+// sets it on recipe
+func (rr *PostgresRecipeStore) UpdateRecipe(ctx context.Context, recipe *models.Recipe) error {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	query := `
+		WITH recipe AS (
+			UPDATE recipes
+			SET title = $1, description = $2, ingredients = $3, steps = $4, prep_time = $5, category_id = $6, images = $7,
+				updated_by = $10, version = version + 1, updated_at = current_timestamp
+			WHERE id = $8 AND version = $9 AND deleted_at IS NULL
+			RETURNING *
+		)
+	` + saveRevision + `
+		RETURNING version, created_at
+	`
+	tx, err := rr.DB.Primary.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError("starting transaction", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockEditors(ctx, tx, recipe.UpdatedBy); err != nil {
+		return translateError("updating recipe", err)
+	}
+	if recipe.CategoryID != 0 {
+		if err := lockCategory(ctx, tx, recipe.CategoryID); err != nil {
+			return translateError("updating recipe", err)
+		}
+	}
+
+	// Images the new version no longer references are released, but stay
+	// in use while a revision references them
+	release := `
+		UPDATE media
+		SET released_at = current_timestamp
+		WHERE path IN (SELECT unnest(images) FROM recipes WHERE id = $1)
+		AND NOT (path = ANY($2))
+	`
+	if _, err := tx.ExecContext(ctx, release, recipe.ID, textArray(recipe.Images)); err != nil {
+		return translateError("releasing recipe images", err)
+	}
+
+	var version int64
+	var updatedAt time.Time
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		recipe.Title,
+		recipe.Description,
+		textArray(recipe.Ingredients),
+		textArray(recipe.Steps),
+		recipe.PrepTime,
+		nullableID(recipe.CategoryID),
+		textArray(recipe.Images),
+		recipe.ID,
+		recipe.Version,
+		nullableID(recipe.UpdatedBy),
+	).Scan(&version, &updatedAt)
+	if err == sql.ErrNoRows {
+		return staleOrMissing(ctx, tx, recipe.ID, "updating recipe")
+	}
+	if err != nil {
+		return translateError("updating recipe", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return translateError("updating recipe", err)
+	}
+	rr.DB.MarkWrite(ctx)
+	recipe.Version = version
+	recipe.UpdatedAt = updatedAt
+	return nil
+}
+
+// lockEditors locks the accounts a change is attributed to with lockUser.
+// Zero IDs, for changes attributed to nobody, are skipped.
+func lockEditors(ctx context.Context, tx *sql.Tx, userIDs ...int64) error {
+	for _, userID := range userIDs {
+		if userID == 0 {
+			continue
+		}
+		if err := lockUser(ctx, tx, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// staleOrMissing explains why a statement expecting a recipe version found
+// no row: the recipe has either moved on to another version or does not
+// exist
+func staleOrMissing(ctx context.Context, tx *sql.Tx, recipeID int64, action string) error {
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM recipes WHERE id = $1 AND deleted_at IS NULL)`, recipeID).Scan(&exists); err != nil {
+		return translateError(action, err)
+	}
+	if exists {
+		return fmt.Errorf("%s: %w", action, ErrStale)
+	}
+	return translateError(action, sql.ErrNoRows)
+}
+
+// SetRecipeStatus stores recipe.Status and recipe.PublishAt if
+// recipe.Version is still the current version, saves the change as a
+// revision and sets the new version on recipe
+func (rr *PostgresRecipeStore) SetRecipeStatus(ctx context.Context, recipe *models.Recipe) error {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	query := `
+		WITH recipe AS (
+			UPDATE recipes
+			SET status = $1, publish_at = $2,
+				published_at = CASE WHEN $1 = 'published' THEN COALESCE(published_at, current_timestamp) ELSE published_at END,
+				updated_by = $5, version = version + 1, updated_at = current_timestamp
+			WHERE id = $3 AND version = $4 AND deleted_at IS NULL
+			RETURNING *
+		), revision AS (
+	` + saveRevision + `
+		)
+		SELECT version, updated_at, published_at FROM recipe
+	`
+	tx, err := rr.DB.Primary.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError("starting transaction", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockEditors(ctx, tx, recipe.UpdatedBy); err != nil {
+		return translateError("changing recipe status", err)
+	}
+	var publishedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, query, recipe.Status, recipe.PublishAt, recipe.ID, recipe.Version, nullableID(recipe.UpdatedBy)).
+		Scan(&recipe.Version, &recipe.UpdatedAt, &publishedAt)
+	if err == sql.ErrNoRows {
+		return staleOrMissing(ctx, tx, recipe.ID, "changing recipe status")
+	}
+	if err != nil {
+		return translateError("changing recipe status", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return translateError("changing recipe status", err)
+	}
+	rr.DB.MarkWrite(ctx)
+	recipe.PublishedAt = nil
+	if publishedAt.Valid {
+		recipe.PublishedAt = &publishedAt.Time
+	}
+	return nil
+}
+
+// PublishDueRecipes publishes the drafts scheduled at or before now, saves
+// each as a revision and returns their IDs. The revision is attributed to
+// whoever scheduled the publication.
+func (rr *PostgresRecipeStore) PublishDueRecipes(ctx context.Context, now time.Time) ([]int64, error) {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	query := `
+		WITH recipe AS (
+			UPDATE recipes
+			SET status = 'published', published_at = publish_at, publish_at = NULL,
+				version = version + 1, updated_at = current_timestamp
+			WHERE status = 'draft' AND publish_at <= $1 AND deleted_at IS NULL
+			RETURNING *
+		)
+	` + saveRevision + `
+		RETURNING recipe_id
+	`
+	rows, err := rr.DB.Primary.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, translateError("publishing recipes", err)
+	}
+	defer rows.Close()
+
+	var published []int64
+	for rows.Next() {
+		var recipeID int64
+		if err := rows.Scan(&recipeID); err != nil {
+			return nil, translateError("scanning published recipe", err)
+		}
+		published = append(published, recipeID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError("publishing recipes", err)
+	}
+	if len(published) > 0 {
+		rr.DB.MarkWrite(ctx)
+	}
+	return published, nil
+}
+
+// DeleteRecipe moves a recipe to its creator's trash on behalf of
+// deletedBy. Its images stay in use until it is purged.
+func (rr *PostgresRecipeStore) DeleteRecipe(ctx context.Context, recipeID, deletedBy int64) error {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	query := `
+		UPDATE recipes
+		SET deleted_at = current_timestamp
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	tx, err := rr.DB.Primary.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError("starting transaction", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockEditors(ctx, tx, deletedBy); err != nil {
+		return translateError("deleting recipe", err)
+	}
+	result, err := tx.ExecContext(ctx, query, recipeID)
+	if err != nil {
+		return translateError("deleting recipe", err)
+	}
+	if err := requireAffected(result); err != nil {
+		return translateError("deleting recipe", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return translateError("deleting recipe", err)
+	}
+	rr.DB.MarkWrite(ctx)
+	return nil
+}
+
+// ListDeletedRecipes retrieves the recipes in a user's trash, most recently
+// deleted first
+func (rr *PostgresRecipeStore) ListDeletedRecipes(ctx context.Context, creatorID int64) ([]*models.Recipe, error) {
+	query := `
+		SELECT ` + recipeColumns + `
+		FROM recipes
+		WHERE creator_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id
+	`
+	return rr.listRecipes(ctx, query, creatorID)
+}
+
+// RestoreRecipe takes a recipe out of its creator's trash if it was deleted
+// at or after deletedAfter
+func (rr *PostgresRecipeStore) RestoreRecipe(ctx context.Context, recipeID, creatorID int64, deletedAfter time.Time) error {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	query := `
+		UPDATE recipes
+		SET deleted_at = NULL
+		WHERE id = $1 AND creator_id = $2 AND deleted_at >= $3
+	`
+	result, err := rr.DB.Primary.ExecContext(ctx, query, recipeID, creatorID, deletedAfter)
+	if err != nil {
+		return translateError("restoring recipe", err)
+	}
+	if err := requireAffected(result); err != nil {
+		return translateError("restoring recipe", err)
+	}
+	rr.DB.MarkWrite(ctx)
+	return nil
+}
+
+// PurgeRecipes permanently deletes recipes deleted before the given time
+// with their revisions and releases the images they referenced
+func (rr *PostgresRecipeStore) PurgeRecipes(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM recipes
+		WHERE deleted_at < $1
+	`
+	tx, err := rr.DB.Primary.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, translateError("starting transaction", err)
+	}
+	defer tx.Rollback()
+
+	// Release the images of every revision so the media sweeper can collect
+	// them once the revisions are deleted with the recipe
+	release := `
+		UPDATE media
+		SET released_at = current_timestamp
+		WHERE path IN (
+			SELECT unnest(v.images) FROM recipe_revisions v
+			JOIN recipes r ON r.id = v.recipe_id
+			WHERE r.deleted_at < $1
+		)
+		OR path IN (SELECT unnest(images) FROM recipes WHERE deleted_at < $1)
+	`
+	if _, err := tx.ExecContext(ctx, release, before); err != nil {
+		return 0, translateError("releasing recipe images", err)
+	}
+
+	result, err := tx.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, translateError("purging recipes", err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, translateError("purging recipes", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, translateError("purging recipes", err)
+	}
+	rr.DB.MarkWrite(ctx)
+	return purged, nil
+}
+
+// GetRecipeByID retrieves a recipe from the database by ID
+func (rr *PostgresRecipeStore) GetRecipeByID(ctx context.Context, recipeID int64) (*models.Recipe, error) {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	var recipe *models.Recipe
+	query := `
+		SELECT ` + recipeColumns + `
+		FROM recipes
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	err := rr.DB.Read(ctx, func(db *sql.DB) error {
+		var err error
+		recipe, err = scanRecipe(db.QueryRowContext(ctx, query, recipeID))
+		return err
+	})
+	if err != nil {
+		return nil, translateError("retrieving recipe", err)
+	}
+	return recipe, nil
+}
+
+// GetAllRecipes retrieves the published recipes that are public or shared
+// with the viewer, and all of the viewer's own recipes
+func (rr *PostgresRecipeStore) GetAllRecipes(ctx context.Context, viewerID int64) ([]*models.Recipe, error) {
+	query := `
+		SELECT ` + recipeColumns + `
+		FROM recipes
+		WHERE deleted_at IS NULL AND ` + listableBy("$1") + `
+		ORDER BY id
+	`
+	return rr.listRecipes(ctx, query, viewerID)
+}
+
+// ListForks retrieves the recipes forked directly from a recipe that the
+// viewer can list, oldest first. The recipe itself need not exist any more.
+func (rr *PostgresRecipeStore) ListForks(ctx context.Context, recipeID, viewerID int64) ([]*models.Recipe, error) {
+	query := `
+		SELECT ` + recipeColumns + `
+		FROM recipes
+		WHERE forked_from = $1 AND deleted_at IS NULL AND ` + listableBy("$2") + `
+		ORDER BY id
+	`
+	return rr.listRecipes(ctx, query, recipeID, viewerID)
+}
+
+// listableBy is the condition under which the viewer passed as the given
+// parameter, e.g. "$1", sees a recipe in lists
+func listableBy(viewer string) string {
+	return `(creator_id = ` + viewer + `
+		OR id IN (SELECT recipe_id FROM recipe_authors WHERE user_id = ` + viewer + `)
+		OR status = 'published' AND (visibility = 'public'
+			OR id IN (SELECT recipe_id FROM recipe_shares WHERE user_id = ` + viewer + `)))`
+}
+
+// listRecipes runs a query selecting recipeColumns
+func (rr *PostgresRecipeStore) listRecipes(ctx context.Context, query string, args ...interface{}) ([]*models.Recipe, error) {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	var recipes []*models.Recipe
+	err := rr.DB.Read(ctx, func(db *sql.DB) error {
+		// Start over if a replica fails and the read is retried
+		recipes = nil
+
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return translateError("retrieving recipes", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			recipe, err := scanRecipe(rows)
+			if err != nil {
+				return translateError("scanning recipe row", err)
+			}
+			recipes = append(recipes, recipe)
+		}
+		if err := rows.Err(); err != nil {
+			return translateError("iterating over recipe rows", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recipes, nil
+}
+
+const recipeColumns = `id, title, COALESCE(description, ''), ingredients, steps, COALESCE(prep_time, 0), category_id, creator_id,
+	images, version, updated_at, updated_by, forked_from, forked_from_version, forked_from_creator, deleted_at, status, publish_at,
+	published_at, visibility, COALESCE(share_token, '')`
+
+// scanRecipe scans a row of recipeColumns
+func scanRecipe(row interface{ Scan(...interface{}) error }) (*models.Recipe, error) {
+	var recipe models.Recipe
+	var categoryID, updatedBy, forkedFrom, forkedVersion, forkedCreator sql.NullInt64
+	var deletedAt, publishAt, publishedAt sql.NullTime
+	err := row.Scan(
+		&recipe.ID,
+		&recipe.Title,
+		&recipe.Description,
+		pq.Array(&recipe.Ingredients),
+		pq.Array(&recipe.Steps),
+		&recipe.PrepTime,
+		&categoryID,
+		&recipe.CreatorID,
+		pq.Array(&recipe.Images),
+		&recipe.Version,
+		&recipe.UpdatedAt,
+		&updatedBy,
+		&forkedFrom,
+		&forkedVersion,
+		&forkedCreator,
+		&deletedAt,
+		&recipe.Status,
+		&publishAt,
+		&publishedAt,
+		&recipe.Visibility,
+		&recipe.ShareToken,
+	)
+	if err != nil {
+		return nil, err
+	}
+	recipe.CategoryID = categoryID.Int64
+	recipe.UpdatedBy = updatedBy.Int64
+	if forkedFrom.Valid {
+		recipe.ForkedFrom = &models.ForkOrigin{
+			RecipeID:  forkedFrom.Int64,
+			Version:   forkedVersion.Int64,
+			CreatorID: forkedCreator.Int64,
+		}
+	}
+	if deletedAt.Valid {
+		recipe.DeletedAt = &deletedAt.Time
+	}
+	if publishAt.Valid {
+		recipe.PublishAt = &publishAt.Time
+	}
+	if publishedAt.Valid {
+		recipe.PublishedAt = &publishedAt.Time
+	}
+	return &recipe, nil
+}
+
+// saveRevision completes a statement whose "recipe" CTE returns a created
+// or updated recipe row by saving that row as a revision
+const saveRevision = `
+	INSERT INTO recipe_revisions (recipe_id, version, title, description, ingredients, steps, prep_time, category_id, images, editor_id, created_at)
+	SELECT id, version, title, description, ingredients, steps, prep_time, category_id, images, updated_by, updated_at
+	FROM recipe
+`
+
+// ListRevisions retrieves every revision of a recipe, newest first
+func (rr *PostgresRecipeStore) ListRevisions(ctx context.Context, recipeID int64) ([]*models.Revision, error) {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	var revisions []*models.Revision
+	query := `
+		SELECT ` + revisionColumns + `
+		FROM recipe_revisions
+		WHERE recipe_id = $1
+		AND EXISTS (SELECT 1 FROM recipes WHERE id = $1 AND deleted_at IS NULL)
+		ORDER BY version DESC
+	`
+	err := rr.DB.Read(ctx, func(db *sql.DB) error {
+		// Start over if a replica fails and the read is retried
+		revisions = nil
+
+		rows, err := db.QueryContext(ctx, query, recipeID)
+		if err != nil {
+			return translateError("retrieving revisions", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			revision, err := scanRevision(rows)
+			if err != nil {
+				return translateError("scanning revision row", err)
+			}
+			revisions = append(revisions, revision)
+		}
+		if err := rows.Err(); err != nil {
+			return translateError("iterating over revision rows", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Every recipe has at least the revision it was created as
+	if len(revisions) == 0 {
+		return nil, translateError("retrieving revisions", sql.ErrNoRows)
+	}
+	return revisions, nil
+}
+
+// GetRevision retrieves one revision of a recipe
+func (rr *PostgresRecipeStore) GetRevision(ctx context.Context, recipeID, version int64) (*models.Revision, error) {
+	ctx, cancel := withTimeout(ctx, rr.Timeout)
+	defer cancel()
+
+	var revision *models.Revision
+	query := `
+		SELECT ` + revisionColumns + `
+		FROM recipe_revisions
+		WHERE recipe_id = $1 AND version = $2
+		AND EXISTS (SELECT 1 FROM recipes WHERE id = $1 AND deleted_at IS NULL)
+	`
+	err := rr.DB.Read(ctx, func(db *sql.DB) error {
+		var err error
+		revision, err = scanRevision(db.QueryRowContext(ctx, query, recipeID, version))
+		return err
+	})
+	if err != nil {
+		return nil, translateError("retrieving revision", err)
+	}
+	return revision, nil
+}
+
+const revisionColumns = `recipe_id, version, title, COALESCE(description, ''), ingredients, steps, COALESCE(prep_time, 0),
+	category_id, images, editor_id, created_at`
+
+// scanRevision scans a row of revisionColumns
+func scanRevision(row interface{ Scan(...interface{}) error }) (*models.Revision, error) {
+	var revision models.Revision
+	var categoryID, editorID sql.NullInt64
+	err := row.Scan(
+		&revision.RecipeID,
+		&revision.Version,
+		&revision.Title,
+		&revision.Description,
+		pq.Array(&revision.Ingredients),
+		pq.Array(&revision.Steps),
+		&revision.PrepTime,
+		&categoryID,
+		pq.Array(&revision.Images),
+		&editorID,
+		&revision.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	revision.CategoryID = categoryID.Int64
+	revision.EditorID = editorID.Int64
+	return &revision, nil
+}
+
+// textArray encodes values as a PostgreSQL TEXT[], using an empty array
+// rather than NULL for a nil slice
+func textArray(values []string) interface{} {
+	if values == nil {
+		values = []string{}
+	}
+	return pq.Array(values)
+}
+
+// nullableString stores an empty string as NULL
+func nullableString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullableID stores a zero ID as NULL, the way optional foreign keys are
+// represented in the schema
+func nullableID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
@@ -0,0 +1,16 @@
+package store
+
+import "database/sql"
+
+// requireAffected returns sql.ErrNoRows when an UPDATE or DELETE matched no
+// row, so callers can tell a missing record apart from a successful write
+func requireAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
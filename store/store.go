@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"backend-app/models"
+)
+
+// UserStore stores user accounts. Emails are unique among live accounts.
+// Deleting a user deletes the recipes they created with them; both are kept,
+// hidden from the other methods, until they are restored or purged.
+type UserStore interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	UpdateUser(ctx context.Context, user *models.User) error
+	DeleteUser(ctx context.Context, userID int64) error
+	GetUserByID(ctx context.Context, userID int64) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	// GetUserByUsername retrieves the user with the username. Usernames are
+	// not unique, so it fails with ErrConflict if several users share it.
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	// GetDeletedUserByEmail retrieves the most recently deleted account
+	// with the email that was deleted at or after deletedAfter
+	GetDeletedUserByEmail(ctx context.Context, email string, deletedAfter time.Time) (*models.User, error)
+	// RestoreUser restores an account deleted at or after deletedAfter
+	// together with the recipes deleted with it
+	RestoreUser(ctx context.Context, userID int64, deletedAfter time.Time) error
+	// PurgeUsers permanently deletes accounts deleted before the given time
+	// and returns how many were deleted
+	PurgeUsers(ctx context.Context, before time.Time) (int64, error)
+}
+
+// CategoryStore stores recipe categories. Names are unique among live
+// categories. Deleting a category moves its recipes, including deleted
+// ones, to reassignTo and returns their IDs; a zero reassignTo makes
+// deleted recipes uncategorized and blocks deletion while live recipes
+// belong to it. Deleted categories are hidden until they are purged.
+type CategoryStore interface {
+	CreateCategory(ctx context.Context, category *models.Category) error
+	UpdateCategory(ctx context.Context, category *models.Category) error
+	DeleteCategory(ctx context.Context, categoryID, reassignTo int64) ([]int64, error)
+	GetCategoryByID(ctx context.Context, categoryID int64) (*models.Category, error)
+	GetAllCategories(ctx context.Context) ([]*models.Category, error)
+	// PurgeCategories permanently deletes categories deleted before the
+	// given time and returns how many were deleted
+	PurgeCategories(ctx context.Context, before time.Time) (int64, error)
+}
+
+// RecipeStore stores recipes. A recipe's creator and category must
+// exist; a zero CategoryID means the recipe is uncategorized. Creating or
+// updating a recipe saves the new version as a revision, and revisions are
+// purged with their recipe. Images stay in use while the recipe or one of
+// its revisions references them. A fork keeps its ForkedFrom attribution
+// after the recipe it was forked from is deleted.
+//
+// Changes are attributed to live accounts: creating a recipe, or changing,
+// deleting, sharing it or inviting authors to it, on behalf of a deleted
+// account fails with ErrInvalidReference, so a deleted account's token
+// cannot act on recipes or add ones that would keep it from being purged.
+//
+// Deleted recipes are moved to their creator's trash, where they are
+// hidden from the other methods, until they are restored or purged.
+//
+// Recipes created without a status are drafts, and those without a
+// visibility are public. Updates leave both alone; they only change through
+// SetRecipeStatus, PublishDueRecipes and SetRecipeVisibility. Reads by ID
+// do not check the viewer's access, since the service decides it from the
+// recipe, the viewer's share and authorship; lists only return what the
+// viewer may see.
+type RecipeStore interface {
+	CreateRecipe(ctx context.Context, recipe *models.Recipe) (*models.Recipe, error)
+	UpdateRecipe(ctx context.Context, recipe *models.Recipe) error
+	// DeleteRecipe moves a recipe to its creator's trash on behalf of
+	// deletedBy
+	DeleteRecipe(ctx context.Context, recipeID, deletedBy int64) error
+	GetRecipeByID(ctx context.Context, recipeID int64) (*models.Recipe, error)
+	// GetAllRecipes retrieves the published recipes that are public or
+	// shared with the viewer, and all of the recipes the viewer created or
+	// co-authors. A zero
+	// viewerID stands for an anonymous viewer.
+	GetAllRecipes(ctx context.Context, viewerID int64) ([]*models.Recipe, error)
+	// ListForks retrieves the forks of a recipe the viewer can list, like
+	// GetAllRecipes
+	ListForks(ctx context.Context, recipeID, viewerID int64) ([]*models.Recipe, error)
+	// SetRecipeStatus stores recipe.Status and recipe.PublishAt if
+	// recipe.Version is still the current version. The change is saved as
+	// a new version.
+	SetRecipeStatus(ctx context.Context, recipe *models.Recipe) error
+	// PublishDueRecipes publishes the drafts scheduled at or before now and
+	// returns their IDs
+	PublishDueRecipes(ctx context.Context, now time.Time) ([]int64, error)
+	// SetRecipeVisibility stores recipe.Visibility and recipe.ShareToken
+	// like SetRecipeStatus
+	SetRecipeVisibility(ctx context.Context, recipe *models.Recipe) error
+	// GetRecipeByShareToken retrieves the unlisted recipe with the share
+	// link token unless it is a draft
+	GetRecipeByShareToken(ctx context.Context, token string) (*models.Recipe, error)
+	// ShareRecipe shares a recipe with a user, or changes the permission it
+	// is shared with, on behalf of sharedBy. The user must exist.
+	ShareRecipe(ctx context.Context, share *models.RecipeShare, sharedBy int64) error
+	UnshareRecipe(ctx context.Context, recipeID, userID int64) error
+	// GetShare retrieves the share of a recipe with a user. Shares with
+	// deleted accounts are not found.
+	GetShare(ctx context.Context, recipeID, userID int64) (*models.RecipeShare, error)
+	// ListShares retrieves the users a recipe is shared with, oldest share
+	// first. Shares with deleted accounts are hidden until the account is
+	// restored.
+	ListShares(ctx context.Context, recipeID int64) ([]*models.RecipeShare, error)
+	// InviteAuthor stores a pending invitation to become an author of a
+	// recipe and sets its ID and creation time. It fails with ErrConflict if
+	// the invitee is already invited or a co-author, and with
+	// ErrInvalidReference if the invitee does not exist.
+	InviteAuthor(ctx context.Context, invitation *models.Invitation) error
+	GetInvitation(ctx context.Context, invitationID int64) (*models.Invitation, error)
+	// ListInvitations retrieves a user's pending invitations to recipes that
+	// are not deleted, oldest first
+	ListInvitations(ctx context.Context, inviteeID int64) ([]*models.Invitation, error)
+	// AcceptInvitation makes the invitee a co-author with the role they were
+	// invited with and removes the invitation. Invitations to deleted
+	// accounts are not found.
+	AcceptInvitation(ctx context.Context, invitationID int64) (*models.RecipeAuthor, error)
+	DeleteInvitation(ctx context.Context, invitationID int64) error
+	// GetAuthor retrieves a co-author of a recipe. Its creator is not
+	// stored as one, and deleted accounts are not found.
+	GetAuthor(ctx context.Context, recipeID, userID int64) (*models.RecipeAuthor, error)
+	// ListAuthors retrieves the co-authors of a recipe, oldest first, hiding
+	// deleted accounts like ListShares
+	ListAuthors(ctx context.Context, recipeID int64) ([]*models.RecipeAuthor, error)
+	// ListAuthorships retrieves the recipes a user co-authors, by recipe ID,
+	// and nothing for a deleted account
+	ListAuthorships(ctx context.Context, userID int64) ([]*models.RecipeAuthor, error)
+	RemoveAuthor(ctx context.Context, recipeID, userID int64) error
+	ListRevisions(ctx context.Context, recipeID int64) ([]*models.Revision, error)
+	GetRevision(ctx context.Context, recipeID, version int64) (*models.Revision, error)
+	// ListDeletedRecipes retrieves the recipes in a user's trash, most
+	// recently deleted first
+	ListDeletedRecipes(ctx context.Context, creatorID int64) ([]*models.Recipe, error)
+	// RestoreRecipe takes a recipe out of its creator's trash if it was
+	// deleted at or after deletedAfter
+	RestoreRecipe(ctx context.Context, recipeID, creatorID int64, deletedAfter time.Time) error
+	// PurgeRecipes permanently deletes recipes deleted before the given time
+	// with their revisions, releases the images they referenced and returns
+	// how many were deleted
+	PurgeRecipes(ctx context.Context, before time.Time) (int64, error)
+}
+
+// MediaStore tracks uploaded media for garbage collection
+type MediaStore interface {
+	TrackMedia(ctx context.Context, path string) error
+	OrphanedMedia(ctx context.Context, before time.Time) ([]string, error)
+	ForgetMedia(ctx context.Context, path string) error
+}
+
+var (
+	_ UserStore     = (*PostgresUserStore)(nil)
+	_ CategoryStore = (*PostgresCategoryStore)(nil)
+	_ RecipeStore   = (*PostgresRecipeStore)(nil)
+	_ MediaStore    = (*PostgresMediaStore)(nil)
+
+	_ UserStore     = (*InstrumentedUserStore)(nil)
+	_ CategoryStore = (*InstrumentedCategoryStore)(nil)
+	_ RecipeStore   = (*InstrumentedRecipeStore)(nil)
+	_ MediaStore    = (*InstrumentedMediaStore)(nil)
+
+	_ UserStore     = (*CachedUserStore)(nil)
+	_ CategoryStore = (*CachedCategoryStore)(nil)
+	_ RecipeStore   = (*CachedRecipeStore)(nil)
+)
@@ -0,0 +1,1107 @@
+// Package storetest holds contract tests that every implementation of the
+// store interfaces must pass. The PostgreSQL stores and the in-memory stores
+// run the same suite, which keeps the fakes used by service and controller
+// tests honest.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"backend-app/models"
+	"backend-app/store"
+)
+
+// Stores is one set of stores sharing a backing database
+type Stores struct {
+	Users      store.UserStore
+	Categories store.CategoryStore
+	Recipes    store.RecipeStore
+	Media      store.MediaStore
+}
+
+// Run runs the contract suite. newStores must return stores over an empty
+// database each time it is called.
+func Run(t *testing.T, newStores func(t *testing.T) Stores) {
+	t.Run("Users", func(t *testing.T) { testUsers(t, newStores) })
+	t.Run("Categories", func(t *testing.T) { testCategories(t, newStores) })
+	t.Run("Recipes", func(t *testing.T) { testRecipes(t, newStores) })
+	t.Run("Media", func(t *testing.T) { testMedia(t, newStores) })
+}
+
+func testUsers(t *testing.T, newStores func(t *testing.T) Stores) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		stores := newStores(t)
+		user := createUser(t, stores, "abebe@example.com")
+		if user.ID == 0 {
+			t.Fatal("CreateUser did not assign an ID")
+		}
+
+		byID, err := stores.Users.GetUserByID(ctx, int64(user.ID))
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		byEmail, err := stores.Users.GetUserByEmail(ctx, user.Email)
+		if err != nil {
+			t.Fatalf("GetUserByEmail: %v", err)
+		}
+		for _, got := range []*models.User{byID, byEmail} {
+			if got.ID != user.ID || got.Username != user.Username || got.PasswordHash != user.PasswordHash {
+				t.Errorf("stored user = %+v, want %+v", got, user)
+			}
+		}
+	})
+
+	t.Run("DuplicateEmail", func(t *testing.T) {
+		stores := newStores(t)
+		createUser(t, stores, "abebe@example.com")
+		err := stores.Users.CreateUser(ctx, &models.User{Username: "other", Email: "abebe@example.com", PasswordHash: "hash"})
+		expectError(t, err, store.ErrConflict)
+
+		other := createUser(t, stores, "other@example.com")
+		other.Email = "abebe@example.com"
+		expectError(t, stores.Users.UpdateUser(ctx, other), store.ErrConflict)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		stores := newStores(t)
+		_, err := stores.Users.GetUserByID(ctx, 404)
+		expectError(t, err, store.ErrNotFound)
+		_, err = stores.Users.GetUserByEmail(ctx, "nobody@example.com")
+		expectError(t, err, store.ErrNotFound)
+		expectError(t, stores.Users.UpdateUser(ctx, &models.User{ID: 404, Username: "x", Email: "x@example.com"}), store.ErrNotFound)
+		expectError(t, stores.Users.DeleteUser(ctx, 404), store.ErrNotFound)
+	})
+
+	t.Run("ByUsername", func(t *testing.T) {
+		stores := newStores(t)
+		user := createUser(t, stores, "abebe@example.com")
+		got, err := stores.Users.GetUserByUsername(ctx, user.Username)
+		if err != nil || got.ID != user.ID {
+			t.Fatalf("GetUserByUsername = %+v, %v; want user %d", got, err, user.ID)
+		}
+		_, err = stores.Users.GetUserByUsername(ctx, "nobody")
+		expectError(t, err, store.ErrNotFound)
+
+		// Usernames are not unique
+		other := createUser(t, stores, "other@example.com")
+		_, err = stores.Users.GetUserByUsername(ctx, user.Username)
+		expectError(t, err, store.ErrConflict)
+		if err := stores.Users.DeleteUser(ctx, int64(other.ID)); err != nil {
+			t.Fatalf("DeleteUser: %v", err)
+		}
+		if got, err := stores.Users.GetUserByUsername(ctx, user.Username); err != nil || got.ID != user.ID {
+			t.Errorf("GetUserByUsername after deleting the namesake = %+v, %v", got, err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		stores := newStores(t)
+		user := createUser(t, stores, "abebe@example.com")
+		user.Username = "abebe-b"
+		if err := stores.Users.UpdateUser(ctx, user); err != nil {
+			t.Fatalf("UpdateUser: %v", err)
+		}
+		got, err := stores.Users.GetUserByID(ctx, int64(user.ID))
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		if got.Username != "abebe-b" {
+			t.Errorf("username = %q, want abebe-b", got.Username)
+		}
+	})
+
+	t.Run("DeleteAndRestore", func(t *testing.T) {
+		stores := newStores(t)
+		past := time.Now().Add(-time.Hour)
+		future := time.Now().Add(time.Hour)
+		user := createUser(t, stores, "abebe@example.com")
+		kept := createRecipe(t, stores, user, nil)
+		trashed := createRecipe(t, stores, user, nil)
+		if err := stores.Recipes.DeleteRecipe(ctx, trashed.ID, trashed.CreatorID); err != nil {
+			t.Fatalf("DeleteRecipe: %v", err)
+		}
+
+		// The account's recipes are deleted with it
+		if err := stores.Users.DeleteUser(ctx, int64(user.ID)); err != nil {
+			t.Fatalf("DeleteUser: %v", err)
+		}
+		_, err := stores.Users.GetUserByID(ctx, int64(user.ID))
+		expectError(t, err, store.ErrNotFound)
+		_, err = stores.Users.GetUserByEmail(ctx, user.Email)
+		expectError(t, err, store.ErrNotFound)
+		_, err = stores.Recipes.GetRecipeByID(ctx, kept.ID)
+		expectError(t, err, store.ErrNotFound)
+		expectError(t, stores.Users.DeleteUser(ctx, int64(user.ID)), store.ErrNotFound)
+
+		deleted, err := stores.Users.GetDeletedUserByEmail(ctx, user.Email, past)
+		if err != nil {
+			t.Fatalf("GetDeletedUserByEmail: %v", err)
+		}
+		if deleted.ID != user.ID {
+			t.Errorf("deleted user = %+v, want %d", deleted, user.ID)
+		}
+		_, err = stores.Users.GetDeletedUserByEmail(ctx, user.Email, future)
+		expectError(t, err, store.ErrNotFound)
+
+		// Restoring brings back the recipes deleted with the account only
+		expectError(t, stores.Users.RestoreUser(ctx, int64(user.ID), future), store.ErrNotFound)
+		if err := stores.Users.RestoreUser(ctx, int64(user.ID), past); err != nil {
+			t.Fatalf("RestoreUser: %v", err)
+		}
+		if _, err := stores.Users.GetUserByID(ctx, int64(user.ID)); err != nil {
+			t.Errorf("GetUserByID after restore: %v", err)
+		}
+		if _, err := stores.Recipes.GetRecipeByID(ctx, kept.ID); err != nil {
+			t.Errorf("GetRecipeByID after restore: %v", err)
+		}
+		_, err = stores.Recipes.GetRecipeByID(ctx, trashed.ID)
+		expectError(t, err, store.ErrNotFound)
+	})
+
+	t.Run("DeletedEmailReuseAndPurge", func(t *testing.T) {
+		stores := newStores(t)
+		user := createUser(t, stores, "abebe@example.com")
+		createRecipe(t, stores, user, nil)
+		if err := stores.Users.DeleteUser(ctx, int64(user.ID)); err != nil {
+			t.Fatalf("DeleteUser: %v", err)
+		}
+
+		// A deleted account gives up its email, and cannot be restored once
+		// someone else has taken it
+		createUser(t, stores, "abebe@example.com")
+		expectError(t, stores.Users.RestoreUser(ctx, int64(user.ID), time.Now().Add(-time.Hour)), store.ErrConflict)
+
+		// Accounts are purged after their recipes
+		future := time.Now().Add(time.Hour)
+		expectPurged(t, "users", 0)(stores.Users.PurgeUsers(ctx, future))
+		expectPurged(t, "recipes", 1)(stores.Recipes.PurgeRecipes(ctx, future))
+		expectPurged(t, "users", 1)(stores.Users.PurgeUsers(ctx, future))
+		_, err := stores.Users.GetDeletedUserByEmail(ctx, user.Email, time.Time{})
+		expectError(t, err, store.ErrNotFound)
+	})
+}
+
+func testCategories(t *testing.T, newStores func(t *testing.T) Stores) {
+	ctx := context.Background()
+
+	t.Run("CreateAndList", func(t *testing.T) {
+		stores := newStores(t)
+		breakfast := createCategory(t, stores, "Breakfast")
+		lunch := createCategory(t, stores, "Lunch")
+
+		got, err := stores.Categories.GetAllCategories(ctx)
+		if err != nil {
+			t.Fatalf("GetAllCategories: %v", err)
+		}
+		if len(got) != 2 || got[0].ID != breakfast.ID || got[1].ID != lunch.ID {
+			t.Errorf("categories = %+v, want Breakfast and Lunch ordered by ID", got)
+		}
+	})
+
+	t.Run("DuplicateName", func(t *testing.T) {
+		stores := newStores(t)
+		createCategory(t, stores, "Breakfast")
+		expectError(t, stores.Categories.CreateCategory(ctx, &models.Category{Name: "Breakfast"}), store.ErrConflict)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		stores := newStores(t)
+		_, err := stores.Categories.GetCategoryByID(ctx, 404)
+		expectError(t, err, store.ErrNotFound)
+		expectError(t, stores.Categories.UpdateCategory(ctx, &models.Category{ID: 404, Name: "x"}), store.ErrNotFound)
+		_, err = stores.Categories.DeleteCategory(ctx, 404, 0)
+		expectError(t, err, store.ErrNotFound)
+	})
+
+	t.Run("Rename", func(t *testing.T) {
+		stores := newStores(t)
+		category := createCategory(t, stores, "Breakfast")
+		category.Name = "Brunch"
+		if err := stores.Categories.UpdateCategory(ctx, category); err != nil {
+			t.Fatalf("UpdateCategory: %v", err)
+		}
+		got, err := stores.Categories.GetCategoryByID(ctx, int64(category.ID))
+		if err != nil {
+			t.Fatalf("GetCategoryByID: %v", err)
+		}
+		if got.Name != "Brunch" {
+			t.Errorf("name = %q, want Brunch", got.Name)
+		}
+	})
+
+	t.Run("DeleteInUse", func(t *testing.T) {
+		stores := newStores(t)
+		user := createUser(t, stores, "abebe@example.com")
+		category := createCategory(t, stores, "Breakfast")
+		createRecipe(t, stores, user, category)
+		for _, reassignTo := range []int64{0, int64(category.ID), 404} {
+			_, err := stores.Categories.DeleteCategory(ctx, int64(category.ID), reassignTo)
+			expectError(t, err, store.ErrInvalidReference)
+		}
+	})
+
+	t.Run("DeleteReassigns", func(t *testing.T) {
+		stores := newStores(t)
+		user := createUser(t, stores, "abebe@example.com")
+		breakfast := createCategory(t, stores, "Breakfast")
+		brunch := createCategory(t, stores, "Brunch")
+		live := createRecipe(t, stores, user, breakfast)
+		trashed := createRecipe(t, stores, user, breakfast)
+		if err := stores.Recipes.DeleteRecipe(ctx, trashed.ID, trashed.CreatorID); err != nil {
+			t.Fatalf("DeleteRecipe: %v", err)
+		}
+
+		// Reading the recipe first caches it in stores that cache
+		if _, err := stores.Recipes.GetRecipeByID(ctx, live.ID); err != nil {
+			t.Fatalf("GetRecipeByID: %v", err)
+		}
+		moved, err := stores.Categories.DeleteCategory(ctx, int64(breakfast.ID), int64(brunch.ID))
+		if err != nil {
+			t.Fatalf("DeleteCategory: %v", err)
+		}
+		if len(moved) != 2 {
+			t.Errorf("moved recipes = %v, want both", moved)
+		}
+		_, err = stores.Categories.GetCategoryByID(ctx, int64(breakfast.ID))
+		expectError(t, err, store.ErrNotFound)
+		if all, _ := stores.Categories.GetAllCategories(ctx); len(all) != 1 || all[0].ID != brunch.ID {
+			t.Errorf("categories = %+v, want only Brunch", all)
+		}
+
+		// The move is a new version of each recipe, deleted ones included
+		got, err := stores.Recipes.GetRecipeByID(ctx, live.ID)
+		if err != nil {
+			t.Fatalf("GetRecipeByID: %v", err)
+		}
+		if got.CategoryID != int64(brunch.ID) || got.Version != live.Version+1 {
+			t.Errorf("recipe = %+v, want version %d in Brunch", got, live.Version+1)
+		}
+		revision, err := stores.Recipes.GetRevision(ctx, live.ID, got.Version)
+		if err != nil {
+			t.Fatalf("GetRevision: %v", err)
+		}
+		if revision.CategoryID != int64(brunch.ID) {
+			t.Errorf("revision = %+v, want the move", revision)
+		}
+		trash, err := stores.Recipes.ListDeletedRecipes(ctx, int64(user.ID))
+		if err != nil {
+			t.Fatalf("ListDeletedRecipes: %v", err)
+		}
+		if len(trash) != 1 || trash[0].CategoryID != int64(brunch.ID) {
+			t.Errorf("trash = %+v, want the deleted recipe in Brunch", trash)
+		}
+
+		// New recipes cannot use the deleted category, but its name is free
+		recipe := &models.Recipe{Title: "Chechebsa", CreatorID: int64(user.ID), CategoryID: int64(breakfast.ID)}
+		_, err = stores.Recipes.CreateRecipe(ctx, recipe)
+		expectError(t, err, store.ErrInvalidReference)
+		createCategory(t, stores, "Breakfast")
+
+		expectPurged(t, "categories", 0)(stores.Categories.PurgeCategories(ctx, time.Now().Add(-time.Hour)))
+		expectPurged(t, "categories", 1)(stores.Categories.PurgeCategories(ctx, time.Now().Add(time.Hour)))
+	})
+
+	t.Run("DeleteUncategorizesTrash", func(t *testing.T) {
+		stores := newStores(t)
+		user := createUser(t, stores, "abebe@example.com")
+		category := createCategory(t, stores, "Breakfast")
+		trashed := createRecipe(t, stores, user, category)
+		if err := stores.Recipes.DeleteRecipe(ctx, trashed.ID, trashed.CreatorID); err != nil {
+			t.Fatalf("DeleteRecipe: %v", err)
+		}
+
+		if _, err := stores.Categories.DeleteCategory(ctx, int64(category.ID), 0); err != nil {
+			t.Fatalf("DeleteCategory: %v", err)
+		}
+		if err := stores.Recipes.RestoreRecipe(ctx, trashed.ID, int64(user.ID), time.Time{}); err != nil {
+			t.Fatalf("RestoreRecipe: %v", err)
+		}
+		got, err := stores.Recipes.GetRecipeByID(ctx, trashed.ID)
+		if err != nil {
+			t.Fatalf("GetRecipeByID: %v", err)
+		}
+		if got.CategoryID != 0 {
+			t.Errorf("category = %d, want uncategorized", got.CategoryID)
+		}
+	})
+}
+
+func testRecipes(t *testing.T, newStores func(t *testing.T) Stores) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		stores := newStores(t)
+		user := createUser(t, stores, "abebe@example.com")
+		category := createCategory(t, stores, "Dinner")
+		recipe := createRecipe(t, stores, user, category)
+
+		got, err := stores.Recipes.GetRecipeByID(ctx, recipe.ID)
+		if err != nil {
+			t.Fatalf("GetRecipeByID: %v", err)
+		}
+		expectRecipe(t, got, recipe)
+	})
+
+	t.Run("Uncategorized", func(t *testing.T) {
+		stores := newStores(t)
+		user := createUser(t, stores, "abebe@example.com")
+		recipe := createRecipe(t, stores, user, nil)
+
+		got, err := stores.Recipes.GetRecipeByID(ctx, recipe.ID)
+		if err != nil {
+			t.Fatalf("GetRecipeByID: %v", err)
+		}
+		if got.CategoryID != 0 {
+			t.Errorf("category = %d, want 0", got.CategoryID)
+		}
+	})
+
+	t.Run("InvalidReferences", func(t *testing.T) {
+		stores := newStores(t)
+		user := createUser(t, stores, "abebe@example.com")
+
+		_, err := stores.Recipes.CreateRecipe(ctx, &models.Recipe{Title: "Shiro", CreatorID: 404})
+		expectError(t, err, store.ErrInvalidReference)
+		_, err = stores.Recipes.CreateRecipe(ctx, &models.Recipe{Title: "Shiro", CreatorID: int64(user.ID), CategoryID: 404})
+		expectError(t, err, store.ErrInvalidReference)
+
+		recipe := createRecipe(t, stores, user, nil)
+		recipe.CategoryID = 404
+		expectError(t, stores.Recipes.UpdateRecipe(ctx, recipe), store.ErrInvalidReference)
+	})
+
+	t.Run("DeletedAccounts", func(t *testing.T) {
+		stores := newStores(t)
+		owner := createUser(t, stores, "abebe@example.com")
+		editor := createUser(t, stores, "kebede@example.com")
+		friend := createUser(t, stores, "almaz@example.com")
+		recipe := createRecipe(t, stores, owner, nil)
+		invitation := &models.Invitation{RecipeID: recipe.ID, InviteeID: int64(editor.ID), InvitedBy: int64(owner.ID), Role: models.RoleOwner}
+		if err := stores.Recipes.InviteAuthor(ctx, invitation); err != nil {
+			t.Fatalf("InviteAuthor: %v", err)
+		}
+		if _, err := stores.Recipes.AcceptInvitation(ctx, invitation.ID); err != nil {
+			t.Fatalf("AcceptInvitation: %v", err)
+		}
+		pending := &models.Invitation{RecipeID: createRecipe(t, stores, owner, nil).ID, InviteeID: int64(editor.ID),
+			InvitedBy: int64(owner.ID), Role: models.RoleEditor}
+		if err := stores.Recipes.InviteAuthor(ctx, pending); err != nil {
+			t.Fatalf("InviteAuthor: %v", err)
+		}
+		shared := &models.RecipeShare{RecipeID: pending.RecipeID, UserID: int64(editor.ID), Permission: models.PermissionEdit}
+		if err := stores.Recipes.ShareRecipe(ctx, shared, int64(owner.ID)); err != nil {
+			t.Fatalf("ShareRecipe: %v", err)
+		}
+		if err := stores.Users.DeleteUser(ctx, int64(editor.ID)); err != nil {
+			t.Fatalf("DeleteUser: %v", err)
+		}
+
+		// Deleted co-authors and share recipients lose their access until
+		// the account is restored, and cannot accept invitations
+		_, err := stores.Recipes.GetAuthor(ctx, recipe.ID, int64(editor.ID))
+		expectError(t, err, store.ErrNotFound)
+		_, err = stores.Recipes.GetShare(ctx, shared.RecipeID, int64(editor.ID))
+		expectError(t, err, store.ErrNotFound)
+		if authorships, err := stores.Recipes.ListAuthorships(ctx, int64(editor.ID)); err != nil || len(authorships) != 0 {
+			t.Errorf("ListAuthorships = %+v, %v; want none", authorships, err)
+		}
+		_, err = stores.Recipes.AcceptInvitation(ctx, pending.ID)
+		expectError(t, err, store.ErrNotFound)
+
+		// Nothing is written on behalf of a deleted account
+		_, err = stores.Recipes.CreateRecipe(ctx, &models.Recipe{Title: "Kitfo", CreatorID: int64(editor.ID)})
+		expectError(t, err, store.ErrInvalidReference)
+		recipe.UpdatedBy = int64(editor.ID)
+		expectError(t, stores.Recipes.UpdateRecipe(ctx, recipe), store.ErrInvalidReference)
+		recipe.Status = models.StatusArchived
+		expectError(t, stores.Recipes.SetRecipeStatus(ctx, recipe), store.ErrInvalidReference)
+		recipe.Visibility = models.VisibilityPrivate
+		expectError(t, stores.Recipes.SetRecipeVisibility(ctx, recipe), store.ErrInvalidReference)
+		expectError(t, stores.Recipes.DeleteRecipe(ctx, recipe.ID, int64(editor.ID)), store.ErrInvalidReference)
+		share := &models.RecipeShare{RecipeID: recipe.ID, UserID: int64(friend.ID), Permission: models.PermissionEdit}
+		expectError(t, stores.Recipes.ShareRecipe(ctx, share, int64(editor.ID)), store.ErrInvalidReference)
+		invitation = &models.Invitation{RecipeID: recipe.ID, InviteeID: int64(friend.ID), InvitedBy: int64(editor.ID), Role: models.RoleOwner}
+		expectError(t, stores.Recipes.InviteAuthor(ctx, invitation), store.ErrInvalidReference)
+		if got, err := stores.Recipes.GetRecipeByID(ctx, recipe.ID); err != nil || got.Version != 1 {
+			t.Errorf("recipe = %+v, %v; want it unchanged", got, err)
+		}
+
+		// So the account is purged once its retention ends
+		purged, err := stores.Users.PurgeUsers(ctx, time.Now().Add(time.Hour))
+		if err != nil || purged != 1 {
+			t.Errorf("PurgeUsers = %d, %v; want 1", purged, err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		stores := newStores(t)
+		user := createUser(t, stores, "abebe@example.com")
+		recipe := createRecipe(t, stores, user, nil)
+
+		updated := *recipe
+		updated.Title = "Spicy Shiro"
+		updated.Steps = []string{"Toast the chickpea flour", "Add berbere"}
+		updated.CreatorID = 404 // the creator never changes
+		if err := stores.Recipes.UpdateRecipe(ctx, &updated); err != nil {
+			t.Fatalf("UpdateRecipe: %v", err)
+		}
+
+		got, err := stores.Recipes.GetRecipeByID(ctx, recipe.ID)
+		if err != nil {
+			t.Fatalf("GetRecipeByID: %v", err)
+		}
+		updated.CreatorID = recipe.CreatorID
+		expectRecipe(t, got, &updated)
+		if updated.Version != recipe.Version+1 || got.Version != updated.Version {
+			t.Errorf("versions after update = %d stored, %d returned, want %d", got.Version, updated.Version, recipe.Version+1)
+		}
+		if got.UpdatedAt.Before(recipe.UpdatedAt) {
+			t.Errorf("UpdatedAt went back from %s to %s", recipe.UpdatedAt, got.UpdatedAt)
+		}
+	})
+
+	t.Run("UpdateStale", func(t *testing.T) {
+		stores := newStores(t)
+		user := createUser(t, stores, "abebe@example.com")
+		recipe := createRecipe(t, stores, user, nil)
+
+		first, second := *recipe, *recipe
+		first.Title = "Spicy Shiro"
+		if err := stores.Recipes.UpdateRecipe(ctx, &first); err != nil {
+			t.Fatalf("UpdateRecipe: %v", err)
+		}
+		// The second writer read the recipe before the first update
+		second.Title = "Mild Shiro"
+		expectError(t, stores.Recipes.UpdateRecipe(ctx, &second), store.ErrStale)
+
+		got, err := stores.Recipes.GetRecipeByID(ctx, recipe.ID)
+		if err != nil {
+			t.Fatalf("GetRecipeByID: %v", err)
+		}
+		expectRecipe(t, got, &first)
+	})
+
+	t.Run("Revisions", func(t *testing.T) {
+		stores := newStores(t)
+		user := createUser(t, stores, "abebe@example.com")
+		recipe := createRecipe(t, stores, user, nil)
+
+		updated := *recipe
+		updated.Title = "Spicy Shiro"
+		updated.Images = []string{"uploads/shiro.jpg"}
+		updated.UpdatedBy = int64(user.ID)
+		if err := stores.Recipes.UpdateRecipe(ctx, &updated); err != nil {
+			t.Fatalf("UpdateRecipe: %v", err)
+		}
+
+		revisions, err := stores.Recipes.ListRevisions(ctx, recipe.ID)
+		if err != nil {
+			t.Fatalf("ListRevisions: %v", err)
+		}
+		if len(revisions) != 2 || revisions[0].Version != updated.Version || revisions[1].Version != recipe.Version {
+			t.Fatalf("revisions = %+v, want versions %d and %d, newest first", revisions, updated.Version, recipe.Version)
+		}
+		latest := revisions[0]
+		if latest.RecipeID != recipe.ID || latest.Title != "Spicy Shiro" || !sameStrings(latest.Images, updated.Images) ||
+			!sameStrings(latest.Steps, recipe.Steps) || latest.EditorID != int64(user.ID) || latest.CreatedAt.IsZero() {
+			t.Errorf("latest revision = %+v, want the update", latest)
+		}
+
+		first, err := stores.Recipes.GetRevision(ctx, recipe.ID, recipe.Version)
+		if err != nil {
+			t.Fatalf("GetRevision: %v", err)
+		}
+		if first.Title != "Shiro" || first.Description != recipe.Description || first.PrepTime != recipe.PrepTime ||
+			!sameStrings(first.Ingredients, recipe.Ingredients) || first.EditorID != recipe.CreatorID {
+			t.Errorf("first revision = %+v, want the created recipe", first)
+		}
+
+		_, err = stores.Recipes.GetRevision(ctx, recipe.ID, 404)
+		expectError(t, err, store.ErrNotFound)
+
+		// Revisions are deleted with their recipe
+		if err := stores.Recipes.DeleteRecipe(ctx, recipe.ID, recipe.CreatorID); err != nil {
+			t.Fatalf("DeleteRecipe: %v", err)
+		}
+		_, err = stores.Recipes.ListRevisions(ctx, recipe.ID)
+		expectError(t, err, store.ErrNotFound)
+	})
+
+	t.Run("Forks", func(t *testing.T) {
+		stores := newStores(t)
+		author := createUser(t, stores, "abebe@example.com")
+		forker := createUser(t, stores, "kebede@example.com")
+		track(t, stores, "uploads/shiro.jpg")
+		parent := &models.Recipe{Title: "Shiro", CreatorID: int64(author.ID), Images: []string{"uploads/shiro.jpg"}, Status: models.StatusPublished}
+		if _, err := stores.Recipes.CreateRecipe(ctx, parent); err != nil {
+			t.Fatalf("CreateRecipe: %v", err)
+		}
+
+		origin := models.ForkOrigin{RecipeID: parent.ID, Version: parent.Version, CreatorID: parent.CreatorID}
+		fork := &models.Recipe{Title: "Shiro", CreatorID: int64(forker.ID), Images: parent.Images, ForkedFrom: &origin, Status: models.StatusPublished}
+		if _, err := stores.Recipes.CreateRecipe(ctx, fork); err != nil {
+			t.Fatalf("CreateRecipe: %v", err)
+		}
+		if got, _ := stores.Recipes.GetRecipeByID(ctx, parent.ID); got.ForkedFrom != nil {
+			t.Errorf("parent forked from %+v, want nil", got.ForkedFrom)
+		}
+
+		// Updates keep the origin
+		fork.Title = "Spicy Shiro"
+		fork.ForkedFrom = nil
+		if err := stores.Recipes.UpdateRecipe(ctx, fork); err != nil {
+			t.Fatalf("UpdateRecipe: %v", err)
+		}
+		got, err := stores.Recipes.GetRecipeByID(ctx, fork.ID)
+		if err != nil {
+			t.Fatalf("GetRecipeByID: %v", err)
+		}
+		if got.ForkedFrom == nil || *got.ForkedFrom != origin {
+			t.Errorf("forked from %+v, want %+v", got.ForkedFrom, origin)
+		}
+
+		// The fork, its attribution and the images it shares outlive the
+		// parent and its author
+		if err := stores.Users.DeleteUser(ctx, int64(author.ID)); err != nil {
+			t.Fatalf("DeleteUser: %v", err)
+		}
+		if _, err := stores.Recipes.PurgeRecipes(ctx, time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("PurgeRecipes: %v", err)
+		}
+		if _, err := stores.Users.PurgeUsers(ctx, time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("PurgeUsers: %v", err)
+		}
+		forks, err := stores.Recipes.ListForks(ctx, parent.ID, 0)
+		if err != nil {
+			t.Fatalf("ListForks: %v", err)
+		}
+		if len(forks) != 1 || forks[0].ID != fork.ID || forks[0].ForkedFrom == nil ||
+			forks[0].ForkedFrom.RecipeID != parent.ID || forks[0].ForkedFrom.CreatorID != 0 {
+			t.Errorf("forks = %+v, want the fork without its original author", forks)
+		}
+		expectOrphans(t, stores, time.Now().Add(time.Hour))
+
+		if forks, _ := stores.Recipes.ListForks(ctx, fork.ID, 0); len(forks) != 0 {
+			t.Errorf("forks of the fork = %+v, want none", forks)
+		}
+	})
+
+	t.Run("TrashRestoreAndPurge", func(t *testing.T) {
+		stores := newStores(t)
+		past := time.Now().Add(-time.Hour)
+		future := time.Now().Add(time.Hour)
+		user := createUser(t, stores, "abebe@example.com")
+		other := createUser(t, stores, "kebede@example.com")
+		recipe := createRecipe(t, stores, user, nil)
+
+		if err := stores.Recipes.DeleteRecipe(ctx, recipe.ID, recipe.CreatorID); err != nil {
+			t.Fatalf("DeleteRecipe: %v", err)
+		}
+		expectError(t, stores.Recipes.DeleteRecipe(ctx, recipe.ID, recipe.CreatorID), store.ErrNotFound)
+		expectError(t, stores.Recipes.UpdateRecipe(ctx, recipe), store.ErrNotFound)
+		_, err := stores.Recipes.ListRevisions(ctx, recipe.ID)
+		expectError(t, err, store.ErrNotFound)
+		if all, _ := stores.Recipes.GetAllRecipes(ctx, 0); len(all) != 0 {
+			t.Errorf("recipes = %+v, want none", all)
+		}
+
+		trash, err := stores.Recipes.ListDeletedRecipes(ctx, int64(user.ID))
+		if err != nil {
+			t.Fatalf("ListDeletedRecipes: %v", err)
+		}
+		if len(trash) != 1 || trash[0].ID != recipe.ID || trash[0].DeletedAt == nil {
+			t.Fatalf("trash = %+v, want the deleted recipe", trash)
+		}
+		if trash, _ := stores.Recipes.ListDeletedRecipes(ctx, int64(other.ID)); len(trash) != 0 {
+			t.Errorf("other user's trash = %+v, want none", trash)
+		}
+
+		// Only the creator restores, and only within the window
+		expectError(t, stores.Recipes.RestoreRecipe(ctx, recipe.ID, int64(other.ID), past), store.ErrNotFound)
+		expectError(t, stores.Recipes.RestoreRecipe(ctx, recipe.ID, int64(user.ID), future), store.ErrNotFound)
+		if err := stores.Recipes.RestoreRecipe(ctx, recipe.ID, int64(user.ID), past); err != nil {
+			t.Fatalf("RestoreRecipe: %v", err)
+		}
+		got, err := stores.Recipes.GetRecipeByID(ctx, recipe.ID)
+		if err != nil {
+			t.Fatalf("GetRecipeByID: %v", err)
+		}
+		if got.DeletedAt != nil || got.Version != recipe.Version {
+			t.Errorf("restored recipe = %+v, want version %d", got, recipe.Version)
+		}
+		expectError(t, stores.Recipes.RestoreRecipe(ctx, recipe.ID, int64(user.ID), past), store.ErrNotFound)
+
+		if err := stores.Recipes.DeleteRecipe(ctx, recipe.ID, recipe.CreatorID); err != nil {
+			t.Fatalf("DeleteRecipe: %v", err)
+		}
+		expectPurged(t, "recipes", 0)(stores.Recipes.PurgeRecipes(ctx, past))
+		expectPurged(t, "recipes", 1)(stores.Recipes.PurgeRecipes(ctx, future))
+		if trash, _ := stores.Recipes.ListDeletedRecipes(ctx, int64(user.ID)); len(trash) != 0 {
+			t.Errorf("trash after purge = %+v, want none", trash)
+		}
+		expectError(t, stores.Recipes.RestoreRecipe(ctx, recipe.ID, int64(user.ID), past), store.ErrNotFound)
+	})
+
+	t.Run("Lifecycle", func(t *testing.T) {
+		stores := newStores(t)
+		author := createUser(t, stores, "abebe@example.com")
+		reader := createUser(t, stores, "kebede@example.com")
+		published := createRecipe(t, stores, author, nil)
+		if published.Status != models.StatusPublished || published.PublishedAt == nil {
+			t.Errorf("published recipe = %+v", published)
+		}
+		draft, err := stores.Recipes.CreateRecipe(ctx, &models.Recipe{Title: "Draft", CreatorID: int64(author.ID)})
+		if err != nil {
+			t.Fatalf("CreateRecipe: %v", err)
+		}
+		if draft.Status != models.StatusDraft || draft.PublishedAt != nil {
+			t.Errorf("recipe created without a status = %+v, want an unpublished draft", draft)
+		}
+
+		// Drafts are only listed for their creator
+		expectListed := func(viewerID int64, want ...int64) {
+			t.Helper()
+			recipes, err := stores.Recipes.GetAllRecipes(ctx, viewerID)
+			if err != nil {
+				t.Fatalf("GetAllRecipes: %v", err)
+			}
+			var got []int64
+			for _, recipe := range recipes {
+				got = append(got, recipe.ID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("recipes listed for %d = %v, want %v", viewerID, got, want)
+			}
+		}
+		expectListed(0, published.ID)
+		expectListed(int64(reader.ID), published.ID)
+		expectListed(int64(author.ID), published.ID, draft.ID)
+
+		// Scheduling is a change of its own; content updates keep it
+		publishAt := time.Now().Add(-time.Minute)
+		draft.PublishAt = &publishAt
+		if err := stores.Recipes.SetRecipeStatus(ctx, draft); err != nil {
+			t.Fatalf("SetRecipeStatus: %v", err)
+		}
+		if draft.Version != 2 {
+			t.Errorf("version after scheduling = %d, want 2", draft.Version)
+		}
+		stale := *draft
+		stale.Version = 1
+		expectError(t, stores.Recipes.SetRecipeStatus(ctx, &stale), store.ErrStale)
+		draft.Title = "Edited draft"
+		if err := stores.Recipes.UpdateRecipe(ctx, draft); err != nil {
+			t.Fatalf("UpdateRecipe: %v", err)
+		}
+		got, err := stores.Recipes.GetRecipeByID(ctx, draft.ID)
+		if err != nil {
+			t.Fatalf("GetRecipeByID: %v", err)
+		}
+		if got.Status != models.StatusDraft || got.PublishAt == nil || !got.PublishAt.Equal(publishAt) {
+			t.Errorf("scheduled draft after update = %+v", got)
+		}
+
+		due, err := stores.Recipes.PublishDueRecipes(ctx, publishAt.Add(-time.Second))
+		if err != nil || len(due) != 0 {
+			t.Errorf("published before the schedule = %v, %v", due, err)
+		}
+		due, err = stores.Recipes.PublishDueRecipes(ctx, time.Now())
+		if err != nil || len(due) != 1 || due[0] != draft.ID {
+			t.Fatalf("published = %v, %v; want the draft", due, err)
+		}
+		got, err = stores.Recipes.GetRecipeByID(ctx, draft.ID)
+		if err != nil {
+			t.Fatalf("GetRecipeByID: %v", err)
+		}
+		if got.Status != models.StatusPublished || got.PublishAt != nil || got.PublishedAt == nil || got.Version != 4 {
+			t.Errorf("recipe after its publication = %+v", got)
+		}
+		if revisions, _ := stores.Recipes.ListRevisions(ctx, draft.ID); len(revisions) != 4 {
+			t.Errorf("revisions = %d, want one per version", len(revisions))
+		}
+		expectListed(int64(reader.ID), published.ID, draft.ID)
+
+		// Archived recipes drop out of other users' lists
+		got.Status = models.StatusArchived
+		if err := stores.Recipes.SetRecipeStatus(ctx, got); err != nil {
+			t.Fatalf("SetRecipeStatus: %v", err)
+		}
+		if got.PublishedAt == nil {
+			t.Error("archiving cleared the publication time")
+		}
+		expectListed(0, published.ID)
+		expectListed(int64(author.ID), published.ID, draft.ID)
+		expectError(t, stores.Recipes.SetRecipeStatus(ctx, &models.Recipe{ID: 404, Version: 1, Status: models.StatusDraft}), store.ErrNotFound)
+	})
+
+	t.Run("Sharing", func(t *testing.T) {
+		stores := newStores(t)
+		author := createUser(t, stores, "abebe@example.com")
+		friend := createUser(t, stores, "kebede@example.com")
+		stranger := createUser(t, stores, "almaz@example.com")
+		recipe := createRecipe(t, stores, author, nil)
+		if recipe.Visibility != models.VisibilityPublic {
+			t.Errorf("recipe created without a visibility = %q, want public", recipe.Visibility)
+		}
+		expectListed := func(viewerID int64, want int) {
+			t.Helper()
+			recipes, err := stores.Recipes.GetAllRecipes(ctx, viewerID)
+			if err != nil {
+				t.Fatalf("GetAllRecipes: %v", err)
+			}
+			if len(recipes) != want {
+				t.Errorf("recipes listed for %d = %d, want %d", viewerID, len(recipes), want)
+			}
+		}
+
+		// Unlisted recipes are reachable by their token only
+		recipe.Visibility = models.VisibilityUnlisted
+		recipe.ShareToken = "secret-token"
+		if err := stores.Recipes.SetRecipeVisibility(ctx, recipe); err != nil {
+			t.Fatalf("SetRecipeVisibility: %v", err)
+		}
+		if recipe.Version != 2 {
+			t.Errorf("version after changing visibility = %d, want 2", recipe.Version)
+		}
+		stale := *recipe
+		stale.Version = 1
+		expectError(t, stores.Recipes.SetRecipeVisibility(ctx, &stale), store.ErrStale)
+		shared, err := stores.Recipes.GetRecipeByShareToken(ctx, "secret-token")
+		if err != nil {
+			t.Fatalf("GetRecipeByShareToken: %v", err)
+		}
+		if shared.ID != recipe.ID || shared.ShareToken != "secret-token" {
+			t.Errorf("shared recipe = %+v", shared)
+		}
+		_, err = stores.Recipes.GetRecipeByShareToken(ctx, "guess")
+		expectError(t, err, store.ErrNotFound)
+		other := createRecipe(t, stores, friend, nil)
+		other.Visibility = models.VisibilityUnlisted
+		other.ShareToken = "secret-token"
+		expectError(t, stores.Recipes.SetRecipeVisibility(ctx, other), store.ErrConflict)
+		expectListed(int64(stranger.ID), 1)
+		expectListed(int64(author.ID), 2)
+
+		// Private recipes are listed for the users they are shared with
+		recipe.Visibility = models.VisibilityPrivate
+		recipe.ShareToken = ""
+		if err := stores.Recipes.SetRecipeVisibility(ctx, recipe); err != nil {
+			t.Fatalf("SetRecipeVisibility: %v", err)
+		}
+		_, err = stores.Recipes.GetRecipeByShareToken(ctx, "secret-token")
+		expectError(t, err, store.ErrNotFound)
+		share := &models.RecipeShare{RecipeID: recipe.ID, UserID: int64(friend.ID), Permission: models.PermissionView}
+		if err := stores.Recipes.ShareRecipe(ctx, share, recipe.CreatorID); err != nil {
+			t.Fatalf("ShareRecipe: %v", err)
+		}
+		if share.CreatedAt.IsZero() {
+			t.Error("ShareRecipe did not set the creation time")
+		}
+		share.Permission = models.PermissionEdit
+		if err := stores.Recipes.ShareRecipe(ctx, share, recipe.CreatorID); err != nil {
+			t.Fatalf("ShareRecipe again: %v", err)
+		}
+		got, err := stores.Recipes.GetShare(ctx, recipe.ID, int64(friend.ID))
+		if err != nil || got.Permission != models.PermissionEdit {
+			t.Errorf("GetShare = %+v, %v; want edit permission", got, err)
+		}
+		shares, err := stores.Recipes.ListShares(ctx, recipe.ID)
+		if err != nil || len(shares) != 1 || shares[0].UserID != int64(friend.ID) {
+			t.Errorf("ListShares = %+v, %v", shares, err)
+		}
+		expectListed(int64(friend.ID), 2)
+		expectListed(int64(stranger.ID), 1)
+		expectListed(0, 1)
+
+		expectError(t, stores.Recipes.ShareRecipe(ctx, &models.RecipeShare{RecipeID: recipe.ID, UserID: 404, Permission: models.PermissionView}, recipe.CreatorID),
+			store.ErrInvalidReference)
+		expectError(t, stores.Recipes.ShareRecipe(ctx, &models.RecipeShare{RecipeID: 404, UserID: int64(friend.ID), Permission: models.PermissionView}, recipe.CreatorID),
+			store.ErrNotFound)
+
+		if err := stores.Recipes.UnshareRecipe(ctx, recipe.ID, int64(friend.ID)); err != nil {
+			t.Fatalf("UnshareRecipe: %v", err)
+		}
+		expectError(t, stores.Recipes.UnshareRecipe(ctx, recipe.ID, int64(friend.ID)), store.ErrNotFound)
+		_, err = stores.Recipes.GetShare(ctx, recipe.ID, int64(friend.ID))
+		expectError(t, err, store.ErrNotFound)
+		expectListed(int64(friend.ID), 1)
+	})
+
+	t.Run("Authors", func(t *testing.T) {
+		stores := newStores(t)
+		owner := createUser(t, stores, "abebe@example.com")
+		editor := createUser(t, stores, "kebede@example.com")
+		recipe, err := stores.Recipes.CreateRecipe(ctx, &models.Recipe{Title: "Draft", CreatorID: int64(owner.ID), Status: models.StatusDraft})
+		if err != nil {
+			t.Fatalf("CreateRecipe: %v", err)
+		}
+
+		invitation := &models.Invitation{RecipeID: recipe.ID, InviteeID: int64(editor.ID), InvitedBy: int64(owner.ID), Role: models.RoleEditor}
+		if err := stores.Recipes.InviteAuthor(ctx, invitation); err != nil {
+			t.Fatalf("InviteAuthor: %v", err)
+		}
+		if invitation.ID == 0 || invitation.CreatedAt.IsZero() {
+			t.Errorf("invitation = %+v, want an ID and creation time", invitation)
+		}
+		again := *invitation
+		expectError(t, stores.Recipes.InviteAuthor(ctx, &again), store.ErrConflict)
+		expectError(t, stores.Recipes.InviteAuthor(ctx, &models.Invitation{RecipeID: recipe.ID, InviteeID: 404, InvitedBy: int64(owner.ID),
+			Role: models.RoleEditor}), store.ErrInvalidReference)
+		expectError(t, stores.Recipes.InviteAuthor(ctx, &models.Invitation{RecipeID: 404, InviteeID: int64(editor.ID), InvitedBy: int64(owner.ID),
+			Role: models.RoleEditor}), store.ErrNotFound)
+
+		pending, err := stores.Recipes.ListInvitations(ctx, int64(editor.ID))
+		if err != nil || len(pending) != 1 || pending[0].ID != invitation.ID || pending[0].Role != models.RoleEditor {
+			t.Fatalf("ListInvitations = %+v, %v", pending, err)
+		}
+		got, err := stores.Recipes.GetInvitation(ctx, invitation.ID)
+		if err != nil || got.InvitedBy != int64(owner.ID) {
+			t.Errorf("GetInvitation = %+v, %v", got, err)
+		}
+
+		// Accepting makes the invitee an author, who lists the draft
+		author, err := stores.Recipes.AcceptInvitation(ctx, invitation.ID)
+		if err != nil {
+			t.Fatalf("AcceptInvitation: %v", err)
+		}
+		if author.UserID != int64(editor.ID) || author.Role != models.RoleEditor {
+			t.Errorf("author = %+v", author)
+		}
+		_, err = stores.Recipes.AcceptInvitation(ctx, invitation.ID)
+		expectError(t, err, store.ErrNotFound)
+		if pending, _ := stores.Recipes.ListInvitations(ctx, int64(editor.ID)); len(pending) != 0 {
+			t.Errorf("invitations after accepting = %+v, want none", pending)
+		}
+		if got, err := stores.Recipes.GetAuthor(ctx, recipe.ID, int64(editor.ID)); err != nil || got.Role != models.RoleEditor {
+			t.Errorf("GetAuthor = %+v, %v", got, err)
+		}
+		authors, err := stores.Recipes.ListAuthors(ctx, recipe.ID)
+		if err != nil || len(authors) != 1 || authors[0].UserID != int64(editor.ID) {
+			t.Errorf("ListAuthors = %+v, %v", authors, err)
+		}
+		authorships, err := stores.Recipes.ListAuthorships(ctx, int64(editor.ID))
+		if err != nil || len(authorships) != 1 || authorships[0].RecipeID != recipe.ID || authorships[0].Role != models.RoleEditor {
+			t.Errorf("ListAuthorships = %+v, %v", authorships, err)
+		}
+		if recipes, _ := stores.Recipes.GetAllRecipes(ctx, int64(editor.ID)); len(recipes) != 1 {
+			t.Errorf("recipes listed for the co-author = %d, want the draft", len(recipes))
+		}
+		again.ID = 0
+		expectError(t, stores.Recipes.InviteAuthor(ctx, &again), store.ErrConflict)
+
+		// Declined invitations are deleted
+		third := createUser(t, stores, "almaz@example.com")
+		declined := &models.Invitation{RecipeID: recipe.ID, InviteeID: int64(third.ID), InvitedBy: int64(owner.ID), Role: models.RoleOwner}
+		if err := stores.Recipes.InviteAuthor(ctx, declined); err != nil {
+			t.Fatalf("InviteAuthor: %v", err)
+		}
+		if err := stores.Recipes.DeleteInvitation(ctx, declined.ID); err != nil {
+			t.Fatalf("DeleteInvitation: %v", err)
+		}
+		expectError(t, stores.Recipes.DeleteInvitation(ctx, declined.ID), store.ErrNotFound)
+		_, err = stores.Recipes.GetInvitation(ctx, declined.ID)
+		expectError(t, err, store.ErrNotFound)
+
+		if err := stores.Recipes.RemoveAuthor(ctx, recipe.ID, int64(editor.ID)); err != nil {
+			t.Fatalf("RemoveAuthor: %v", err)
+		}
+		expectError(t, stores.Recipes.RemoveAuthor(ctx, recipe.ID, int64(editor.ID)), store.ErrNotFound)
+		_, err = stores.Recipes.GetAuthor(ctx, recipe.ID, int64(editor.ID))
+		expectError(t, err, store.ErrNotFound)
+		if recipes, _ := stores.Recipes.GetAllRecipes(ctx, int64(editor.ID)); len(recipes) != 0 {
+			t.Errorf("recipes listed for a former co-author = %d, want none", len(recipes))
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		stores := newStores(t)
+		_, err := stores.Recipes.GetRecipeByID(ctx, 404)
+		expectError(t, err, store.ErrNotFound)
+		expectError(t, stores.Recipes.UpdateRecipe(ctx, &models.Recipe{ID: 404, Title: "x"}), store.ErrNotFound)
+		expectError(t, stores.Recipes.DeleteRecipe(ctx, 404, 0), store.ErrNotFound)
+	})
+
+	t.Run("ListAndDelete", func(t *testing.T) {
+		stores := newStores(t)
+		user := createUser(t, stores, "abebe@example.com")
+		first := createRecipe(t, stores, user, nil)
+		second := createRecipe(t, stores, user, nil)
+
+		if err := stores.Recipes.DeleteRecipe(ctx, first.ID, first.CreatorID); err != nil {
+			t.Fatalf("DeleteRecipe: %v", err)
+		}
+		got, err := stores.Recipes.GetAllRecipes(ctx, 0)
+		if err != nil {
+			t.Fatalf("GetAllRecipes: %v", err)
+		}
+		if len(got) != 1 || got[0].ID != second.ID {
+			t.Errorf("recipes = %+v, want only recipe %d", got, second.ID)
+		}
+	})
+}
+
+func testMedia(t *testing.T, newStores func(t *testing.T) Stores) {
+	ctx := context.Background()
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	t.Run("UnattachedUpload", func(t *testing.T) {
+		stores := newStores(t)
+		track(t, stores, "uploads/a.jpg")
+
+		expectOrphans(t, stores, past)
+		expectOrphans(t, stores, future, "uploads/a.jpg")
+
+		if err := stores.Media.ForgetMedia(ctx, "uploads/a.jpg"); err != nil {
+			t.Fatalf("ForgetMedia: %v", err)
+		}
+		expectOrphans(t, stores, future)
+	})
+
+	t.Run("KeptUntilPurged", func(t *testing.T) {
+		stores := newStores(t)
+		user := createUser(t, stores, "abebe@example.com")
+		track(t, stores, "uploads/a.jpg")
+		track(t, stores, "uploads/b.jpg")
+
+		recipe := &models.Recipe{Title: "Kitfo", CreatorID: int64(user.ID), Images: []string{"uploads/a.jpg", "uploads/b.jpg"}}
+		if _, err := stores.Recipes.CreateRecipe(ctx, recipe); err != nil {
+			t.Fatalf("CreateRecipe: %v", err)
+		}
+		expectOrphans(t, stores, future)
+
+		// The first revision still references the dropped image
+		recipe.Images = []string{"uploads/b.jpg"}
+		if err := stores.Recipes.UpdateRecipe(ctx, recipe); err != nil {
+			t.Fatalf("UpdateRecipe: %v", err)
+		}
+		expectOrphans(t, stores, future)
+
+		// A deleted recipe may be restored until it is purged
+		if err := stores.Recipes.DeleteRecipe(ctx, recipe.ID, recipe.CreatorID); err != nil {
+			t.Fatalf("DeleteRecipe: %v", err)
+		}
+		expectOrphans(t, stores, future)
+
+		if _, err := stores.Recipes.PurgeRecipes(ctx, future); err != nil {
+			t.Fatalf("PurgeRecipes: %v", err)
+		}
+		expectOrphans(t, stores, future, "uploads/a.jpg", "uploads/b.jpg")
+	})
+}
+
+func createUser(t *testing.T, stores Stores, email string) *models.User {
+	t.Helper()
+	user := &models.User{Username: "abebe", Email: email, PasswordHash: "hash"}
+	if err := stores.Users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return user
+}
+
+func createCategory(t *testing.T, stores Stores, name string) *models.Category {
+	t.Helper()
+	category := &models.Category{Name: name}
+	if err := stores.Categories.CreateCategory(context.Background(), category); err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	return category
+}
+
+// createRecipe stores a recipe by user in category, which may be nil
+func createRecipe(t *testing.T, stores Stores, user *models.User, category *models.Category) *models.Recipe {
+	t.Helper()
+	recipe := &models.Recipe{
+		Title:       "Shiro",
+		Description: "Chickpea stew",
+		Ingredients: []string{"chickpea flour", "onion"},
+		Steps:       []string{"Simmer"},
+		PrepTime:    30,
+		CreatorID:   int64(user.ID),
+		Status:      models.StatusPublished,
+	}
+	if category != nil {
+		recipe.CategoryID = int64(category.ID)
+	}
+	if _, err := stores.Recipes.CreateRecipe(context.Background(), recipe); err != nil {
+		t.Fatalf("CreateRecipe: %v", err)
+	}
+	if recipe.ID == 0 {
+		t.Fatal("CreateRecipe did not assign an ID")
+	}
+	return recipe
+}
+
+func track(t *testing.T, stores Stores, path string) {
+	t.Helper()
+	if err := stores.Media.TrackMedia(context.Background(), path); err != nil {
+		t.Fatalf("TrackMedia: %v", err)
+	}
+}
+
+func expectOrphans(t *testing.T, stores Stores, before time.Time, want ...string) {
+	t.Helper()
+	got, err := stores.Media.OrphanedMedia(context.Background(), before)
+	if err != nil {
+		t.Fatalf("OrphanedMedia: %v", err)
+	}
+	if !sameStrings(got, want) {
+		t.Errorf("orphaned media before %s = %v, want %v", before.Format(time.TimeOnly), got, want)
+	}
+}
+
+// expectPurged returns a check of the results of a purge, to be called with
+// them directly
+func expectPurged(t *testing.T, what string, want int64) func(int64, error) {
+	t.Helper()
+	return func(purged int64, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("purging %s: %v", what, err)
+		}
+		if purged != want {
+			t.Errorf("purged %d %s, want %d", purged, what, want)
+		}
+	}
+}
+
+func expectRecipe(t *testing.T, got, want *models.Recipe) {
+	t.Helper()
+	if got.ID != want.ID || got.Title != want.Title || got.Description != want.Description ||
+		got.PrepTime != want.PrepTime || got.CategoryID != want.CategoryID || got.CreatorID != want.CreatorID ||
+		!sameStrings(got.Ingredients, want.Ingredients) || !sameStrings(got.Steps, want.Steps) || !sameStrings(got.Images, want.Images) {
+		t.Errorf("recipe = %+v, want %+v", got, want)
+	}
+}
+
+func expectError(t *testing.T, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Errorf("error = %v, want %v", err, want)
+	}
+}
+
+// sameStrings compares string lists, treating nil and empty as equal and
+// ignoring order
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int)
+	for _, s := range a {
+		counts[s]++
+	}
+	for _, s := range b {
+		counts[s]--
+		if counts[s] < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,290 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend-app/models"
+)
+
+type PostgresUserStore struct {
+	DB      *sql.DB
+	Timeout time.Duration // Per-query timeout, DefaultQueryTimeout if zero
+}
+
+// NewPostgresUserStore initializes a new PostgresUserStore
+func NewPostgresUserStore(db *sql.DB) *PostgresUserStore {
+	return &PostgresUserStore{
+		DB:      db,
+		Timeout: DefaultQueryTimeout,
+	}
+}
+
+// CreateUser creates a new user in the database
+func (ur *PostgresUserStore) CreateUser(ctx context.Context, user *models.User) error {
+	ctx, cancel := withTimeout(ctx, ur.Timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO users (username, email, password_hash)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	err := ur.DB.QueryRowContext(
+		ctx,
+		query,
+		user.Username,
+		user.Email,
+		user.PasswordHash,
+	).Scan(&user.ID)
+	return translateError("creating user", err)
+}
+
+// UpdateUser updates an existing user in the database
+func (ur *PostgresUserStore) UpdateUser(ctx context.Context, user *models.User) error {
+	ctx, cancel := withTimeout(ctx, ur.Timeout)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET username = $1, email = $2, password_hash = $3
+		WHERE id = $4 AND deleted_at IS NULL
+	`
+	result, err := ur.DB.ExecContext(
+		ctx,
+		query,
+		user.Username,
+		user.Email,
+		user.PasswordHash,
+		user.ID,
+	)
+	if err != nil {
+		return translateError("updating user", err)
+	}
+	return translateError("updating user", requireAffected(result))
+}
+
+// DeleteUser deletes a user and the recipes they created. Both are kept
+// until they are restored or purged.
+func (ur *PostgresUserStore) DeleteUser(ctx context.Context, userID int64) error {
+	ctx, cancel := withTimeout(ctx, ur.Timeout)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET deleted_at = current_timestamp
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	tx, err := ur.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError("starting transaction", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, userID)
+	if err != nil {
+		return translateError("deleting user", err)
+	}
+	if err := requireAffected(result); err != nil {
+		return translateError("deleting user", err)
+	}
+
+	// The recipes share the account's deletion time, which is how
+	// RestoreUser tells them from recipes that were already in the trash
+	recipes := `
+		UPDATE recipes
+		SET deleted_at = current_timestamp
+		WHERE creator_id = $1 AND deleted_at IS NULL
+	`
+	if _, err := tx.ExecContext(ctx, recipes, userID); err != nil {
+		return translateError("deleting user recipes", err)
+	}
+	return translateError("deleting user", tx.Commit())
+}
+
+// RestoreUser restores a user deleted at or after deletedAfter with the
+// recipes that were deleted with them
+func (ur *PostgresUserStore) RestoreUser(ctx context.Context, userID int64, deletedAfter time.Time) error {
+	ctx, cancel := withTimeout(ctx, ur.Timeout)
+	defer cancel()
+
+	query := `
+		WITH deleted AS (
+			SELECT deleted_at FROM users
+			WHERE id = $1 AND deleted_at >= $2
+			FOR UPDATE
+		)
+		UPDATE users
+		SET deleted_at = NULL
+		FROM deleted
+		WHERE users.id = $1
+		RETURNING deleted.deleted_at
+	`
+	tx, err := ur.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError("starting transaction", err)
+	}
+	defer tx.Rollback()
+
+	var deletedAt time.Time
+	if err := tx.QueryRowContext(ctx, query, userID, deletedAfter).Scan(&deletedAt); err != nil {
+		return translateError("restoring user", err)
+	}
+
+	recipes := `
+		UPDATE recipes
+		SET deleted_at = NULL
+		WHERE creator_id = $1 AND deleted_at = $2
+	`
+	if _, err := tx.ExecContext(ctx, recipes, userID, deletedAt); err != nil {
+		return translateError("restoring user recipes", err)
+	}
+	return translateError("restoring user", tx.Commit())
+}
+
+// PurgeUsers permanently deletes users deleted before the given time. Users
+// whose recipes have not been purged yet are left for a later purge.
+func (ur *PostgresUserStore) PurgeUsers(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx, ur.Timeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM users
+		WHERE deleted_at < $1
+		AND NOT EXISTS (SELECT 1 FROM recipes WHERE creator_id = users.id)
+	`
+	result, err := ur.DB.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, translateError("purging users", err)
+	}
+	purged, err := result.RowsAffected()
+	return purged, translateError("purging users", err)
+}
+
+// GetUserByID retrieves a user from the database by ID
+func (ur *PostgresUserStore) GetUserByID(ctx context.Context, userID int64) (*models.User, error) {
+	ctx, cancel := withTimeout(ctx, ur.Timeout)
+	defer cancel()
+
+	var user models.User
+	query := `
+		SELECT id, username, email, password_hash
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	err := ur.DB.QueryRowContext(ctx, query, userID).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.PasswordHash,
+	)
+	if err != nil {
+		return nil, translateError("retrieving user", err)
+	}
+	return &user, nil
+}
+
+// GetUserByEmail retrieves a user from the database by email
+func (ur *PostgresUserStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := withTimeout(ctx, ur.Timeout)
+	defer cancel()
+
+	var user models.User
+	query := `
+		SELECT id, username, email, password_hash
+		FROM users
+		WHERE email = $1 AND deleted_at IS NULL
+	`
+	err := ur.DB.QueryRowContext(ctx, query, email).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.PasswordHash,
+	)
+	if err != nil {
+		return nil, translateError("retrieving user by email", err)
+	}
+	return &user, nil
+}
+
+// GetUserByUsername retrieves the user with the username, failing with
+// ErrConflict if several users share it
+func (ur *PostgresUserStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, cancel := withTimeout(ctx, ur.Timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, username, email, password_hash
+		FROM users
+		WHERE username = $1 AND deleted_at IS NULL
+		LIMIT 2
+	`
+	rows, err := ur.DB.QueryContext(ctx, query, username)
+	if err != nil {
+		return nil, translateError("retrieving user by username", err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash); err != nil {
+			return nil, translateError("scanning user row", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError("iterating over user rows", err)
+	}
+
+	switch len(users) {
+	case 0:
+		return nil, fmt.Errorf("retrieving user by username: %w", ErrNotFound)
+	case 1:
+		return &users[0], nil
+	default:
+		return nil, fmt.Errorf("retrieving user by username: %w", ErrConflict)
+	}
+}
+
+// GetDeletedUserByEmail retrieves the most recently deleted user with the
+// email that was deleted at or after deletedAfter
+func (ur *PostgresUserStore) GetDeletedUserByEmail(ctx context.Context, email string, deletedAfter time.Time) (*models.User, error) {
+	ctx, cancel := withTimeout(ctx, ur.Timeout)
+	defer cancel()
+
+	var user models.User
+	query := `
+		SELECT id, username, email, password_hash
+		FROM users
+		WHERE email = $1 AND deleted_at >= $2
+		ORDER BY deleted_at DESC
+		LIMIT 1
+	`
+	err := ur.DB.QueryRowContext(ctx, query, email, deletedAfter).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.PasswordHash,
+	)
+	if err != nil {
+		return nil, translateError("retrieving deleted user by email", err)
+	}
+	return &user, nil
+}
+
+// lockUser checks that a live account exists and keeps it from being
+// deleted until tx ends, so that nothing is written on behalf of a deleted
+// account. It returns ErrInvalidReference if there is none.
+func lockUser(ctx context.Context, tx *sql.Tx, userID int64) error {
+	var id int64
+	query := `SELECT id FROM users WHERE id = $1 AND deleted_at IS NULL FOR SHARE`
+	err := tx.QueryRowContext(ctx, query, userID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInvalidReference
+	}
+	return err
+}
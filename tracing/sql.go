@@ -0,0 +1,191 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"unicode"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "backend-app/tracing"
+
+// WrapConnector returns a connector whose connections record a client span
+// for every query and statement they run. Spans carry the sanitized SQL but
+// never the arguments. Open the pool with sql.OpenDB.
+func WrapConnector(c driver.Connector) driver.Connector {
+	return &tracedConnector{Connector: c}
+}
+
+type tracedConnector struct {
+	driver.Connector
+}
+
+func (c *tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: conn}, nil
+}
+
+// tracedConn passes everything through to the driver's connection, timing
+// queries and statements. Where the driver lacks an optional interface it
+// answers driver.ErrSkip so database/sql falls back as it would without
+// the wrapper.
+type tracedConn struct {
+	driver.Conn
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := startQuery(ctx, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	endQuery(span, err)
+	return rows, err
+}
+
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := startQuery(ctx, query)
+	result, err := execer.ExecContext(ctx, query, args)
+	endQuery(span, err)
+	return result, err
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *tracedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// startQuery starts a span for query named after its operation, e.g.
+// "SELECT"
+func startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	statement := SanitizeSQL(query)
+	operation := statement
+	if i := strings.IndexByte(operation, ' '); i >= 0 {
+		operation = operation[:i]
+	}
+	operation = strings.ToUpper(operation)
+
+	return otel.Tracer(instrumentationName).Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(statement),
+		),
+	)
+}
+
+// endQuery records err, if any, and ends span
+func endQuery(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, driver.ErrSkip) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// SanitizeSQL collapses the whitespace of query and replaces string and
+// numeric literals with "?", so that values written into the SQL rather
+// than passed as arguments do not end up in traces. Placeholders such as
+// $1 are kept.
+func SanitizeSQL(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	space := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = b.Len() > 0
+			continue
+		case c == '\'':
+			// Skip to the closing quote; '' is an escaped quote
+			for i++; i < len(query); i++ {
+				if query[i] == '\'' {
+					if i+1 < len(query) && query[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			c = '?'
+		case isDigit(c) && !continuesIdentifier(query, i):
+			for i+1 < len(query) && (isDigit(query[i+1]) || query[i+1] == '.') {
+				i++
+			}
+			c = '?'
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// continuesIdentifier reports whether the digit at query[i] is part of an
+// identifier or placeholder, as in "table2" or "$1", rather than a literal
+func continuesIdentifier(query string, i int) bool {
+	if i == 0 {
+		return false
+	}
+	prev := rune(query[i-1])
+	return prev == '$' || prev == '_' || unicode.IsLetter(prev) || unicode.IsDigit(prev)
+}
@@ -0,0 +1,36 @@
+package tracing
+
+import "testing"
+
+func TestSanitizeSQL(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: `
+				SELECT id, username, email, password_hash
+				FROM users
+				WHERE email = $1
+			`,
+			want: "SELECT id, username, email, password_hash FROM users WHERE email = $1",
+		},
+		{
+			query: "SELECT * FROM users WHERE email = 'cook@example.com' AND password_hash = 'it''s secret'",
+			want:  "SELECT * FROM users WHERE email = ? AND password_hash = ?",
+		},
+		{
+			query: "UPDATE recipes SET rating = 4.5 WHERE id = 12 AND step2 = $10",
+			want:  "UPDATE recipes SET rating = ? WHERE id = ? AND step2 = $10",
+		},
+		{
+			query: "SELECT 'unterminated",
+			want:  "SELECT ?",
+		},
+	}
+	for _, tt := range tests {
+		if got := SanitizeSQL(tt.query); got != tt.want {
+			t.Errorf("SanitizeSQL(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Package tracing sets up OpenTelemetry tracing: spans are batched and
+// exported to an OTLP/HTTP collector, and SQL statements get spans of their
+// own through a traced database connector.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Options configures span export
+type Options struct {
+	// Endpoint is the base URL of the collector; spans are posted to its
+	// /v1/traces path
+	Endpoint    string
+	ServiceName string
+	// SampleRatio is the fraction of new traces that are recorded
+	SampleRatio float64
+}
+
+// Setup installs a global tracer provider that exports to opts.Endpoint and
+// W3C trace context propagation, so that requests continue their callers'
+// traces. The returned function flushes pending spans and stops export.
+func Setup(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	u, err := url.Parse(opts.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parsing tracing endpoint: %w", err)
+	}
+	exporterOpts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithURLPath(path.Join("/", u.Path, "v1/traces")),
+	}
+	if u.Scheme == "http" {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating span exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(opts.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,188 @@
+package tracing_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	"backend-app/middleware"
+	"backend-app/store"
+	"backend-app/tracing"
+)
+
+// collector is an in-process OTLP/HTTP collector that keeps every span it
+// receives
+type collector struct {
+	mu    sync.Mutex
+	spans []*tracepb.Span
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/traces" {
+		http.NotFound(w, r)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req collectortrace.ExportTraceServiceRequest
+	if err := proto.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	for _, resource := range req.ResourceSpans {
+		for _, scope := range resource.ScopeSpans {
+			c.spans = append(c.spans, scope.Spans...)
+		}
+	}
+	c.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	out, _ := proto.Marshal(&collectortrace.ExportTraceServiceResponse{})
+	w.Write(out)
+}
+
+// span returns the received span called name
+func (c *collector) span(t *testing.T, name string) *tracepb.Span {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, span := range c.spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("no span named %q among %d spans", name, len(c.spans))
+	return nil
+}
+
+// attribute returns the string value of the span attribute key
+func attribute(span *tracepb.Span, key string) string {
+	for _, attr := range span.Attributes {
+		if attr.Key == key {
+			return attr.Value.GetStringValue()
+		}
+	}
+	return ""
+}
+
+// userConnector opens connections that answer every query with one user
+type userConnector struct{}
+
+func (userConnector) Connect(context.Context) (driver.Conn, error) { return userConn{}, nil }
+func (userConnector) Driver() driver.Driver                        { return nil }
+
+type userConn struct{}
+
+func (userConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (userConn) Close() error                        { return nil }
+func (userConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (userConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &userRows{}, nil
+}
+
+type userRows struct{ done bool }
+
+func (r *userRows) Columns() []string { return []string{"id", "username", "email", "password_hash"} }
+func (r *userRows) Close() error      { return nil }
+
+func (r *userRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0], dest[1], dest[2], dest[3] = int64(7), "cook", "cook@example.com", "hash"
+	return nil
+}
+
+func TestRequestAndQuerySpansAreExported(t *testing.T) {
+	spans := &collector{}
+	server := httptest.NewServer(spans)
+	defer server.Close()
+
+	shutdown, err := tracing.Setup(context.Background(), tracing.Options{
+		Endpoint:    server.URL,
+		ServiceName: "backend-app-test",
+		SampleRatio: 1,
+	})
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+
+	db := sql.OpenDB(tracing.WrapConnector(userConnector{}))
+	defer db.Close()
+	users := &store.InstrumentedUserStore{Next: store.NewPostgresUserStore(db)}
+
+	router := mux.NewRouter()
+	router.Use(middleware.RecordRoute)
+	router.HandleFunc("/users/{email}", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := users.GetUserByEmail(r.Context(), mux.Vars(r)["email"]); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	// The caller's trace is continued
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest("GET", "/users/cook@example.com", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	middleware.Trace(router).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	// Shutting down flushes the batched spans to the collector
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	request := spans.span(t, "GET /users/{email}")
+	method := spans.span(t, "GetUserByEmail")
+	query := spans.span(t, "SELECT")
+
+	if got := hex.EncodeToString(request.TraceId); got != traceID {
+		t.Errorf("trace ID = %s, want the caller's %s", got, traceID)
+	}
+	if request.Kind != tracepb.Span_SPAN_KIND_SERVER {
+		t.Errorf("request span kind = %v, want server", request.Kind)
+	}
+	if got := attribute(request, "http.route"); got != "/users/{email}" {
+		t.Errorf("http.route = %q", got)
+	}
+	if string(method.ParentSpanId) != string(request.SpanId) {
+		t.Error("GetUserByEmail span is not a child of the request span")
+	}
+	if string(query.ParentSpanId) != string(method.SpanId) {
+		t.Error("SELECT span is not a child of the GetUserByEmail span")
+	}
+
+	statement := attribute(query, "db.query.text")
+	if want := "SELECT id, username, email, password_hash FROM users WHERE email = $1 AND deleted_at IS NULL"; statement != want {
+		t.Errorf("db.query.text = %q, want %q", statement, want)
+	}
+	for _, span := range spans.spans {
+		for _, attr := range span.Attributes {
+			if span != request && strings.Contains(attr.Value.GetStringValue(), "cook@example.com") {
+				t.Errorf("span %q leaks the query argument in %s", span.Name, attr.Key)
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Package trash permanently deletes users, categories and recipes once
+// they have been deleted for longer than the retention window.
+package trash
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RecipePurger permanently deletes recipes deleted before a time
+type RecipePurger interface {
+	PurgeRecipes(ctx context.Context, before time.Time) (int64, error)
+}
+
+// UserPurger permanently deletes accounts deleted before a time
+type UserPurger interface {
+	PurgeUsers(ctx context.Context, before time.Time) (int64, error)
+}
+
+// CategoryPurger permanently deletes categories deleted before a time
+type CategoryPurger interface {
+	PurgeCategories(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Purger empties the trash of everything deleted more than Retention ago.
+// Recipes go first, since accounts are only purged once their recipes are.
+type Purger struct {
+	Recipes    RecipePurger
+	Users      UserPurger
+	Categories CategoryPurger
+	Retention  time.Duration
+}
+
+// Counts reports how many records a purge deleted
+type Counts struct {
+	Recipes    int64
+	Users      int64
+	Categories int64
+}
+
+// NewPurger initializes a new Purger
+func NewPurger(recipes RecipePurger, users UserPurger, categories CategoryPurger, retention time.Duration) *Purger {
+	return &Purger{
+		Recipes:    recipes,
+		Users:      users,
+		Categories: categories,
+		Retention:  retention,
+	}
+}
+
+// Purge performs a single pass and returns what it deleted
+func (p *Purger) Purge(ctx context.Context) (Counts, error) {
+	var counts Counts
+	before := time.Now().Add(-p.Retention)
+
+	var err error
+	if counts.Recipes, err = p.Recipes.PurgeRecipes(ctx, before); err != nil {
+		return counts, err
+	}
+	if counts.Users, err = p.Users.PurgeUsers(ctx, before); err != nil {
+		return counts, err
+	}
+	if counts.Categories, err = p.Categories.PurgeCategories(ctx, before); err != nil {
+		return counts, err
+	}
+	return counts, nil
+}
+
+// Run purges every interval until ctx is cancelled
+func (p *Purger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			counts, err := p.Purge(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "purging trash", "error", err)
+			}
+			if counts != (Counts{}) {
+				slog.InfoContext(ctx, "purged trash", "recipes", counts.Recipes, "users", counts.Users, "categories", counts.Categories)
+			}
+		}
+	}
+}
@@ -1,114 +1,42 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
+	"os"
 
+	"backend-app/config"
+	"backend-app/migrations"
 	_ "github.com/lib/pq"
 )
 
-const (
-	host     = "localhost"
-	port     = 5432
-	user     = "postgres"
-	password = "azme07@"
-	dbname   = "food_recipes"
-)
-
 func main() {
-	// Establish a connection to the PostgreSQL database
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable", host, port, user, password, dbname)
-	db, err := sql.Open("postgres", connStr)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer db.Close()
-
-	// Create tables
-	createUsersTable(db)
-	createRecipesTable(db)
-	createStepsTable(db)
-	createIngredientsTable(db)
-	createCategoriesTable(db)
-
-	fmt.Println("Tables created successfully")
-}
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML configuration file")
+	flag.Parse()
 
-func createUsersTable(db *sql.DB) {
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS users (
-			id SERIAL PRIMARY KEY,
-			username VARCHAR(100) NOT NULL,
-			email VARCHAR(255) UNIQUE NOT NULL,
-			password_hash VARCHAR(255) NOT NULL
-		)
-	`)
+	// Use the same configuration, and database credentials, as the server
+	cfg, err := config.Load(*configPath)
 	if err != nil {
 		log.Fatal(err)
 	}
-}
 
-func createRecipesTable(db *sql.DB) {
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS recipes (
-			id SERIAL PRIMARY KEY,
-			title VARCHAR(255) NOT NULL,
-			description TEXT,
-			prep_time INT,
-			category_id INT,
-			creator_id INT NOT NULL,
-			created_at TIMESTAMP DEFAULT current_timestamp,
-			updated_at TIMESTAMP DEFAULT current_timestamp,
-			FOREIGN KEY (category_id) REFERENCES categories(id),
-			FOREIGN KEY (creator_id) REFERENCES users(id)
-		)
-	`)
-	if err != nil {
-		log.Fatal(err)
-	}
-}
-
-func createStepsTable(db *sql.DB) {
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS steps (
-			id SERIAL PRIMARY KEY,
-			recipe_id INT NOT NULL,
-			step_number INT NOT NULL,
-			description TEXT,
-			FOREIGN KEY (recipe_id) REFERENCES recipes(id)
-		)
-	`)
+	// Establish a connection to the PostgreSQL database
+	db, err := sql.Open("postgres", cfg.Database.DSN())
 	if err != nil {
 		log.Fatal(err)
 	}
-}
+	defer db.Close()
 
-func createIngredientsTable(db *sql.DB) {
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS ingredients (
-			id SERIAL PRIMARY KEY,
-			recipe_id INT NOT NULL,
-			name VARCHAR(255),
-			quantity VARCHAR(50),
-			FOREIGN KEY (recipe_id) REFERENCES recipes(id)
-		)
-	`)
+	// Create or upgrade tables
+	applied, err := migrations.Apply(context.Background(), db)
 	if err != nil {
 		log.Fatal(err)
 	}
-}
 
-func createCategoriesTable(db *sql.DB) {
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS categories (
-			id SERIAL PRIMARY KEY,
-			name VARCHAR(100) UNIQUE NOT NULL
-		)
-	`)
-	if err != nil {
-		log.Fatal(err)
-	}
+	fmt.Printf("Applied %d migrations\n", applied)
 }
 
 // CREATE TABLE users (
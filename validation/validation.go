@@ -0,0 +1,113 @@
+// Package validation checks request DTOs against their `validate` struct
+// tags and reports every violation as problem field errors.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"backend-app/problem"
+	"github.com/go-playground/validator/v10"
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by their JSON names so clients can match errors to the
+	// payload they sent
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		switch name {
+		case "-":
+			if form := field.Tag.Get("form"); form != "" {
+				return form
+			}
+			return ""
+		case "":
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// Struct validates v and returns a validation problem listing all violated
+// rules, or nil if v is valid
+func Struct(v interface{}) *problem.Problem {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return problem.Internal()
+	}
+
+	fieldErrors := make([]problem.FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		field := fieldPath(fe)
+		fieldErrors = append(fieldErrors, problem.FieldError{
+			Field:   field,
+			Code:    fe.Tag(),
+			Message: field + " " + message(fe),
+		})
+	}
+	return problem.Validation(fieldErrors...)
+}
+
+// fieldPath turns a validator namespace such as
+// "UpdateRecipeRequest.RecipeFields.ingredients[2]" into the JSON path
+// "ingredients[2]". Go struct names are capitalized while every JSON name in
+// the API is lower case, which is how the struct and embedded-struct
+// segments are told apart.
+func fieldPath(fe validator.FieldError) string {
+	var path []string
+	for _, segment := range strings.Split(fe.Namespace(), ".") {
+		if segment != "" && unicode.IsUpper(rune(segment[0])) {
+			continue
+		}
+		path = append(path, segment)
+	}
+	return strings.Join(path, ".")
+}
+
+// message describes a violated rule in plain English
+func message(fe validator.FieldError) string {
+	unit := "characters"
+	switch fe.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = "items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		unit = ""
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min", "gte":
+		if unit == "" {
+			return "must be at least " + fe.Param()
+		}
+		return fmt.Sprintf("must contain at least %s %s", fe.Param(), unit)
+	case "max", "lte":
+		if unit == "" {
+			return "must be at most " + fe.Param()
+		}
+		return fmt.Sprintf("must contain at most %s %s", fe.Param(), unit)
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "oneof":
+		return "must be one of: " + fe.Param()
+	}
+	return "is invalid (" + fe.Tag() + ")"
+}